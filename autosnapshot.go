@@ -0,0 +1,86 @@
+package gector
+
+import (
+	"encoding/gob"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// StartAutoSnapshot saves the index to path every interval in a background
+// goroutine, in the format of Save. Each snapshot is written to a temporary
+// file in the same directory and renamed over path, so path always holds a
+// complete snapshot. The read lock is only held while the index is copied,
+// not while it is encoded and written. A snapshot that fails to be written
+// is skipped and retried on the next tick; the error is kept for
+// LastSnapshotError.
+//
+// The returned stop function terminates the goroutine and waits for a
+// snapshot in progress to finish. It is safe to call more than once.
+func (hnsw *HNSW) StartAutoSnapshot(path string, interval time.Duration) (stop func()) {
+	done := make(chan struct{})
+	finished := make(chan struct{})
+	go func() {
+		defer close(finished)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				err := hnsw.writeSnapshotFile(path)
+				hnsw.snapshotErrMu.Lock()
+				hnsw.snapshotErr = err
+				hnsw.snapshotErrMu.Unlock()
+			}
+		}
+	}()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			close(done)
+			<-finished
+		})
+	}
+}
+
+// LastSnapshotError returns the error of the latest snapshot written by
+// StartAutoSnapshot, or nil when it succeeded or none was written yet.
+func (hnsw *HNSW) LastSnapshotError() error {
+	hnsw.snapshotErrMu.Lock()
+	defer hnsw.snapshotErrMu.Unlock()
+	return hnsw.snapshotErr
+}
+
+// writeSnapshotFile atomically replaces path with a snapshot of the index.
+func (hnsw *HNSW) writeSnapshotFile(path string) error {
+	hnsw.snapshotting.Add(1)
+	defer hnsw.snapshotting.Add(-1)
+
+	locked := hnsw.readLock()
+	// The snapshot copies the neighbor lists, which later inserts modify in place
+	s := hnsw.snapshot()
+	hnsw.readUnlock(locked)
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if err := gob.NewEncoder(tmp).Encode(s); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
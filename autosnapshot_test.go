@@ -0,0 +1,83 @@
+package gector
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// Test for StartAutoSnapshot writing a loadable snapshot in the background
+func TestStartAutoSnapshot(t *testing.T) {
+	hnswIndex := NewHNSW(5, 3)
+	for i := 0; i < 50; i++ {
+		hnswIndex.AddVector(fmt.Sprintf("vec-%d", i), generateRandomVector(4))
+	}
+
+	path := filepath.Join(t.TempDir(), "index.gob")
+	stop := hnswIndex.StartAutoSnapshot(path, 10*time.Millisecond)
+
+	// Keep writing while snapshots are taken
+	for i := 50; i < 100; i++ {
+		hnswIndex.AddVector(fmt.Sprintf("vec-%d", i), generateRandomVector(4))
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		if _, err := os.Stat(path); err == nil {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("Expected a snapshot at %s within 5s", path)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	stop()
+	stop()
+
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("Error opening snapshot: %v", err)
+	}
+	defer f.Close()
+	loaded, err := Load(f)
+	if err != nil {
+		t.Fatalf("Error loading snapshot: %v", err)
+	}
+	if len(loaded.nodes) < 50 {
+		t.Errorf("Expected at least 50 vectors in the snapshot, but got %d", len(loaded.nodes))
+	}
+
+	// No temporary files are left behind
+	entries, _ := os.ReadDir(filepath.Dir(path))
+	if len(entries) != 1 {
+		t.Errorf("Expected only the snapshot file, but got %d entries", len(entries))
+	}
+	if err := hnswIndex.LastSnapshotError(); err != nil {
+		t.Errorf("Expected no snapshot error, but got %v", err)
+	}
+}
+
+// Test for StartAutoSnapshot reporting a snapshot that cannot be written
+func TestStartAutoSnapshotError(t *testing.T) {
+	hnswIndex := NewHNSW(5, 3)
+	hnswIndex.AddVector("vec-0", generateRandomVector(4))
+
+	path := filepath.Join(t.TempDir(), "missing", "index.gob")
+	stop := hnswIndex.StartAutoSnapshot(path, 10*time.Millisecond)
+	defer stop()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for hnswIndex.LastSnapshotError() == nil {
+		if time.Now().After(deadline) {
+			t.Fatalf("Expected a snapshot error within 5s")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	if err := hnswIndex.LastSnapshotError(); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("Expected a missing directory error, but got %v", err)
+	}
+}
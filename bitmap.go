@@ -0,0 +1,117 @@
+package gector
+
+import "math/bits"
+
+// bitmap is a growable set of node slots.
+type bitmap []uint64
+
+// set adds slot i to the set.
+func (b *bitmap) set(i int) {
+	word := i / 64
+	for len(*b) <= word {
+		*b = append(*b, 0)
+	}
+	(*b)[word] |= 1 << (uint(i) % 64)
+}
+
+// clear removes slot i from the set.
+func (b bitmap) clear(i int) {
+	if word := i / 64; word < len(b) {
+		b[word] &^= 1 << (uint(i) % 64)
+	}
+}
+
+// union returns a new set holding the slots of both sets.
+func (b bitmap) union(other bitmap) bitmap {
+	if len(other) > len(b) {
+		b, other = other, b
+	}
+	result := make(bitmap, len(b))
+	copy(result, b)
+	for i, word := range other {
+		result[i] |= word
+	}
+	return result
+}
+
+// forEach calls fn for every slot in the set, in ascending order.
+func (b bitmap) forEach(fn func(i int)) {
+	for w, word := range b {
+		for word != 0 {
+			fn(w*64 + bits.TrailingZeros64(word))
+			word &= word - 1
+		}
+	}
+}
+
+// categoryKey reports whether a metadata value can be indexed by a
+// categorical bitmap, which needs it to be usable as a map key.
+func categoryKey(value any) bool {
+	switch value.(type) {
+	case string, bool, int, int32, int64, uint, uint32, uint64, float32, float64:
+		return true
+	}
+	return false
+}
+
+// registerNode stores a node and assigns it a slot for the categorical
+// bitmaps, replacing any node with the same ID. The caller must hold the write lock.
+func (hnsw *HNSW) registerNode(node *HNSWNode) {
+	if old, exists := hnsw.nodes[node.ID]; exists {
+		hnsw.unregisterNode(old)
+	}
+
+	if n := len(hnsw.freeSlots); n > 0 {
+		node.slot = hnsw.freeSlots[n-1]
+		hnsw.freeSlots = hnsw.freeSlots[:n-1]
+		hnsw.slots[node.slot] = node
+	} else {
+		node.slot = len(hnsw.slots)
+		hnsw.slots = append(hnsw.slots, node)
+	}
+	hnsw.nodes[node.ID] = node
+	hnsw.size.Store(int64(len(hnsw.nodes)))
+	hnsw.updateCentroid(node.Vector, true)
+	hnsw.indexCategories(node)
+	hnsw.trackAccess(node)
+}
+
+// unregisterNode removes a node and frees its slot. The caller must hold the write lock.
+func (hnsw *HNSW) unregisterNode(node *HNSWNode) {
+	delete(hnsw.nodes, node.ID)
+	hnsw.untrackAccess(node)
+	hnsw.size.Store(int64(len(hnsw.nodes)))
+	hnsw.updateCentroid(node.Vector, false)
+	if node.slot >= len(hnsw.slots) || hnsw.slots[node.slot] != node {
+		return
+	}
+
+	hnsw.unindexCategories(node)
+	hnsw.slots[node.slot] = nil
+	hnsw.freeSlots = append(hnsw.freeSlots, node.slot)
+}
+
+// indexCategories sets the node's slot in the bitmaps of its categorical
+// metadata values. The caller must hold the write lock.
+func (hnsw *HNSW) indexCategories(node *HNSWNode) {
+	for field, values := range hnsw.categories {
+		if value, ok := node.Metadata[field]; ok && categoryKey(value) {
+			if values[value] == nil {
+				values[value] = &bitmap{}
+			}
+			values[value].set(node.slot)
+		}
+	}
+}
+
+// unindexCategories clears the node's slot from the bitmaps of its
+// categorical metadata values. The caller must hold the write lock.
+func (hnsw *HNSW) unindexCategories(node *HNSWNode) {
+	for field, values := range hnsw.categories {
+		if value, ok := node.Metadata[field]; ok && categoryKey(value) {
+			if matches := values[value]; matches != nil {
+				matches.clear(node.slot)
+			}
+		}
+	}
+}
@@ -0,0 +1,311 @@
+package gector
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/binary"
+	"encoding/gob"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+)
+
+// compactMagic identifies files written by SaveCompact.
+const compactMagic = "GCTC"
+
+// compactVersion is the version of the compact format. Version 1 files,
+// written before the transforms were stored, version 2 files, written before
+// weights were stored, and version 3 files, written before the tag was
+// stored, are still read.
+const compactVersion = 4
+
+// compactMaxPrealloc caps how many elements LoadCompact allocates up front
+// for a count read from the file. Larger counts grow as the data is actually
+// read, so a corrupt count fails at the end of the input instead of
+// allocating memory the input cannot fill.
+const compactMaxPrealloc = 1 << 16
+
+// compactTransforms holds the transforms of the index applied to inserted
+// and queried vectors, stored after the metadata.
+type compactTransforms struct {
+	Whitener   *Whitener
+	Quantizer  *snapshotQuantizer
+	Projection *snapshotProjection
+}
+
+// SaveCompact writes the index in a compact binary format meant for archival.
+// Nodes are numbered by sorted ID, neighbor lists are stored as sorted,
+// delta-encoded varint indices, and the vector and metadata block can be
+// gzipped. Neighbor order is not preserved and neighbor IDs that no longer
+// exist in the index are dropped. Weights and the tag are kept. The
+// whitener, quantizer and projection, if any, are stored so loaded indexes
+// transform and rank queries like the saved one.
+//
+// Metadata is written with gob, as by Save: values of types other than the
+// predeclared ones, and slices and maps of them, must be registered with
+// gob.Register before saving and loading, or SaveCompact returns an error.
+func (hnsw *HNSW) SaveCompact(w io.Writer, compress bool) error {
+	locked := hnsw.readLock()
+	s := hnsw.snapshot()
+	hnsw.readUnlock(locked)
+
+	index := make(map[string]uint64, len(s.Nodes))
+	for i, n := range s.Nodes {
+		index[n.ID] = uint64(i)
+	}
+
+	bw := bufio.NewWriter(w)
+	var buf []byte
+	buf = append(buf, compactMagic...)
+	buf = append(buf, compactVersion)
+	buf = binary.AppendUvarint(buf, uint64(s.MaxNeighbors))
+	buf = binary.AppendUvarint(buf, uint64(s.MaxLevels))
+	buf = binary.AppendUvarint(buf, uint64(s.Metric))
+	buf = binary.AppendUvarint(buf, uint64(s.Dim))
+	buf = binary.AppendUvarint(buf, uint64(len(s.Nodes)))
+	if compress {
+		buf = append(buf, 1)
+	} else {
+		buf = append(buf, 0)
+	}
+	buf = binary.AppendUvarint(buf, uint64(len(s.Tag)))
+	buf = append(buf, s.Tag...)
+
+	// Graph block: IDs, levels and delta-encoded neighbor indices
+	for _, n := range s.Nodes {
+		buf = binary.AppendUvarint(buf, uint64(len(n.ID)))
+		buf = append(buf, n.ID...)
+		buf = binary.AppendUvarint(buf, uint64(n.Level))
+
+		neighbors := make([]uint64, 0, len(n.Neighbors))
+		for _, neighborID := range n.Neighbors {
+			if i, exists := index[neighborID]; exists {
+				neighbors = append(neighbors, i)
+			}
+		}
+		sort.Slice(neighbors, func(i, j int) bool { return neighbors[i] < neighbors[j] })
+
+		buf = binary.AppendUvarint(buf, uint64(len(neighbors)))
+		var previous uint64
+		for _, i := range neighbors {
+			buf = binary.AppendUvarint(buf, i-previous)
+			previous = i
+		}
+	}
+	if _, err := bw.Write(buf); err != nil {
+		return err
+	}
+
+	// Vector and metadata block, optionally gzipped
+	var block io.Writer = bw
+	var zw *gzip.Writer
+	if compress {
+		zw = gzip.NewWriter(bw)
+		block = zw
+	}
+
+	buf = buf[:0]
+	metadata := make([]map[string]any, len(s.Nodes))
+	for i, n := range s.Nodes {
+		buf = binary.AppendUvarint(buf, uint64(len(n.Values)))
+		for _, value := range n.Values {
+			buf = binary.LittleEndian.AppendUint64(buf, math.Float64bits(value))
+		}
+		buf = binary.LittleEndian.AppendUint64(buf, math.Float64bits(n.Weight))
+		metadata[i] = n.metadata()
+	}
+	if _, err := block.Write(buf); err != nil {
+		return err
+	}
+	encoder := gob.NewEncoder(block)
+	if err := encoder.Encode(metadata); err != nil {
+		return err
+	}
+	if err := encoder.Encode(compactTransforms{Whitener: s.Whitener, Quantizer: s.Quantizer, Projection: s.Projection}); err != nil {
+		return err
+	}
+
+	if zw != nil {
+		if err := zw.Close(); err != nil {
+			return err
+		}
+	}
+	return bw.Flush()
+}
+
+// LoadCompact reads an index written by SaveCompact.
+func LoadCompact(r io.Reader) (*HNSW, error) {
+	br := bufio.NewReader(r)
+
+	header := make([]byte, len(compactMagic)+1)
+	if _, err := io.ReadFull(br, header); err != nil {
+		return nil, err
+	}
+	if string(header[:len(compactMagic)]) != compactMagic {
+		return nil, fmt.Errorf("not a compact index file")
+	}
+	version := header[len(compactMagic)]
+	if version < 1 || version > compactVersion {
+		return nil, fmt.Errorf("unsupported compact format version %d", version)
+	}
+
+	var fields [5]uint64
+	for i := range fields {
+		value, err := binary.ReadUvarint(br)
+		if err != nil {
+			return nil, err
+		}
+		fields[i] = value
+	}
+	compressed, err := br.ReadByte()
+	if err != nil {
+		return nil, err
+	}
+	var tag []byte
+	if version >= 4 {
+		length, err := binary.ReadUvarint(br)
+		if err != nil {
+			return nil, err
+		}
+		if tag, err = readCompactBytes(br, length); err != nil {
+			return nil, err
+		}
+	}
+
+	for i, limit := range []uint64{math.MaxInt32, maxSnapshotLevels, math.MaxInt32, math.MaxInt32, math.MaxInt32} {
+		if fields[i] > limit {
+			return nil, fmt.Errorf("invalid compact header field %d: %d exceeds %d", i, fields[i], limit)
+		}
+	}
+	nodeCount := fields[4]
+
+	s := snapshot{
+		Tag:          string(tag),
+		MaxNeighbors: int(fields[0]),
+		MaxLevels:    int(fields[1]),
+		Metric:       Metric(fields[2]),
+		Dim:          int(fields[3]),
+		Nodes:        make([]snapshotNode, 0, min(nodeCount, compactMaxPrealloc)),
+	}
+
+	// Graph block
+	neighborIndices := make([][]uint64, 0, min(nodeCount, compactMaxPrealloc))
+	for i := uint64(0); i < nodeCount; i++ {
+		length, err := binary.ReadUvarint(br)
+		if err != nil {
+			return nil, err
+		}
+		id, err := readCompactBytes(br, length)
+		if err != nil {
+			return nil, err
+		}
+		level, err := binary.ReadUvarint(br)
+		if err != nil {
+			return nil, err
+		}
+		if level >= fields[1] {
+			return nil, fmt.Errorf("vector with id %s has invalid level %d", id, level)
+		}
+
+		count, err := binary.ReadUvarint(br)
+		if err != nil {
+			return nil, err
+		}
+		if count > nodeCount {
+			return nil, fmt.Errorf("vector with id %s has %d neighbors among %d vectors", id, count, nodeCount)
+		}
+		indices := make([]uint64, 0, min(count, compactMaxPrealloc))
+		var previous uint64
+		for j := uint64(0); j < count; j++ {
+			delta, err := binary.ReadUvarint(br)
+			if err != nil {
+				return nil, err
+			}
+			previous += delta
+			indices = append(indices, previous)
+		}
+
+		s.Nodes = append(s.Nodes, snapshotNode{ID: string(id), Level: int(level)})
+		neighborIndices = append(neighborIndices, indices)
+	}
+	for i, indices := range neighborIndices {
+		for _, j := range indices {
+			if j >= uint64(len(s.Nodes)) {
+				return nil, fmt.Errorf("vector with id %s has invalid neighbor index %d", s.Nodes[i].ID, j)
+			}
+			s.Nodes[i].Neighbors = append(s.Nodes[i].Neighbors, s.Nodes[j].ID)
+		}
+	}
+
+	// Vector and metadata block
+	block := br
+	if compressed == 1 {
+		zr, err := gzip.NewReader(br)
+		if err != nil {
+			return nil, err
+		}
+		defer zr.Close()
+		block = bufio.NewReader(zr)
+	}
+
+	var raw [8]byte
+	for i := range s.Nodes {
+		length, err := binary.ReadUvarint(block)
+		if err != nil {
+			return nil, err
+		}
+		values := make([]float64, 0, min(length, compactMaxPrealloc))
+		for j := uint64(0); j < length; j++ {
+			if _, err := io.ReadFull(block, raw[:]); err != nil {
+				return nil, err
+			}
+			values = append(values, math.Float64frombits(binary.LittleEndian.Uint64(raw[:])))
+		}
+		s.Nodes[i].Values = values
+		if version >= 3 {
+			if _, err := io.ReadFull(block, raw[:]); err != nil {
+				return nil, err
+			}
+			s.Nodes[i].Weight = math.Float64frombits(binary.LittleEndian.Uint64(raw[:]))
+		}
+	}
+
+	var metadata []map[string]any
+	decoder := gob.NewDecoder(block)
+	if err := decoder.Decode(&metadata); err != nil {
+		return nil, err
+	}
+	if version >= 2 {
+		var transforms compactTransforms
+		if err := decoder.Decode(&transforms); err != nil {
+			return nil, err
+		}
+		s.Whitener = transforms.Whitener
+		s.Quantizer = transforms.Quantizer
+		s.Projection = transforms.Projection
+	}
+	for i := range s.Nodes {
+		if i < len(metadata) {
+			s.Nodes[i].Metadata = metadata[i]
+		}
+	}
+
+	return restoreSnapshot(s)
+}
+
+// readCompactBytes reads length bytes, allocating no more than the input
+// actually holds.
+func readCompactBytes(r io.Reader, length uint64) ([]byte, error) {
+	if length > math.MaxInt64 {
+		return nil, fmt.Errorf("invalid length %d", length)
+	}
+	data, err := io.ReadAll(io.LimitReader(r, int64(length)))
+	if err != nil {
+		return nil, err
+	}
+	if uint64(len(data)) != length {
+		return nil, io.ErrUnexpectedEOF
+	}
+	return data, nil
+}
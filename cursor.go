@@ -0,0 +1,90 @@
+package gector
+
+// Cursor yields the results of a search one at a time in ascending distance
+// order. It fetches them in batches, so only one batch of results is held in
+// memory at a time.
+type Cursor struct {
+	hnsw         *HNSW
+	query        Vector
+	queryInvNorm float64
+	// The nodes present when the cursor was created
+	nodes []*HNSWNode
+	batch int
+	// Fetched results not yet returned by Next
+	pending []SearchResult
+	// The last result fetched; later batches only hold results after it
+	last    SearchResult
+	fetched bool
+	done    bool
+}
+
+// SearchCursor returns a cursor over every stored vector in ascending distance
+// to the query, fetching ef results at a time. The cursor iterates over the
+// vectors present when it was created: vectors added later are not returned,
+// while deleted ones still are. Each batch is scored under the read lock,
+// which is not held between calls to Next.
+func (hnsw *HNSW) SearchCursor(query Vector, ef int) *Cursor {
+	defer hnsw.readUnlock(hnsw.readLock())
+
+	if hnsw.checkDimension("search", query.ID, query) != nil {
+		return &Cursor{hnsw: hnsw, done: true}
+	}
+
+	query = hnsw.transformQuery(query)
+	nodes := make([]*HNSWNode, 0, len(hnsw.nodes))
+	for _, node := range hnsw.nodes {
+		nodes = append(nodes, node)
+	}
+	return &Cursor{
+		hnsw:         hnsw,
+		query:        query,
+		queryInvNorm: inverseNorm(vectorNorm(query)),
+		nodes:        nodes,
+		batch:        max(ef, 1),
+	}
+}
+
+// Next returns the next nearest result, or false once every vector has been
+// returned.
+func (c *Cursor) Next() (SearchResult, bool) {
+	if len(c.pending) == 0 && !c.done {
+		c.fetch()
+	}
+	if len(c.pending) == 0 {
+		return SearchResult{}, false
+	}
+
+	result := c.pending[0]
+	c.pending = c.pending[1:]
+	return result, true
+}
+
+// fetch scores the nodes for the next batch of results that follow the last
+// fetched one in distance order.
+func (c *Cursor) fetch() {
+	hnsw := c.hnsw
+	defer hnsw.readUnlock(hnsw.readLock())
+
+	best := make(resultHeap, 0, c.batch)
+	for _, node := range c.nodes {
+		result := SearchResult{
+			ID:       node.ID,
+			Vector:   node.Vector,
+			Distance: hnsw.queryDistance(c.query, c.queryInvNorm, node),
+			Metadata: hnsw.resultMetadata(node),
+		}
+		if c.fetched && !resultLess(c.last, result) {
+			continue
+		}
+		best.offer(result, c.batch)
+	}
+
+	c.pending = best.sorted()
+	if len(c.pending) < c.batch {
+		c.done = true
+	}
+	if len(c.pending) > 0 {
+		c.last = c.pending[len(c.pending)-1]
+		c.fetched = true
+	}
+}
@@ -0,0 +1,42 @@
+package gector
+
+import (
+	"fmt"
+	"testing"
+)
+
+// Test for SearchCursor yielding the batch results in order
+func TestSearchCursor(t *testing.T) {
+	hnswIndex := NewHNSW(5, 4)
+	for i := 0; i < 200; i++ {
+		hnswIndex.AddVector(fmt.Sprintf("vec-%d", i), generateRandomVector(4))
+	}
+	query := generateRandomVector(4)
+	expected, _ := hnswIndex.SearchWithStats(query, 200)
+
+	cursor := hnswIndex.SearchCursor(query, 16)
+	count := 0
+	for {
+		result, ok := cursor.Next()
+		if !ok {
+			break
+		}
+		if result.ID != expected[count].ID || result.Distance != expected[count].Distance {
+			t.Fatalf("Expected %s at rank %d, but got %s", expected[count].ID, count, result.ID)
+		}
+		count++
+	}
+	if count != 200 {
+		t.Errorf("Expected the cursor to yield 200 results, but got %d", count)
+	}
+	if _, ok := cursor.Next(); ok {
+		t.Errorf("Expected an exhausted cursor to stay exhausted")
+	}
+
+	// Vectors added after the cursor was created are not returned
+	cursor = hnswIndex.SearchCursor(query, 16)
+	hnswIndex.AddVector("late", query)
+	if result, _ := cursor.Next(); result.ID == "late" {
+		t.Errorf("Expected the cursor to ignore vectors added after its creation")
+	}
+}
@@ -0,0 +1,101 @@
+package gector
+
+import "sort"
+
+// FindDuplicateClusters groups vectors whose pairwise distances are all at
+// most eps and returns the groups with more than one member. Groups are built
+// greedily: in ID order, each vector not yet grouped seeds a group and takes
+// in, nearest first, every ungrouped vector within eps of all the members so
+// far. IDs are sorted within each group, and groups are ordered by their
+// first ID, so the result is deterministic.
+func (hnsw *HNSW) FindDuplicateClusters(eps float64) [][]string {
+	defer hnsw.readUnlock(hnsw.readLock())
+
+	return hnsw.duplicateClusters(eps)
+}
+
+// MergeDuplicates keeps the first ID of every group found by
+// FindDuplicateClusters, deletes the other members, and returns how many
+// vectors were deleted. The neighbor lists that pointed at deleted vectors
+// are repaired.
+func (hnsw *HNSW) MergeDuplicates(eps float64) (int, error) {
+	hnsw.mu.Lock()
+	defer hnsw.mu.Unlock()
+
+	if hnsw.frozen.Load() {
+		return 0, ErrFrozen
+	}
+
+	deleted := make(map[string]bool)
+	for _, cluster := range hnsw.duplicateClusters(eps) {
+		for _, id := range cluster[1:] {
+			deleted[id] = true
+			hnsw.deleteVector(id)
+			hnsw.recordDelta(DeltaDelete, id, Vector{}, nil)
+		}
+	}
+
+	hnsw.recordRepair(deleted)
+	hnsw.repairNeighbors(deleted)
+	return len(deleted), nil
+}
+
+// duplicateClusters implements FindDuplicateClusters. The caller must hold the lock.
+func (hnsw *HNSW) duplicateClusters(eps float64) [][]string {
+	ids := make([]string, 0, len(hnsw.nodes))
+	for id := range hnsw.nodes {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	grouped := make(map[string]bool, len(ids))
+	var clusters [][]string
+	for _, seedID := range ids {
+		if grouped[seedID] {
+			continue
+		}
+		seed := hnsw.nodes[seedID]
+
+		// Ungrouped vectors within eps of the seed, nearest first
+		var candidates []SearchResult
+		for _, id := range ids {
+			if id == seedID || grouped[id] {
+				continue
+			}
+			if distance := hnsw.nodeDistance(seed, hnsw.nodes[id]); distance <= eps {
+				candidates = append(candidates, SearchResult{ID: id, Distance: distance})
+			}
+		}
+		if len(candidates) == 0 {
+			continue
+		}
+		sortResults(candidates)
+
+		members := []*HNSWNode{seed}
+		for _, candidate := range candidates {
+			node := hnsw.nodes[candidate.ID]
+			withinAll := true
+			for _, member := range members[1:] {
+				if hnsw.nodeDistance(node, member) > eps {
+					withinAll = false
+					break
+				}
+			}
+			if withinAll {
+				members = append(members, node)
+			}
+		}
+		if len(members) < 2 {
+			continue
+		}
+
+		cluster := make([]string, len(members))
+		for i, member := range members {
+			cluster[i] = member.ID
+			grouped[member.ID] = true
+		}
+		sort.Strings(cluster)
+		clusters = append(clusters, cluster)
+	}
+	return clusters
+}
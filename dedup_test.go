@@ -0,0 +1,71 @@
+package gector
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+// buildDuplicateIndex plants three tight clusters among well separated vectors.
+func buildDuplicateIndex() *HNSW {
+	hnswIndex := NewHNSW(5, 3)
+	for i := 0; i < 20; i++ {
+		id := fmt.Sprintf("unique-%02d", i)
+		hnswIndex.AddVector(id, Vector{ID: id, Values: []float64{float64(i) * 10, 0}})
+	}
+	planted := map[string][]float64{
+		"dup-a1": {5, 5}, "dup-a2": {5.001, 5}, "dup-a3": {5, 5.001},
+		"dup-b1": {55, 5}, "dup-b2": {55, 5.002},
+		"dup-c1": {105, 5}, "dup-c2": {105.001, 5.001}, "dup-c3": {104.999, 5}, "dup-c4": {105, 4.999},
+	}
+	for id, values := range planted {
+		hnswIndex.AddVector(id, Vector{ID: id, Values: values})
+	}
+	return hnswIndex
+}
+
+// Test for FindDuplicateClusters finding planted duplicate clusters
+func TestFindDuplicateClusters(t *testing.T) {
+	hnswIndex := buildDuplicateIndex()
+
+	clusters := hnswIndex.FindDuplicateClusters(0.01)
+	expected := [][]string{
+		{"dup-a1", "dup-a2", "dup-a3"},
+		{"dup-b1", "dup-b2"},
+		{"dup-c1", "dup-c2", "dup-c3", "dup-c4"},
+	}
+	if !reflect.DeepEqual(clusters, expected) {
+		t.Errorf("Expected clusters %v, but got %v", expected, clusters)
+	}
+
+	if clusters := hnswIndex.FindDuplicateClusters(0.0001); len(clusters) != 0 {
+		t.Errorf("Expected no clusters below the planted spread, but got %v", clusters)
+	}
+}
+
+// Test for MergeDuplicates keeping one representative per cluster
+func TestMergeDuplicates(t *testing.T) {
+	hnswIndex := buildDuplicateIndex()
+
+	if merged, _ := hnswIndex.MergeDuplicates(0.01); merged != 6 {
+		t.Errorf("Expected 6 merged vectors, but got %d", merged)
+	}
+	if len(hnswIndex.nodes) != 23 {
+		t.Errorf("Expected 23 vectors left, but got %d", len(hnswIndex.nodes))
+	}
+	for _, id := range []string{"dup-a1", "dup-b1", "dup-c1"} {
+		if _, ok := hnswIndex.GetVector(id); !ok {
+			t.Errorf("Expected representative %s to be kept", id)
+		}
+	}
+	for _, node := range hnswIndex.nodes {
+		for _, neighborID := range node.Neighbors {
+			if _, ok := hnswIndex.nodes[neighborID]; !ok {
+				t.Errorf("Expected %s to have no link to merged vector %s", node.ID, neighborID)
+			}
+		}
+	}
+	if clusters := hnswIndex.FindDuplicateClusters(0.01); len(clusters) != 0 {
+		t.Errorf("Expected no clusters after merging, but got %v", clusters)
+	}
+}
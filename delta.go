@@ -0,0 +1,258 @@
+package gector
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+)
+
+// DeltaOp identifies the kind of mutation a Delta records.
+type DeltaOp string
+
+const (
+	// DeltaAdd records an AddVector or AddVectorWithMetadata call.
+	DeltaAdd DeltaOp = "add"
+	// DeltaUpdate records an UpdateVector call.
+	DeltaUpdate DeltaOp = "update"
+	// DeltaDelete records the removal of a vector.
+	DeltaDelete DeltaOp = "delete"
+	// DeltaRename records a RenameVector call from ID to NewID.
+	DeltaRename DeltaOp = "rename"
+	// DeltaMetadata records the replacement of a vector's metadata.
+	DeltaMetadata DeltaOp = "metadata"
+	// DeltaWeight records a SetWeight call.
+	DeltaWeight DeltaOp = "weight"
+)
+
+// Delta is a single mutation of an index, numbered by a monotonic sequence.
+type Delta struct {
+	Seq      uint64         `json:"seq"`
+	Op       DeltaOp        `json:"op"`
+	ID       string         `json:"id"`
+	Vector   Vector         `json:"vector"`
+	Metadata map[string]any `json:"metadata,omitempty"`
+	// Pinned adds place the vector on Level rather than a random level
+	Pinned bool `json:"pinned,omitempty"`
+	Level  int  `json:"level,omitempty"`
+	// New ID of a renamed vector
+	NewID string `json:"new_id,omitempty"`
+	// Restored adds hold a vector as stored by the primary, already
+	// transformed, placed on Level with these neighbors like AddVectorWithMeta
+	Restored  bool     `json:"restored,omitempty"`
+	Neighbors []string `json:"neighbors,omitempty"`
+	// Weight set by SetWeight
+	Weight float64 `json:"weight,omitempty"`
+	// IDs removed by a batch delete, such as DeleteWhere, set on its last
+	// delete; neighbor lists pointing at them are repaired after it
+	Repair []string `json:"repair,omitempty"`
+}
+
+// recordDelta appends a mutation to the delta log when it is enabled. The
+// vector's values are copied, since callers may reuse their buffer after the
+// insert. The caller must hold the write lock.
+func (hnsw *HNSW) recordDelta(op DeltaOp, id string, vector Vector, metadata map[string]any) {
+	if !hnsw.deltaLog {
+		return
+	}
+	if vector.Values != nil {
+		vector.Values = append([]float64(nil), vector.Values...)
+	}
+	hnsw.deltaSeq++
+	hnsw.deltas = append(hnsw.deltas, Delta{
+		Seq:      hnsw.deltaSeq,
+		Op:       op,
+		ID:       id,
+		Vector:   vector,
+		Metadata: metadata,
+	})
+}
+
+// recordPinnedDelta records an AddVectorAtLevel call. The caller must hold the write lock.
+func (hnsw *HNSW) recordPinnedDelta(id string, vector Vector, level int) {
+	hnsw.recordDelta(DeltaAdd, id, vector, nil)
+	if hnsw.deltaLog {
+		last := &hnsw.deltas[len(hnsw.deltas)-1]
+		last.Pinned = true
+		last.Level = level
+	}
+}
+
+// recordWeightDelta records a SetWeight call. The caller must hold the write lock.
+func (hnsw *HNSW) recordWeightDelta(id string, w float64) {
+	hnsw.recordDelta(DeltaWeight, id, Vector{}, nil)
+	if hnsw.deltaLog {
+		hnsw.deltas[len(hnsw.deltas)-1].Weight = w
+	}
+}
+
+// recordRepair marks the last recorded delta, the final delete of a batch, as
+// followed by a repair of the neighbor lists pointing at removed. The caller
+// must hold the write lock.
+func (hnsw *HNSW) recordRepair(removed map[string]bool) {
+	if !hnsw.deltaLog || len(removed) == 0 {
+		return
+	}
+	ids := make([]string, 0, len(removed))
+	for id := range removed {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	hnsw.deltas[len(hnsw.deltas)-1].Repair = ids
+}
+
+// recordRestoredDelta records an AddVectorWithMeta call. The caller must hold
+// the write lock.
+func (hnsw *HNSW) recordRestoredDelta(id string, vector Vector, level int, neighbors []string) {
+	hnsw.recordPinnedDelta(id, vector, level)
+	if hnsw.deltaLog {
+		last := &hnsw.deltas[len(hnsw.deltas)-1]
+		last.Restored = true
+		last.Neighbors = append([]string(nil), neighbors...)
+	}
+}
+
+// DeltaSince returns the recorded mutations with a sequence number greater
+// than seq, in order. It returns nil unless the index was created WithDeltaLog.
+func (hnsw *HNSW) DeltaSince(seq uint64) []Delta {
+	defer hnsw.readUnlock(hnsw.readLock())
+
+	var deltas []Delta
+	for _, delta := range hnsw.deltas {
+		if delta.Seq > seq {
+			deltas = append(deltas, delta)
+		}
+	}
+	return deltas
+}
+
+// TruncateDeltas drops the recorded mutations with a sequence number up to
+// and including seq, typically the lowest sequence applied by every replica,
+// and returns how many were dropped. The log otherwise grows with every
+// mutation. A replica that still needs dropped deltas gets a gap error from
+// Apply and must be reloaded from a snapshot instead.
+func (hnsw *HNSW) TruncateDeltas(seq uint64) int {
+	hnsw.mu.Lock()
+	defer hnsw.mu.Unlock()
+
+	dropped := sort.Search(len(hnsw.deltas), func(i int) bool {
+		return hnsw.deltas[i].Seq > seq
+	})
+	if dropped == 0 {
+		return 0
+	}
+	// Copy the rest so the dropped deltas can be collected
+	hnsw.deltas = append([]Delta(nil), hnsw.deltas[dropped:]...)
+	return dropped
+}
+
+// Apply replays a mutation recorded on another index. Deltas must be applied
+// in sequence order; already applied deltas are ignored and gaps are errors.
+// A replica holds the same vectors and metadata as its primary, and also the
+// same graph when both were created with the same seed.
+func (hnsw *HNSW) Apply(delta Delta) error {
+	hnsw.mu.Lock()
+	defer hnsw.mu.Unlock()
+
+	if hnsw.frozen.Load() {
+		return ErrFrozen
+	}
+
+	if delta.Seq <= hnsw.appliedSeq {
+		return nil
+	}
+	if delta.Seq != hnsw.appliedSeq+1 {
+		return fmt.Errorf("delta %d applied out of order, expected %d", delta.Seq, hnsw.appliedSeq+1)
+	}
+
+	var removed map[string]bool
+	switch delta.Op {
+	case DeltaAdd:
+		if delta.Restored {
+			values := append([]float64(nil), delta.Vector.Values...)
+			if err := hnsw.addRestored(delta.ID, values, delta.Level, delta.Neighbors); err != nil {
+				return err
+			}
+		} else if delta.Pinned {
+			if delta.Level < 0 || delta.Level >= hnsw.MaxLevels {
+				return fmt.Errorf("level %d out of range [0, %d)", delta.Level, hnsw.MaxLevels)
+			}
+			hnsw.addVectorAtLevel(delta.ID, delta.Vector, delta.Metadata, delta.Level)
+		} else {
+			hnsw.addVector(delta.ID, delta.Vector, delta.Metadata)
+		}
+	case DeltaUpdate:
+		node, exists := hnsw.nodes[delta.ID]
+		if !exists {
+			return notFoundError("apply", delta.ID)
+		}
+		hnsw.deleteVector(delta.ID)
+		hnsw.addVector(delta.ID, delta.Vector, node.Metadata)
+		hnsw.nodes[delta.ID].weight = node.weight
+	case DeltaDelete:
+		hnsw.deleteVector(delta.ID)
+		if len(delta.Repair) > 0 {
+			removed = make(map[string]bool, len(delta.Repair))
+			for _, id := range delta.Repair {
+				removed[id] = true
+			}
+			hnsw.repairNeighbors(removed)
+		}
+	case DeltaMetadata:
+		node, exists := hnsw.nodes[delta.ID]
+		if !exists {
+			return notFoundError("apply", delta.ID)
+		}
+		hnsw.setMetadata(node, delta.Metadata)
+	case DeltaRename:
+		if err := hnsw.renameVector(delta.ID, delta.NewID); err != nil {
+			return err
+		}
+	case DeltaWeight:
+		if err := hnsw.setWeight(delta.ID, delta.Weight); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("unknown delta op %q", delta.Op)
+	}
+
+	hnsw.appliedSeq = delta.Seq
+	if delta.Restored {
+		hnsw.recordRestoredDelta(delta.ID, delta.Vector, delta.Level, delta.Neighbors)
+	} else if delta.Pinned {
+		hnsw.recordPinnedDelta(delta.ID, delta.Vector, delta.Level)
+	} else if delta.Op == DeltaRename {
+		hnsw.recordRenameDelta(delta.ID, delta.NewID)
+	} else if delta.Op == DeltaWeight {
+		hnsw.recordWeightDelta(delta.ID, delta.Weight)
+	} else {
+		hnsw.recordDelta(delta.Op, delta.ID, delta.Vector, delta.Metadata)
+	}
+	hnsw.recordRepair(removed)
+	return nil
+}
+
+// EncodeDeltas writes deltas to w as a stream of JSON objects.
+func EncodeDeltas(w io.Writer, deltas []Delta) error {
+	encoder := json.NewEncoder(w)
+	for _, delta := range deltas {
+		if err := encoder.Encode(delta); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// DecodeDeltas reads a stream written by EncodeDeltas.
+func DecodeDeltas(r io.Reader) ([]Delta, error) {
+	decoder := json.NewDecoder(r)
+	var deltas []Delta
+	for decoder.More() {
+		var delta Delta
+		if err := decoder.Decode(&delta); err != nil {
+			return nil, err
+		}
+		deltas = append(deltas, delta)
+	}
+	return deltas, nil
+}
@@ -0,0 +1,172 @@
+package gector
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+)
+
+// Test for replaying a recorded delta stream on a fresh replica
+func TestDeltaReplication(t *testing.T) {
+	primary := NewHNSW(5, 4, WithSeed(1), WithDeltaLog())
+	replica := NewHNSW(5, 4, WithSeed(1))
+
+	for i := 0; i < 20; i++ {
+		id := fmt.Sprintf("vec-%d", i)
+		primary.AddVectorWithMetadata(id, generateRandomVector(5), map[string]any{"n": float64(i)})
+	}
+	if err := primary.UpdateVector("vec-3", generateRandomVector(5)); err != nil {
+		t.Fatalf("Error updating vector: %v", err)
+	}
+	primary.DeleteVector("vec-7")
+	if err := primary.AddVectorAtLevel("hub", generateRandomVector(5), 0); err != nil {
+		t.Fatalf("Error adding pinned vector: %v", err)
+	}
+
+	deltas := primary.DeltaSince(0)
+	if len(deltas) != 23 {
+		t.Fatalf("Expected 23 deltas, but got %d", len(deltas))
+	}
+	for i, delta := range deltas {
+		if delta.Seq != uint64(i+1) {
+			t.Fatalf("Expected delta %d to have sequence %d, but got %d", i, i+1, delta.Seq)
+		}
+	}
+
+	// Round-trip the stream through its encoding
+	var buf bytes.Buffer
+	if err := EncodeDeltas(&buf, deltas); err != nil {
+		t.Fatalf("Error encoding deltas: %v", err)
+	}
+	decoded, err := DecodeDeltas(&buf)
+	if err != nil {
+		t.Fatalf("Error decoding deltas: %v", err)
+	}
+
+	for _, delta := range decoded {
+		if err := replica.Apply(delta); err != nil {
+			t.Fatalf("Error applying delta %d: %v", delta.Seq, err)
+		}
+	}
+
+	if !primary.StructurallyEqual(replica) {
+		t.Errorf("Expected the replica to match the primary after applying the deltas")
+	}
+	if replica.nodes["vec-5"].Metadata["n"] != float64(5) {
+		t.Errorf("Expected metadata to be replicated, but got %v", replica.nodes["vec-5"].Metadata)
+	}
+
+	// Later deltas are picked up incrementally and replays are ignored
+	primary.AddVector("vec-20", generateRandomVector(5))
+	for _, delta := range primary.DeltaSince(23) {
+		if err := replica.Apply(delta); err != nil {
+			t.Fatalf("Error applying delta %d: %v", delta.Seq, err)
+		}
+	}
+	if _, exists := replica.nodes["vec-20"]; !exists {
+		t.Errorf("Expected 'vec-20' to be replicated")
+	}
+	if err := replica.Apply(Delta{Seq: 30, Op: DeltaAdd, ID: "gap"}); err == nil {
+		t.Errorf("Expected an error applying a delta after a gap, but got nil")
+	}
+}
+
+// Test for the delta log keeping its own copy of inserted values
+func TestDeltaCopiesValues(t *testing.T) {
+	hnswIndex := NewHNSW(5, 4, WithDeltaLog())
+	buf := []float64{1, 2, 3}
+	hnswIndex.AddVector("a", Vector{ID: "a", Values: buf})
+	if err := hnswIndex.AddVectorAtLevel("b", Vector{ID: "b", Values: buf}, 0); err != nil {
+		t.Fatalf("Error adding pinned vector: %v", err)
+	}
+	buf[0] = 100
+
+	for _, delta := range hnswIndex.DeltaSince(0) {
+		if delta.Vector.Values[0] != 1 {
+			t.Errorf("Expected delta %d to keep the inserted value 1, but got %v", delta.Seq, delta.Vector.Values[0])
+		}
+	}
+}
+
+// Test for replicas repairing neighbor lists after replayed batch deletes
+func TestDeltaReplicationBatchDeletes(t *testing.T) {
+	primary := NewHNSW(5, 4, WithSeed(2), WithDeltaLog())
+	replica := NewHNSW(5, 4, WithSeed(2))
+
+	for i := 0; i < 200; i++ {
+		id := fmt.Sprintf("vec-%d", i)
+		primary.AddVectorWithMetadata(id, generateRandomVector(5), map[string]any{"n": float64(i)})
+	}
+	deleted, err := primary.DeleteWhere(func(id string, meta map[string]any) bool {
+		return int(meta["n"].(float64))%3 == 0
+	})
+	if err != nil || deleted == 0 {
+		t.Fatalf("Expected DeleteWhere to delete vectors, but got %d, %v", deleted, err)
+	}
+	for i := 0; i < 50; i++ {
+		primary.SearchWithStats(generateRandomVector(5), 5)
+	}
+	if trimmed, _ := primary.TrimToTop(60); trimmed == 0 {
+		t.Fatalf("Expected TrimToTop to delete vectors")
+	}
+
+	repairs := 0
+	for _, delta := range primary.DeltaSince(0) {
+		if len(delta.Repair) > 0 {
+			repairs++
+		}
+		if err := replica.Apply(delta); err != nil {
+			t.Fatalf("Error applying delta %d: %v", delta.Seq, err)
+		}
+	}
+	if repairs != 2 {
+		t.Errorf("Expected a repair on the last delete of each batch, but got %d", repairs)
+	}
+	if !primary.StructurallyEqual(replica) {
+		t.Errorf("Expected the replica graph to match the primary after the batch deletes")
+	}
+}
+
+// Test for TruncateDeltas dropping the deltas every replica has applied
+func TestTruncateDeltas(t *testing.T) {
+	primary := NewHNSW(5, 4, WithSeed(1), WithDeltaLog())
+	replica := NewHNSW(5, 4, WithSeed(1))
+	for i := 0; i < 10; i++ {
+		primary.AddVector(fmt.Sprintf("vec-%d", i), generateRandomVector(3))
+	}
+	for _, delta := range primary.DeltaSince(0) {
+		if err := replica.Apply(delta); err != nil {
+			t.Fatalf("Error applying delta %d: %v", delta.Seq, err)
+		}
+	}
+
+	if dropped := primary.TruncateDeltas(6); dropped != 6 {
+		t.Errorf("Expected 6 dropped deltas, but got %d", dropped)
+	}
+	if deltas := primary.DeltaSince(0); len(deltas) != 4 || deltas[0].Seq != 7 {
+		t.Fatalf("Expected deltas 7 to 10 to remain, but got %d starting at %d", len(deltas), deltas[0].Seq)
+	}
+	if dropped := primary.TruncateDeltas(3); dropped != 0 {
+		t.Errorf("Expected nothing dropped below the log, but got %d", dropped)
+	}
+
+	// Sequence numbers keep counting, so replicas carry on from where they were
+	primary.AddVector("vec-10", generateRandomVector(3))
+	for _, delta := range primary.DeltaSince(10) {
+		if err := replica.Apply(delta); err != nil {
+			t.Fatalf("Error applying delta %d: %v", delta.Seq, err)
+		}
+	}
+	if !primary.StructurallyEqual(replica) {
+		t.Errorf("Expected the replica to match the primary after truncating")
+	}
+
+	// A replica needing dropped deltas sees a gap
+	late := NewHNSW(5, 4, WithSeed(1))
+	if err := late.Apply(primary.DeltaSince(0)[0]); err == nil {
+		t.Errorf("Expected a gap error for a replica behind the truncated log, but got nil")
+	}
+	if dropped := primary.TruncateDeltas(100); dropped != 5 {
+		t.Errorf("Expected the 5 remaining deltas dropped, but got %d", dropped)
+	}
+}
@@ -0,0 +1,99 @@
+package gector
+
+import "math"
+
+// Metric selects the distance function an index uses to compare vectors.
+type Metric int
+
+const (
+	// Euclidean compares vectors by their L2 distance.
+	Euclidean Metric = iota
+	// Cosine compares vectors by one minus their cosine similarity.
+	Cosine
+)
+
+// DistanceFunc calculates the distance between two vectors.
+type DistanceFunc func(a, b Vector) float64
+
+// EuclideanDistance calculates the L2 distance between two vectors.
+func EuclideanDistance(a, b Vector) float64 {
+	return euclideanDistance(a, b)
+}
+
+// CosineDistance calculates one minus the cosine similarity of two vectors.
+func CosineDistance(a, b Vector) float64 {
+	return cosineDistance(a, b)
+}
+
+// queryDistance calculates the distance from a query to a stored node. The
+// query's inverse norm is passed in so it is computed only once per search.
+func (hnsw *HNSW) queryDistance(query Vector, queryInvNorm float64, node *HNSWNode) float64 {
+	if hnsw.distance != nil {
+		return hnsw.distance(query, node.Vector)
+	}
+	if hnsw.Metric == Cosine {
+		return 1 - dotProduct(query, node.Vector)*queryInvNorm*node.invNorm
+	}
+	return euclideanDistance(query, node.Vector)
+}
+
+// nodeDistance calculates the distance between two stored nodes.
+func (hnsw *HNSW) nodeDistance(a, b *HNSWNode) float64 {
+	return hnsw.queryDistance(a.Vector, a.invNorm, b)
+}
+
+// dotProduct calculates the dot product of two vectors.
+func dotProduct(v1, v2 Vector) float64 {
+	return DotRaw(v1.Values, v2.Values)
+}
+
+// inverseNorm returns 1/norm, or 0 for a zero vector so its cosine distance is 1.
+func inverseNorm(norm float64) float64 {
+	if norm == 0 {
+		return 0
+	}
+	return 1 / norm
+}
+
+// cosineDistance calculates one minus the cosine similarity of two vectors.
+func cosineDistance(v1, v2 Vector) float64 {
+	return CosineRaw(v1.Values, v2.Values)
+}
+
+// EuclideanRaw calculates the L2 distance between two slices of equal length.
+func EuclideanRaw(a, b []float64) float64 {
+	var sum float64
+	for i := 0; i < len(a); i++ {
+		diff := a[i] - b[i]
+		sum += diff * diff
+	}
+	return math.Sqrt(sum)
+}
+
+// DotRaw calculates the dot product of two slices of equal length.
+func DotRaw(a, b []float64) float64 {
+	var sum float64
+	for i := 0; i < len(a); i++ {
+		sum += a[i] * b[i]
+	}
+	return sum
+}
+
+// NormRaw calculates the L2 norm of a slice.
+func NormRaw(a []float64) float64 {
+	var sum float64
+	for _, value := range a {
+		sum += value * value
+	}
+	return math.Sqrt(sum)
+}
+
+// CosineRaw calculates one minus the cosine similarity of two slices of
+// equal length. It is 1 when either slice is all zeros.
+func CosineRaw(a, b []float64) float64 {
+	norms := NormRaw(a) * NormRaw(b)
+	if norms == 0 {
+		return 1
+	}
+	return 1 - DotRaw(a, b)/norms
+}
@@ -0,0 +1,125 @@
+package gector
+
+import (
+	"fmt"
+	"math"
+	"testing"
+)
+
+// Test that cosine search with cached inverse norms matches the naive cosine distance
+func TestCosineMatchesNaive(t *testing.T) {
+	hnswIndex := NewHNSW(5, 4)
+	hnswIndex.Metric = Cosine
+
+	vectors := make(map[string]Vector)
+	for i := 0; i < 100; i++ {
+		id := fmt.Sprintf("vec-%d", i)
+		vectors[id] = generateScaledVector(8)
+		hnswIndex.AddVector(id, vectors[id])
+	}
+
+	query := generateScaledVector(8)
+	results := hnswIndex.search(query, len(vectors))
+	if len(results) != len(vectors) {
+		t.Fatalf("Expected %d results, but got %d", len(vectors), len(results))
+	}
+
+	for i, result := range results {
+		want := cosineDistance(query, vectors[result.ID])
+		if math.Abs(result.Distance-want) > 1e-9 {
+			t.Errorf("Expected cosine distance %f for '%s', but got %f", want, result.ID, result.Distance)
+		}
+		if i > 0 && results[i-1].Distance > result.Distance {
+			t.Errorf("Expected results to be sorted by cosine distance")
+		}
+	}
+}
+
+// Test that updating a vector refreshes its cached inverse norm
+func TestCosineUpdateVector(t *testing.T) {
+	hnswIndex := NewHNSW(5, 4)
+	hnswIndex.Metric = Cosine
+
+	hnswIndex.AddVector("vec-1", Vector{ID: "vec-1", Values: []float64{1, 0}})
+	if err := hnswIndex.UpdateVector("vec-1", Vector{ID: "vec-1", Values: []float64{0, 10}}); err != nil {
+		t.Fatalf("Error updating vector: %v", err)
+	}
+
+	results := hnswIndex.search(Vector{Values: []float64{0, 1}}, 1)
+	if len(results) != 1 || math.Abs(results[0].Distance) > 1e-9 {
+		t.Errorf("Expected a cosine distance of 0 after update, but got %v", results)
+	}
+}
+
+// Benchmark cosine search with cached inverse norms against the naive cosine distance
+func BenchmarkCosineSearch(b *testing.B) {
+	hnswIndex := NewHNSW(5, 4)
+	hnswIndex.Metric = Cosine
+	for i := 0; i < 1000; i++ {
+		hnswIndex.AddVector(fmt.Sprintf("vec-%d", i), generateRandomVector(128))
+	}
+	query := generateRandomVector(128)
+
+	b.Run("cached", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			hnswIndex.search(query, 10)
+		}
+	})
+
+	b.Run("naive", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			best := make(resultHeap, 0, 10)
+			for id, node := range hnswIndex.nodes {
+				best.offer(SearchResult{ID: id, Vector: node.Vector, Distance: cosineDistance(query, node.Vector)}, 10)
+			}
+			best.sorted()
+		}
+	})
+}
+
+// Test that the raw slice distances match the Vector versions
+func TestRawDistances(t *testing.T) {
+	a := []float64{1, 2, 3}
+	b := []float64{4, 6, 3}
+
+	if EuclideanRaw(a, b) != 5 {
+		t.Errorf("Expected a Euclidean distance of 5, but got %f", EuclideanRaw(a, b))
+	}
+	if DotRaw(a, b) != 25 {
+		t.Errorf("Expected a dot product of 25, but got %f", DotRaw(a, b))
+	}
+	if NormRaw([]float64{3, 4}) != 5 {
+		t.Errorf("Expected a norm of 5, but got %f", NormRaw([]float64{3, 4}))
+	}
+	if CosineRaw([]float64{1, 0}, []float64{0, 1}) != 1 {
+		t.Errorf("Expected a cosine distance of 1 for orthogonal slices")
+	}
+	if CosineRaw([]float64{0, 0}, b) != 1 {
+		t.Errorf("Expected a cosine distance of 1 for a zero slice")
+	}
+
+	va, vb := Vector{Values: a}, Vector{Values: b}
+	if EuclideanDistance(va, vb) != EuclideanRaw(a, b) {
+		t.Errorf("Expected EuclideanDistance to match EuclideanRaw")
+	}
+	if CosineDistance(va, vb) != CosineRaw(a, b) {
+		t.Errorf("Expected CosineDistance to match CosineRaw")
+	}
+}
+
+// Benchmark the raw slice distance against the Vector wrapper
+func BenchmarkEuclideanRaw(b *testing.B) {
+	v1 := generateRandomVector(128)
+	v2 := generateRandomVector(128)
+
+	b.Run("raw", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			EuclideanRaw(v1.Values, v2.Values)
+		}
+	})
+	b.Run("vector", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			EuclideanDistance(v1, v2)
+		}
+	})
+}
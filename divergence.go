@@ -0,0 +1,56 @@
+package gector
+
+import "math"
+
+// divergenceEpsilon is added to every probability before taking logarithms,
+// so that zero probabilities give a large but finite divergence.
+const divergenceEpsilon = 1e-10
+
+// KLDivergence calculates the Kullback-Leibler divergence of b from a, for
+// vectors holding probability distributions. It is not symmetric: an index
+// using it scores KLDivergence(query, stored). Zero probabilities are
+// smoothed by divergenceEpsilon. A vector with a negative entry is not a
+// distribution, and the divergence is then +Inf.
+func KLDivergence(a, b Vector) float64 {
+	if !nonNegative(a.Values) || !nonNegative(b.Values) {
+		return math.Inf(1)
+	}
+
+	var sum float64
+	for i := 0; i < len(a.Values); i++ {
+		p, q := a.Values[i], b.Values[i]
+		if p == 0 {
+			continue
+		}
+		sum += p * math.Log((p+divergenceEpsilon)/(q+divergenceEpsilon))
+	}
+	return sum
+}
+
+// JensenShannonDivergence calculates the symmetric Jensen-Shannon divergence
+// of two probability distributions: the mean KL divergence of each from
+// their average. It is at most ln 2 and, unlike KLDivergence, finite when
+// one distribution is zero where the other is not. A vector with a negative
+// entry gives +Inf.
+func JensenShannonDivergence(a, b Vector) float64 {
+	if !nonNegative(a.Values) || !nonNegative(b.Values) {
+		return math.Inf(1)
+	}
+
+	mean := make([]float64, len(a.Values))
+	for i := range mean {
+		mean[i] = (a.Values[i] + b.Values[i]) / 2
+	}
+	m := Vector{Values: mean}
+	return (KLDivergence(a, m) + KLDivergence(b, m)) / 2
+}
+
+// nonNegative reports whether every value is at least zero.
+func nonNegative(values []float64) bool {
+	for _, value := range values {
+		if value < 0 {
+			return false
+		}
+	}
+	return true
+}
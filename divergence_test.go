@@ -0,0 +1,63 @@
+package gector
+
+import (
+	"math"
+	"testing"
+)
+
+// Test for KLDivergence and JensenShannonDivergence on known distributions
+func TestDivergences(t *testing.T) {
+	p := Vector{Values: []float64{0.5, 0.5}}
+	q := Vector{Values: []float64{0.9, 0.1}}
+
+	// 0.5*ln(0.5/0.9) + 0.5*ln(0.5/0.1)
+	expected := 0.5*math.Log(0.5/0.9) + 0.5*math.Log(5)
+	if got := KLDivergence(p, q); math.Abs(got-expected) > 1e-6 {
+		t.Errorf("Expected KL divergence %f, but got %f", expected, got)
+	}
+	if got := KLDivergence(p, p); math.Abs(got) > 1e-9 {
+		t.Errorf("Expected KL divergence 0 for equal distributions, but got %f", got)
+	}
+
+	// Zero probabilities give a finite divergence
+	r := Vector{Values: []float64{1, 0}}
+	if got := KLDivergence(p, r); math.IsInf(got, 0) || math.IsNaN(got) {
+		t.Errorf("Expected a finite divergence from a zero probability, but got %f", got)
+	}
+	if got := KLDivergence(r, p); math.Abs(got-math.Log(2)) > 1e-6 {
+		t.Errorf("Expected KL divergence ln 2, but got %f", got)
+	}
+
+	// Disjoint distributions have the maximum Jensen-Shannon divergence ln 2
+	s := Vector{Values: []float64{0, 1}}
+	if got := JensenShannonDivergence(r, s); math.Abs(got-math.Log(2)) > 1e-6 {
+		t.Errorf("Expected Jensen-Shannon divergence ln 2, but got %f", got)
+	}
+	if JensenShannonDivergence(p, q) != JensenShannonDivergence(q, p) {
+		t.Errorf("Expected Jensen-Shannon divergence to be symmetric")
+	}
+
+	negative := Vector{Values: []float64{-0.5, 1.5}}
+	if got := KLDivergence(negative, p); !math.IsInf(got, 1) {
+		t.Errorf("Expected +Inf for a negative entry, but got %f", got)
+	}
+}
+
+// Test for selecting the KL divergence by name
+func TestKLDivergenceMetric(t *testing.T) {
+	opt, err := WithMetricName("kl")
+	if err != nil {
+		t.Fatalf("Error selecting metric: %v", err)
+	}
+	hnswIndex := NewHNSW(5, 4, opt)
+	hnswIndex.AddVector("uniform", Vector{Values: []float64{0.5, 0.5}})
+	hnswIndex.AddVector("skewed", Vector{Values: []float64{0.9, 0.1}})
+
+	results, _ := hnswIndex.SearchWithStats(Vector{Values: []float64{0.8, 0.2}}, 1)
+	if len(results) != 1 || results[0].ID != "skewed" {
+		t.Errorf("Expected skewed to be nearest, but got %v", results)
+	}
+	if err := RegisterMetric("kl", KLDivergence); err == nil {
+		t.Errorf("Expected an error registering a duplicate metric, but got nil")
+	}
+}
@@ -2,9 +2,13 @@ package gector
 
 import (
 	"fmt"
+	"hash/fnv"
 	"math"
 	"math/rand"
 	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
 )
 
 // HNSWNode represents a node in the HNSW graph with vector data.
@@ -12,10 +16,28 @@ type HNSWNode struct {
 	ID        string
 	Neighbors []string
 	Vector    Vector
+	// Optional payload stored alongside the vector
+	Metadata map[string]any
+	// Position of the node in the categorical bitmaps
+	slot int
+	// Cached L2 norm of the vector, used by the distance pre-filter
+	norm float64
+	// Cached inverse L2 norm of the vector, used by the cosine metric
+	invNorm float64
+	// Ranking weight set by SetWeight; 0 means unset, which counts as 1
+	weight float64
+	// Number of times the node was returned by a search
+	accessCount atomic.Int64
+	// Access clock tick of the latest insert or search hit, used for eviction
+	lastAccess atomic.Int64
+	// Product quantization code of the vector, set by WithQuantizer
+	codes []byte
 }
 
 // HNSW represents the entire HNSW graph.
 type HNSW struct {
+	// Guards the nodes, levels and neighbor lists
+	mu sync.RWMutex
 	// Maps node ID to the actual node
 	nodes map[string]*HNSWNode
 	// Graph levels: Higher levels have fewer nodes, lower levels more.
@@ -24,65 +46,426 @@ type HNSW struct {
 	MaxNeighbors int
 	// Maximum number of levels in the graph
 	MaxLevels int
+	// Skip full distance computations that a cheap lower bound already rules out
+	PreFilter bool
+	// Distance metric used to compare vectors
+	Metric Metric
+	// Free-form version or commit tag written with every snapshot
+	Tag string
+	// Custom distance function selected by name; overrides Metric when set
+	distance DistanceFunc
+	// Source of randomness for level promotion; nil uses the global source
+	rng *rand.Rand
+	// Centroid of the stored vectors, cached for routing
+	centroid centroidCache
+	// Number of stored vectors, readable without the lock
+	size atomic.Int64
+	// Set while the graph is being rebuilt or snapshots are being written
+	rebuilding   atomic.Bool
+	snapshotting atomic.Int32
+	// Error of the latest background snapshot, see LastSnapshotError
+	snapshotErr   error
+	snapshotErrMu sync.Mutex
+	// Number of neighbor IDs met by graph traversals with no stored vector
+	missingNeighbors atomic.Int64
+	// Derive each node's level from a hash of its ID instead of rng
+	deterministicLevels bool
+	// Source of randomness for KeepRandom neighbor selection, kept apart from
+	// rng so the selection does not change the levels of later inserts; nil
+	// uses the global source
+	selectRng *rand.Rand
+	// Source of randomness for sampling reads such as EstimateMatches, kept
+	// apart from rng so reads do not change the levels of later inserts; nil
+	// uses the global source
+	sampleRng *rand.Rand
+	// Guards sampleRng for readers that draw from it under the read lock
+	rngMu sync.Mutex
+	// Store caller-provided Values slices as-is instead of copying them
+	noCopy bool
+	// Whether mutations are recorded for replicas
+	deltaLog bool
+	// Recorded mutations and the sequence number of the latest one
+	deltas   []Delta
+	deltaSeq uint64
+	// Sequence number of the latest delta applied from a primary
+	appliedSeq uint64
+	// Dimension of the vectors, captured from the first insert
+	dim int
+	// Optional cache of search results, cleared on every mutation
+	cache *queryCache
+	// Max number of distance evaluations per level of a search; 0 means no cap
+	maxExpansions int
+	// Number of workers computing the distances of a search; 1 or less is sequential
+	distanceWorkers int
+	// Size below which searches are exact brute-force scans; 0 disables it
+	exactBelow int
+	// Max length of vectors accepted by inserts and searches; 0 means no limit
+	maxDimension int
+	// Quantizer whose codes rank search candidates, and the number of
+	// candidates re-scored with the original vectors
+	quantizer *ProductQuantizer
+	rerank    int
+	// Histogram of search latencies, set by WithLatencyHistogram
+	latency *latencyHistogram
+	// Hook applied to the final results of every search, set by WithResultProcessor
+	resultProcessor func([]SearchResult) []SearchResult
+	// Max number of candidates examined when linking a new node; 0 means all
+	maxInsertCandidates int
+	// Optional periodic recall check run during inserts
+	recallProbe *recallProbe
+	// Optional periodic report of the graph size run during inserts
+	growthObserver *growthObserver
+	// Set once the index is frozen; frozen indexes are read without locking
+	frozen atomic.Bool
+	// Nodes by bitmap slot, with the slots freed by deletes for reuse
+	slots     []*HNSWNode
+	freeSlots []int
+	// Bitmaps of node slots per indexed metadata field and value
+	categories map[string]map[any]*bitmap
+	// Max number of vectors kept before evicting the least recently searched; 0 means no cap
+	maxVectors int
+	// Tolerance used when comparing vector components for equality
+	epsilon float64
+	// How neighbors are chosen when there are more candidates than MaxNeighbors
+	neighborSelection NeighborSelection
+	// Optional whitening and projection applied, in that order, to inserted
+	// and queried vectors
+	whitener   *Whitener
+	projection *randomProjection
+	// Attach each vector's metadata to its search results
+	includeMetadata bool
+	// Logical clock ticked by every insert and search when eviction is enabled
+	accessClock atomic.Int64
+	// Nodes ordered by last access, and the nodes listing each ID as a
+	// neighbor, kept when eviction is enabled so an eviction finds its victim
+	// and the lists to repair without scanning the index
+	accessOrder accessHeap
+	inEdges     map[string]map[*HNSWNode]int
 }
 
 // NewHNSW creates a new HNSW index.
-func NewHNSW(maxNeighbors, maxLevels int) *HNSW {
-	return &HNSW{
+func NewHNSW(maxNeighbors, maxLevels int, opts ...Option) *HNSW {
+	hnsw := &HNSW{
 		nodes:        make(map[string]*HNSWNode),
-		levels:       make([]map[string]*HNSWNode, maxLevels),
 		MaxNeighbors: maxNeighbors,
 		MaxLevels:    maxLevels,
 	}
+	for _, opt := range opts {
+		opt(hnsw)
+	}
+
+	// Options may change the number of levels, so allocate them last
+	hnsw.levels = make([]map[string]*HNSWNode, hnsw.MaxLevels)
+	if hnsw.maxVectors > 0 {
+		hnsw.inEdges = make(map[string]map[*HNSWNode]int)
+	}
+	return hnsw
+}
+
+// Reserve pre-sizes the index for n vectors before a bulk insert, so the node
+// and level maps do not repeatedly grow during the load. Each level is sized
+// for the share of nodes expected to be promoted to it. It is a no-op when
+// the index already holds n or more vectors, or is frozen.
+func (hnsw *HNSW) Reserve(n int) {
+	hnsw.mu.Lock()
+	defer hnsw.mu.Unlock()
+
+	if hnsw.frozen.Load() || len(hnsw.nodes) >= n {
+		return
+	}
+
+	hnsw.nodes = growMap(hnsw.nodes, n)
+	expected := float64(n)
+	for level := hnsw.MaxLevels - 1; level >= 0; level-- {
+		hnsw.levels[level] = growMap(hnsw.levels[level], int(expected))
+		expected *= levelProbability
+	}
+	if cap(hnsw.slots) < n {
+		hnsw.slots = append(make([]*HNSWNode, 0, n), hnsw.slots...)
+	}
+}
+
+// growMap returns a copy of m allocated for size entries.
+func growMap(m map[string]*HNSWNode, size int) map[string]*HNSWNode {
+	grown := make(map[string]*HNSWNode, max(size, len(m)))
+	for id, node := range m {
+		grown[id] = node
+	}
+	return grown
 }
 
 // AddVector adds a vector to the HNSW index.
-func (hnsw *HNSW) AddVector(id string, vector Vector) {
-	// Create a new node with the vector
-	node := &HNSWNode{
-		ID:     id,
-		Vector: vector,
+//
+// The vector's Values are copied on insert, so callers may reuse their buffer
+// afterwards. Indexes created with WithNoCopy store the slice as-is instead,
+// and the caller must then never modify it after the call.
+//
+// A vector whose dimension differs from the vectors already stored is
+// rejected with a *VectorError.
+func (hnsw *HNSW) AddVector(id string, vector Vector) error {
+	hnsw.mu.Lock()
+	if hnsw.frozen.Load() {
+		hnsw.mu.Unlock()
+		return ErrFrozen
+	}
+	if err := hnsw.checkDimension("add", id, vector); err != nil {
+		hnsw.mu.Unlock()
+		return err
 	}
 
-	// Add the node to the bottom level of the graph
-	level := hnsw.MaxLevels - 1
-	hnsw.addNodeToLevel(node, level)
+	hnsw.addVector(id, vector, nil)
+	hnsw.recordDelta(DeltaAdd, id, vector, nil)
+	notify := hnsw.afterInsert()
+	hnsw.mu.Unlock()
 
-	// Perform insertion into higher levels based on probability
-	for level > 0 && rand.Float64() < 0.5 {
+	notify()
+	return nil
+}
+
+// AddVectorWithMetadata adds a vector to the HNSW index together with a metadata payload.
+func (hnsw *HNSW) AddVectorWithMetadata(id string, vector Vector, metadata map[string]any) error {
+	hnsw.mu.Lock()
+	if hnsw.frozen.Load() {
+		hnsw.mu.Unlock()
+		return ErrFrozen
+	}
+	if err := hnsw.checkDimension("add", id, vector); err != nil {
+		hnsw.mu.Unlock()
+		return err
+	}
+
+	hnsw.addVector(id, vector, metadata)
+	hnsw.recordDelta(DeltaAdd, id, vector, metadata)
+	notify := hnsw.afterInsert()
+	hnsw.mu.Unlock()
+
+	notify()
+	return nil
+}
+
+// addVector inserts a node into the graph on a randomly chosen level.
+// The caller must hold the write lock.
+func (hnsw *HNSW) addVector(id string, vector Vector, metadata map[string]any) {
+	level := hnsw.randomLevel()
+	if hnsw.deterministicLevels {
+		level = hnsw.hashLevel(id)
+	}
+	hnsw.addVectorAtLevel(id, vector, metadata, level)
+}
+
+// randomLevel picks the highest level of a new node, promoting it one level
+// up from the bottom at a time with probability levelProbability.
+func (hnsw *HNSW) randomLevel() int {
+	level := hnsw.MaxLevels - 1
+	for level > 0 && hnsw.randFloat() < levelProbability {
 		level--
+	}
+	return level
+}
+
+// addVectorAtLevel inserts a node into the graph on every level from the
+// bottom up to topLevel. The caller must hold the write lock, which is what
+// keeps searches from ever observing a node linked on only some levels.
+func (hnsw *HNSW) addVectorAtLevel(id string, vector Vector, metadata map[string]any, topLevel int) {
+	// Detach the stored values from the caller's buffer
+	if !hnsw.noCopy {
+		vector.Values = append([]float64(nil), vector.Values...)
+	}
+	hnsw.insertNode(id, hnsw.transformInsert(vector), metadata, topLevel)
+}
+
+// insertNode is addVectorAtLevel for a vector that is already transformed and
+// owned by the index. The caller must hold the write lock.
+func (hnsw *HNSW) insertNode(id string, vector Vector, metadata map[string]any, topLevel int) {
+	// Re-adding an ID replaces the old node on every level, not just the
+	// levels the new node reaches
+	if _, exists := hnsw.nodes[id]; exists {
+		hnsw.deleteVector(id)
+	}
+
+	hnsw.invalidateCache()
+
+	// Capture the index dimension from the first vector
+	if hnsw.dim == 0 {
+		hnsw.dim = len(vector.Values)
+	}
+
+	// Create a new node with the vector
+	norm := vectorNorm(vector)
+	node := &HNSWNode{
+		ID:       id,
+		Vector:   vector,
+		Metadata: metadata,
+		norm:     norm,
+		invNorm:  inverseNorm(norm),
+	}
+	if hnsw.maxVectors > 0 {
+		node.lastAccess.Store(hnsw.accessClock.Add(1))
+	}
+	if hnsw.quantizer != nil && len(vector.Values) == hnsw.quantizer.dim {
+		node.codes = hnsw.quantizer.Encode(vector)
+	}
+
+	// Add the node to the bottom level of the graph and then up to its top level
+	for level := hnsw.MaxLevels - 1; level >= topLevel; level-- {
 		hnsw.addNodeToLevel(node, level)
 	}
 
 	// Store the node in the map
-	hnsw.nodes[id] = node
+	hnsw.registerNode(node)
+}
+
+// AddVectorAtLevel adds a vector and pins it to the given level and every level
+// below it, regardless of random promotion. Level 0 is the top of the pyramid,
+// so pinning good hub vectors there makes them part of the sparsest level.
+func (hnsw *HNSW) AddVectorAtLevel(id string, vector Vector, level int) error {
+	hnsw.mu.Lock()
+	if hnsw.frozen.Load() {
+		hnsw.mu.Unlock()
+		return ErrFrozen
+	}
+	if level < 0 || level >= hnsw.MaxLevels {
+		hnsw.mu.Unlock()
+		return fmt.Errorf("level %d out of range [0, %d)", level, hnsw.MaxLevels)
+	}
+	if err := hnsw.checkDimension("add", id, vector); err != nil {
+		hnsw.mu.Unlock()
+		return err
+	}
+
+	hnsw.addVectorAtLevel(id, vector, nil, level)
+	hnsw.recordPinnedDelta(id, vector, level)
+	notify := hnsw.afterInsert()
+	hnsw.mu.Unlock()
+
+	notify()
+	return nil
+}
+
+// hashLevel picks the highest level of a new node like randomLevel, but
+// draws the promotion decisions from a hash of the ID, so a given ID always
+// lands on the same level.
+func (hnsw *HNSW) hashLevel(id string) int {
+	h := fnv.New64a()
+	h.Write([]byte(id))
+	state := h.Sum64()
+
+	level := hnsw.MaxLevels - 1
+	for level > 0 {
+		// splitmix64 step, turning the hash into a stream of uniform draws
+		state += 0x9e3779b97f4a7c15
+		z := state
+		z = (z ^ (z >> 30)) * 0xbf58476d1ce4e5b9
+		z = (z ^ (z >> 27)) * 0x94d049bb133111eb
+		z ^= z >> 31
+		if float64(z>>11)/(1<<53) >= levelProbability {
+			break
+		}
+		level--
+	}
+	return level
+}
+
+// randFloat returns a random number in [0, 1) from the index's source.
+func (hnsw *HNSW) randFloat() float64 {
+	if hnsw.rng != nil {
+		return hnsw.rng.Float64()
+	}
+	return rand.Float64()
 }
 
 // UpdateVector updates an existing vector with a new one (by deleting the old one and adding the new one)
+//
+// An update whose components all lie within the index epsilon (see
+// WithEpsilon) of the stored ones is detected as unchanged and skipped.
 func (hnsw *HNSW) UpdateVector(id string, newVector Vector) error {
+	hnsw.mu.Lock()
+	defer hnsw.mu.Unlock()
+
+	if hnsw.frozen.Load() {
+		return ErrFrozen
+	}
+
 	// Check if the vector exists
-	_, exists := hnsw.nodes[id]
+	node, exists := hnsw.nodes[id]
 	if !exists {
-		return fmt.Errorf("vector with id %s not found", id)
+		return notFoundError("update", id)
+	}
+	if err := hnsw.checkDimension("update", id, newVector); err != nil {
+		return err
+	}
+
+	// An update within the index epsilon of the stored vector changes nothing
+	if VectorsAlmostEqual(node.Vector, hnsw.transformQuery(newVector), hnsw.epsilon) {
+		return nil
 	}
 
 	// Remove the old vector (delete node and connections)
-	hnsw.DeleteVector(id)
+	hnsw.deleteVector(id)
 
-	// Add the new vector with the same ID
-	hnsw.AddVector(id, newVector)
+	// Add the new vector with the same ID, keeping its metadata and weight
+	hnsw.addVector(id, newVector, node.Metadata)
+	hnsw.nodes[id].weight = node.weight
+	hnsw.recordDelta(DeltaUpdate, id, newVector, nil)
 	return nil
 }
 
 // DeleteVector removes a vector from the HNSW index
 func (hnsw *HNSW) DeleteVector(id string) error {
+	hnsw.mu.Lock()
+	defer hnsw.mu.Unlock()
+
+	if hnsw.frozen.Load() {
+		return ErrFrozen
+	}
+
+	if _, exists := hnsw.nodes[id]; exists {
+		hnsw.recordDelta(DeltaDelete, id, Vector{}, nil)
+	}
+	hnsw.deleteVector(id)
+	return nil
+}
+
+// GetVector returns the stored vector with the given ID.
+func (hnsw *HNSW) GetVector(id string) (Vector, bool) {
+	defer hnsw.readUnlock(hnsw.readLock())
+
+	node, exists := hnsw.nodes[id]
+	if !exists {
+		return Vector{}, false
+	}
+	return node.Vector, true
+}
+
+// GetVectors returns the stored vectors for the given IDs under a single read
+// lock, along with the IDs that were not found in the order they were given.
+func (hnsw *HNSW) GetVectors(ids []string) (map[string]Vector, []string) {
+	defer hnsw.readUnlock(hnsw.readLock())
+
+	found := make(map[string]Vector, len(ids))
+	var missing []string
+	for _, id := range ids {
+		if node, exists := hnsw.nodes[id]; exists {
+			found[id] = node.Vector
+		} else {
+			missing = append(missing, id)
+		}
+	}
+	return found, missing
+}
+
+// deleteVector removes a node from every level. The caller must hold the write lock.
+func (hnsw *HNSW) deleteVector(id string) {
+	hnsw.invalidateCache()
+
 	// Remove the node from each level
 	for i := 0; i < hnsw.MaxLevels; i++ {
 		delete(hnsw.levels[i], id)
 	}
 	// Remove the node from the Nodes map
-	delete(hnsw.nodes, id)
-	return nil
+	if node, exists := hnsw.nodes[id]; exists {
+		hnsw.unregisterNode(node)
+	}
 }
 
 // addNodeToLevel adds a node to the specified level.
@@ -104,76 +487,230 @@ func (hnsw *HNSW) addNodeToLevel(node *HNSWNode, level int) {
 func (hnsw *HNSW) findNeighbors(node *HNSWNode, level int) []string {
 	// Placeholder for nearest neighbor search logic
 	// We need to calculate the Euclidean distance and return top K nearest neighbors
-	var neighbors []string
-	var distances []float64
+	size := len(hnsw.levels[level])
+	if hnsw.maxInsertCandidates > 0 && hnsw.maxInsertCandidates < size {
+		size = hnsw.maxInsertCandidates
+	}
+	candidates := make([]string, 0, size)
+	distances := make(map[string]float64, size)
 
 	// Iterate over nodes in the same level to find the closest ones
 	for id, otherNode := range hnsw.levels[level] {
 		if node.ID == id {
 			continue
 		}
-		dist := euclideanDistance(node.Vector, otherNode.Vector)
-		distances = append(distances, dist)
-		neighbors = append(neighbors, id)
+		if hnsw.maxInsertCandidates > 0 && len(candidates) >= hnsw.maxInsertCandidates {
+			break
+		}
+		distances[id] = hnsw.nodeDistance(node, otherNode)
+		candidates = append(candidates, id)
 	}
 
-	// Sort neighbors by distance
-	sort.SliceStable(neighbors, func(i, j int) bool {
-		return distances[i] < distances[j]
+	// Sort candidates by distance, breaking ties by ID
+	sort.Slice(candidates, func(i, j int) bool {
+		if distances[candidates[i]] != distances[candidates[j]] {
+			return distances[candidates[i]] < distances[candidates[j]]
+		}
+		return candidates[i] < candidates[j]
 	})
 
-	// Return the top K neighbors based on MaxNeighbors
-	if len(neighbors) > hnsw.MaxNeighbors {
-		neighbors = neighbors[:hnsw.MaxNeighbors]
-	}
-
-	return neighbors
+	// Keep at most MaxNeighbors of them, chosen by the selection strategy
+	return hnsw.selectNeighbors(node, candidates, distances, level)
 }
 
 // euclideanDistance calculates the Euclidean distance between two vectors.
 func euclideanDistance(v1, v2 Vector) float64 {
-	var sum float64
-	for i := 0; i < len(v1.Values); i++ {
-		diff := v1.Values[i] - v2.Values[i]
-		sum += diff * diff
-	}
-	return math.Sqrt(sum)
+	return EuclideanRaw(v1.Values, v2.Values)
+}
+
+// vectorNorm calculates the L2 norm of a vector.
+func vectorNorm(v Vector) float64 {
+	return NormRaw(v.Values)
+}
+
+// SearchResult is a single hit returned by a search.
+type SearchResult struct {
+	ID       string
+	Vector   Vector
+	Distance float64
+	// Metadata stored with the vector, set only by indexes created with
+	// WithIncludeMetadata. The map is shared with the index and must not be
+	// modified.
+	Metadata map[string]any
 }
 
 // NearestNeighbors returns the k nearest neighbors to a given query vector
 func (hnsw *HNSW) NearestNeighbors(query Vector, k int) []Vector {
-	var bestNeighbors []Vector
-	var bestDistances []float64
+	defer hnsw.readUnlock(hnsw.readLock())
 
-	// Search through all levels and collect the closest neighbors
-	for level := hnsw.MaxLevels - 1; level >= 0; level-- {
-		var candidates []string
-		var distances []float64
-
-		// Iterate through nodes at the current level
-		for _, node := range hnsw.levels[level] {
-			dist := euclideanDistance(query, node.Vector)
-			candidates = append(candidates, node.ID)
-			distances = append(distances, dist)
+	if hnsw.checkDimension("search", query.ID, query) != nil {
+		return nil
+	}
+
+	results := hnsw.processResults(hnsw.search(hnsw.transformQuery(query), k))
+
+	bestNeighbors := make([]Vector, len(results))
+	for i, result := range results {
+		bestNeighbors[i] = result.Vector
+	}
+	return bestNeighbors
+}
+
+// BruteForceSearch returns the exact k nearest neighbors to the query by
+// scoring every stored vector, ignoring the pre-filter, the search caps and
+// the query cache.
+func (hnsw *HNSW) BruteForceSearch(query Vector, k int) []SearchResult {
+	defer hnsw.readUnlock(hnsw.readLock())
+
+	if hnsw.checkDimension("search", query.ID, query) != nil {
+		return nil
+	}
+
+	results := hnsw.bruteForce(hnsw.transformQuery(query), k)
+	hnsw.touch(results)
+	return hnsw.processResults(results)
+}
+
+// Len returns the number of stored vectors without taking the lock.
+func (hnsw *HNSW) Len() int {
+	return int(hnsw.size.Load())
+}
+
+// search returns up to k results ordered by ascending distance to the query,
+// which must already be transformed like the stored vectors (see
+// transformQuery). Indexes smaller than exactBelow are searched by brute
+// force. The caller must hold the lock.
+func (hnsw *HNSW) search(query Vector, k int) []SearchResult {
+	if hnsw.latency != nil {
+		defer hnsw.latency.observe(time.Now())
+	}
+
+	if hnsw.cache != nil {
+		if results, ok := hnsw.cache.get(query, k); ok {
+			hnsw.touch(results)
+			return results
 		}
+	}
+
+	var results []SearchResult
+	if len(hnsw.nodes) < hnsw.exactBelow {
+		results = hnsw.bruteForce(query, k)
+	} else {
+		results, _ = hnsw.scan(query, k)
+	}
+	if hnsw.cache != nil {
+		hnsw.cache.put(query, k, results)
+	}
+	hnsw.touch(results)
+	return results
+}
+
+// bruteForce returns the exact k nearest neighbors by scoring every node,
+// bypassing the cache and the pre-filter. The caller must hold the lock.
+func (hnsw *HNSW) bruteForce(query Vector, k int) []SearchResult {
+	if k <= 0 {
+		return nil
+	}
 
-		// Sort neighbors by distance in ascending order
-		sort.SliceStable(candidates, func(i, j int) bool {
-			return distances[i] < distances[j]
+	queryInvNorm := inverseNorm(vectorNorm(query))
+	results := make([]SearchResult, 0, len(hnsw.nodes))
+	for id, node := range hnsw.nodes {
+		results = append(results, SearchResult{
+			ID:       id,
+			Vector:   node.Vector,
+			Distance: hnsw.queryDistance(query, queryInvNorm, node),
+			Metadata: hnsw.resultMetadata(node),
 		})
+	}
+	sortResults(results)
 
-		// Add the best neighbors from this level
-		for i := 0; i < k && i < len(candidates); i++ {
-			node := hnsw.nodes[candidates[i]]
-			bestNeighbors = append(bestNeighbors, node.Vector)
-			bestDistances = append(bestDistances, distances[i])
-		}
+	if len(results) > k {
+		results = results[:k]
 	}
+	return results
+}
 
-	// Ensure we return only the top k neighbors
-	if len(bestNeighbors) > k {
-		bestNeighbors = bestNeighbors[:k]
+// resultMetadata returns the metadata to attach to a search result for node.
+func (hnsw *HNSW) resultMetadata(node *HNSWNode) map[string]any {
+	if !hnsw.includeMetadata {
+		return nil
 	}
+	return node.Metadata
+}
 
-	return bestNeighbors
+// processResults passes the final, ranked results of a public search through
+// the processor set by WithResultProcessor, if any. The caller must hold the
+// lock.
+func (hnsw *HNSW) processResults(results []SearchResult) []SearchResult {
+	if hnsw.resultProcessor == nil {
+		return results
+	}
+	return hnsw.resultProcessor(results)
+}
+
+// invalidateCache drops cached search results after a mutation.
+func (hnsw *HNSW) invalidateCache() {
+	if hnsw.cache != nil {
+		hnsw.cache.clear()
+	}
+}
+
+// scan finds the k closest nodes to the query and also reports how many full
+// distance computations were needed.
+func (hnsw *HNSW) scan(query Vector, k int) ([]SearchResult, int) {
+	return hnsw.scanInto(query, k, nil)
+}
+
+// scanInto is scan writing its results into buf, which is reallocated only
+// when it is too small to hold them.
+func (hnsw *HNSW) scanInto(query Vector, k int, buf []SearchResult) ([]SearchResult, int) {
+	return hnsw.scanCapped(query, k, hnsw.maxExpansions, buf)
+}
+
+// scanCapped is scanInto with maxExpansions in place of the index's own cap
+// on distance evaluations; 0 means no cap.
+func (hnsw *HNSW) scanCapped(query Vector, k, maxExpansions int, buf []SearchResult) ([]SearchResult, int) {
+	if k <= 0 {
+		return buf[:0], 0
+	}
+	if k > len(hnsw.nodes) {
+		k = len(hnsw.nodes)
+	}
+	if hnsw.quantizer != nil && hnsw.Metric == Euclidean && hnsw.distance == nil && maxExpansions == 0 {
+		return hnsw.quantizedScan(query, k, buf)
+	}
+	if hnsw.distanceWorkers > 1 && maxExpansions == 0 && len(hnsw.nodes) >= parallelDistanceThreshold {
+		return hnsw.parallelScan(query, k, buf)
+	}
+
+	queryNorm := vectorNorm(query)
+	queryInvNorm := inverseNorm(queryNorm)
+	preFilter := hnsw.PreFilter && hnsw.Metric == Euclidean && hnsw.distance == nil
+
+	// The bottom level holds every node, so scan all stored nodes
+	best := resultHeap(buf[:0])
+	if cap(best) < k {
+		best = make(resultHeap, 0, k)
+	}
+	evaluations := 0
+	for id, node := range hnsw.nodes {
+		// By the reverse triangle inequality |‖q‖ - ‖v‖| <= ‖q - v‖, so a node
+		// whose bound already exceeds the current k-th distance cannot make it
+		if preFilter && len(best) == k && math.Abs(queryNorm-node.norm) > best[0].Distance {
+			continue
+		}
+
+		if maxExpansions > 0 && evaluations == maxExpansions {
+			break
+		}
+		evaluations++
+		best.offer(SearchResult{
+			ID:       id,
+			Vector:   node.Vector,
+			Distance: hnsw.queryDistance(query, queryInvNorm, node),
+			Metadata: hnsw.resultMetadata(node),
+		}, k)
+	}
+
+	return best.sorted(), evaluations
 }
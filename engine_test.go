@@ -2,7 +2,9 @@ package gector
 
 import (
 	"fmt"
+	"math"
 	"math/rand"
+	"sync"
 	"testing"
 	"time"
 )
@@ -183,3 +185,322 @@ func generateRandomVector(dim int) Vector {
 		Values: values,
 	}
 }
+
+// Test that the distance pre-filter does not change search results
+func TestPreFilterSameResults(t *testing.T) {
+	hnswIndex := NewHNSW(5, 4)
+	for i := 0; i < 200; i++ {
+		hnswIndex.AddVector(fmt.Sprintf("vec-%d", i), generateScaledVector(8))
+	}
+
+	for i := 0; i < 20; i++ {
+		query := generateScaledVector(8)
+
+		hnswIndex.PreFilter = false
+		exact := hnswIndex.search(query, 10)
+		hnswIndex.PreFilter = true
+		filtered := hnswIndex.search(query, 10)
+
+		if len(exact) != len(filtered) {
+			t.Fatalf("Expected %d results with the pre-filter, but got %d", len(exact), len(filtered))
+		}
+		for j := range exact {
+			if exact[j].ID != filtered[j].ID {
+				t.Fatalf("Expected result %d to be '%s' with the pre-filter, but got '%s'", j, exact[j].ID, filtered[j].ID)
+			}
+		}
+	}
+}
+
+// Benchmark full distance evaluations with and without the pre-filter
+func BenchmarkPreFilter(b *testing.B) {
+	hnswIndex := NewHNSW(5, 4)
+	for i := 0; i < 5000; i++ {
+		hnswIndex.AddVector(fmt.Sprintf("vec-%d", i), generateScaledVector(32))
+	}
+	query := generateScaledVector(32)
+
+	for _, preFilter := range []bool{false, true} {
+		b.Run(fmt.Sprintf("prefilter=%v", preFilter), func(b *testing.B) {
+			hnswIndex.PreFilter = preFilter
+			evaluations := 0
+			for i := 0; i < b.N; i++ {
+				_, n := hnswIndex.scan(query, 10)
+				evaluations += n
+			}
+			b.ReportMetric(float64(evaluations)/float64(b.N), "evals/op")
+		})
+	}
+}
+
+// Helper function to generate random vectors with widely varying magnitudes
+func generateScaledVector(dim int) Vector {
+	vector := generateRandomVector(dim)
+	scale := rand.Float64() * 10
+	for i := range vector.Values {
+		vector.Values[i] *= scale
+	}
+	return vector
+}
+
+// Benchmark bulk insertion to track allocations while linking neighbors
+func BenchmarkBulkInsert(b *testing.B) {
+	vectors := make([]Vector, 500)
+	for i := range vectors {
+		vectors[i] = generateRandomVector(16)
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		hnswIndex := NewHNSW(16, 4)
+		for j, vector := range vectors {
+			hnswIndex.AddVector(fmt.Sprintf("vec-%d", j), vector)
+		}
+	}
+}
+
+// Test that inserted vectors are not affected by later changes to the caller's slice
+func TestAddVectorCopiesValues(t *testing.T) {
+	hnswIndex := NewHNSW(5, 4)
+
+	buf := []float64{1, 2, 3}
+	hnswIndex.AddVector("vec-1", Vector{ID: "vec-1", Values: buf})
+	buf[0] = 100
+
+	if hnswIndex.nodes["vec-1"].Vector.Values[0] != 1 {
+		t.Errorf("Expected the stored vector to be unaffected by the caller's buffer, but got %v", hnswIndex.nodes["vec-1"].Vector.Values)
+	}
+
+	// With WithNoCopy the caller's slice is stored as-is
+	noCopyIndex := NewHNSW(5, 4, WithNoCopy())
+	noCopyIndex.AddVector("vec-1", Vector{ID: "vec-1", Values: buf})
+	buf[0] = 200
+
+	if noCopyIndex.nodes["vec-1"].Vector.Values[0] != 200 {
+		t.Errorf("Expected the stored vector to share the caller's buffer with WithNoCopy, but got %v", noCopyIndex.nodes["vec-1"].Vector.Values)
+	}
+}
+
+// Test that search stays correct after deleting the nodes on the top level.
+// Search scans the stored nodes rather than descending from an entry point,
+// so removing the top of the hierarchy must not affect the results.
+func TestSearchAfterDeletingTopLevel(t *testing.T) {
+	hnswIndex := NewHNSW(5, 4, WithSeed(3))
+	vectors := make(map[string]Vector)
+	for i := 0; i < 50; i++ {
+		id := fmt.Sprintf("vec-%d", i)
+		vectors[id] = generateRandomVector(5)
+		hnswIndex.AddVector(id, vectors[id])
+	}
+
+	// Delete every node on the highest populated level
+	for level := 0; level < hnswIndex.MaxLevels; level++ {
+		if len(hnswIndex.levels[level]) == 0 {
+			continue
+		}
+		for id := range hnswIndex.levels[level] {
+			hnswIndex.DeleteVector(id)
+			delete(vectors, id)
+		}
+		break
+	}
+
+	query := generateRandomVector(5)
+	neighbors := hnswIndex.NearestNeighbors(query, 3)
+	if len(neighbors) != 3 {
+		t.Fatalf("Expected 3 nearest neighbors, but got %d", len(neighbors))
+	}
+
+	// Compare against the closest remaining vector found by brute force
+	best := math.Inf(1)
+	for _, vector := range vectors {
+		best = math.Min(best, euclideanDistance(query, vector))
+	}
+	if euclideanDistance(query, neighbors[0]) != best {
+		t.Errorf("Expected the nearest remaining vector at distance %f, but got %f", best, euclideanDistance(query, neighbors[0]))
+	}
+}
+
+// Test that WithMaxInsertCandidates still links nodes within the degree cap
+func TestMaxInsertCandidates(t *testing.T) {
+	hnswIndex := NewHNSW(3, 1, WithMaxInsertCandidates(5))
+	for i := 0; i < 50; i++ {
+		hnswIndex.AddVector(fmt.Sprintf("vec-%d", i), generateRandomVector(5))
+	}
+
+	// Every node inserted after the first three has enough candidates to fill its list
+	for i := 3; i < 50; i++ {
+		id := fmt.Sprintf("vec-%d", i)
+		if len(hnswIndex.nodes[id].Neighbors) != 3 {
+			t.Errorf("Expected '%s' to have 3 neighbors, but got %d", id, len(hnswIndex.nodes[id].Neighbors))
+		}
+	}
+}
+
+// Benchmark insert-time variance with and without a candidate bound
+func BenchmarkMaxInsertCandidates(b *testing.B) {
+	vectors := make([]Vector, 2000)
+	for i := range vectors {
+		vectors[i] = generateRandomVector(16)
+	}
+
+	for _, maxCandidates := range []int{0, 64} {
+		b.Run(fmt.Sprintf("candidates=%d", maxCandidates), func(b *testing.B) {
+			var durations []float64
+			for i := 0; i < b.N; i++ {
+				hnswIndex := NewHNSW(16, 4, WithMaxInsertCandidates(maxCandidates))
+				for j, vector := range vectors {
+					start := time.Now()
+					hnswIndex.AddVector(fmt.Sprintf("vec-%d", j), vector)
+					durations = append(durations, float64(time.Since(start).Nanoseconds()))
+				}
+			}
+
+			var mean, variance float64
+			for _, d := range durations {
+				mean += d
+			}
+			mean /= float64(len(durations))
+			for _, d := range durations {
+				variance += (d - mean) * (d - mean)
+			}
+			b.ReportMetric(mean, "ns/insert")
+			b.ReportMetric(math.Sqrt(variance/float64(len(durations))), "stddev-ns/insert")
+		})
+	}
+}
+
+// Test for AddVectorAtLevel pinning a vector to the top level
+func TestAddVectorAtLevel(t *testing.T) {
+	hnswIndex := NewHNSW(5, 4)
+	for i := 0; i < 20; i++ {
+		hnswIndex.AddVector(fmt.Sprintf("vec-%d", i), generateRandomVector(5))
+	}
+
+	if err := hnswIndex.AddVectorAtLevel("hub", generateRandomVector(5), 0); err != nil {
+		t.Fatalf("Error adding pinned vector: %v", err)
+	}
+
+	// A pinned vector is on its level and every level below it
+	for level := 0; level < hnswIndex.MaxLevels; level++ {
+		if _, exists := hnswIndex.levels[level]["hub"]; !exists {
+			t.Errorf("Expected pinned vector 'hub' to be at level %d", level)
+		}
+	}
+
+	if err := hnswIndex.AddVectorAtLevel("bad", generateRandomVector(5), hnswIndex.MaxLevels); err == nil {
+		t.Errorf("Expected an error pinning a vector to an out-of-range level, but got nil")
+	}
+}
+
+// Test for GetVectors with present and absent IDs
+func TestGetVectors(t *testing.T) {
+	hnswIndex := NewHNSW(5, 4)
+	vector1 := generateRandomVector(5)
+	vector2 := generateRandomVector(5)
+	hnswIndex.AddVector("vec-1", vector1)
+	hnswIndex.AddVector("vec-2", vector2)
+
+	found, missing := hnswIndex.GetVectors([]string{"vec-1", "missing-1", "vec-2", "missing-2"})
+	if len(found) != 2 {
+		t.Fatalf("Expected 2 found vectors, but got %d", len(found))
+	}
+	if !equalVectors(found["vec-1"], vector1) || !equalVectors(found["vec-2"], vector2) {
+		t.Errorf("Expected the found vectors to match the stored ones")
+	}
+	if len(missing) != 2 || missing[0] != "missing-1" || missing[1] != "missing-2" {
+		t.Errorf("Expected missing IDs [missing-1 missing-2], but got %v", missing)
+	}
+
+	if _, exists := hnswIndex.GetVector("missing-1"); exists {
+		t.Errorf("Expected GetVector to report a missing ID")
+	}
+}
+
+// Test that concurrent inserts and searches never observe a half-linked node.
+// Run with -race to also check the locking.
+func TestConcurrentInsertAndSearch(t *testing.T) {
+	hnswIndex := NewHNSW(5, 4)
+	hnswIndex.AddVector("seed", generateRandomVector(5))
+
+	var wg sync.WaitGroup
+	for w := 0; w < 4; w++ {
+		wg.Add(2)
+		go func(w int) {
+			defer wg.Done()
+			for i := 0; i < 50; i++ {
+				// Re-adding IDs exercises replacing nodes on every level
+				hnswIndex.AddVector(fmt.Sprintf("vec-%d-%d", w, i%10), generateRandomVector(5))
+			}
+		}(w)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 50; i++ {
+				for _, node := range hnswIndex.SearchNodes(generateRandomVector(5), 5) {
+					if node == nil || node.Vector.Values == nil {
+						t.Errorf("Expected searches to only return fully linked nodes")
+						return
+					}
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	// Every stored node is on the bottom level and levels hold no stale nodes
+	bottom := hnswIndex.levels[hnswIndex.MaxLevels-1]
+	if len(bottom) != len(hnswIndex.nodes) {
+		t.Errorf("Expected %d nodes on the bottom level, but got %d", len(hnswIndex.nodes), len(bottom))
+	}
+	for level := range hnswIndex.levels {
+		for id, node := range hnswIndex.levels[level] {
+			if hnswIndex.nodes[id] != node {
+				t.Errorf("Expected level %d to hold the current node for '%s'", level, id)
+			}
+		}
+	}
+}
+
+// Benchmark for bulk insert with and without Reserve
+func BenchmarkReserve(b *testing.B) {
+	const size = 20000
+	vectors := make([]Vector, size)
+	for i := range vectors {
+		vectors[i] = generateRandomVector(8)
+	}
+
+	for _, reserve := range []bool{false, true} {
+		b.Run(fmt.Sprintf("reserve=%v", reserve), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				hnswIndex := NewHNSW(5, 4, WithMaxInsertCandidates(8))
+				if reserve {
+					hnswIndex.Reserve(size)
+				}
+				for j, vector := range vectors {
+					hnswIndex.AddVector(fmt.Sprintf("vec-%d", j), vector)
+				}
+			}
+		})
+	}
+}
+
+// Test for Reserve keeping the stored vectors intact
+func TestReserve(t *testing.T) {
+	hnswIndex := NewHNSW(5, 4)
+	for i := 0; i < 10; i++ {
+		hnswIndex.AddVector(fmt.Sprintf("vec-%d", i), generateRandomVector(4))
+	}
+	hnswIndex.Reserve(1000)
+	hnswIndex.Reserve(5)
+	for i := 10; i < 100; i++ {
+		hnswIndex.AddVector(fmt.Sprintf("vec-%d", i), generateRandomVector(4))
+	}
+
+	if len(hnswIndex.nodes) != 100 || len(hnswIndex.levels[hnswIndex.MaxLevels-1]) != 100 {
+		t.Errorf("Expected 100 vectors on the bottom level, but got %d", len(hnswIndex.levels[hnswIndex.MaxLevels-1]))
+	}
+	if results := hnswIndex.NearestNeighbors(generateRandomVector(4), 5); len(results) != 5 {
+		t.Errorf("Expected 5 nearest neighbors, but got %d", len(results))
+	}
+}
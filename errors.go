@@ -0,0 +1,107 @@
+package gector
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrDimensionTooLarge is wrapped by the errors for vectors longer than the
+// limit set with WithMaxDimension.
+var ErrDimensionTooLarge = errors.New("vector dimension above the limit")
+
+// ErrEmptyVector is wrapped by the errors for vectors without values.
+var ErrEmptyVector = errors.New("vector has no values")
+
+// VectorError describes a failed operation on a single vector. Want and Got
+// hold the expected and actual dimension of a dimension mismatch, and are
+// zero when the vector was not found. Err holds the cause of other
+// failures, ErrEmptyVector or ErrDimensionTooLarge, in which case Got is the
+// vector's dimension and Want the WithMaxDimension limit, if any.
+type VectorError struct {
+	ID   string
+	Op   string
+	Want int
+	Got  int
+	Err  error
+}
+
+// Error implements the error interface.
+func (e *VectorError) Error() string {
+	switch {
+	case errors.Is(e.Err, ErrDimensionTooLarge):
+		return fmt.Sprintf("%s: vector with id %s has dimension %d: %v of %d", e.Op, e.ID, e.Got, e.Err, e.Want)
+	case e.Err != nil:
+		return fmt.Sprintf("%s: vector with id %s: %v", e.Op, e.ID, e.Err)
+	}
+	if e.Want == 0 && e.Got == 0 {
+		return fmt.Sprintf("%s: vector with id %s not found", e.Op, e.ID)
+	}
+	return fmt.Sprintf("%s: vector with id %s has dimension %d, expected %d", e.Op, e.ID, e.Got, e.Want)
+}
+
+// Unwrap returns the cause of the failure, if any.
+func (e *VectorError) Unwrap() error {
+	return e.Err
+}
+
+// notFoundError returns the error for an operation on a missing vector.
+func notFoundError(op, id string) error {
+	return &VectorError{ID: id, Op: op}
+}
+
+// checkDimension returns a VectorError when the vector's dimension differs
+// from the index's. Indexes with a random projection also accept vectors of
+// the projection's input dimension. Empty vectors and vectors longer than the
+// WithMaxDimension limit are rejected even before the index dimension is
+// known. The caller must hold the lock.
+func (hnsw *HNSW) checkDimension(op, id string, vector Vector) error {
+	if err := hnsw.checkMaxDimension(op, id, vector); err != nil {
+		return err
+	}
+
+	got := len(vector.Values)
+	if got == 0 {
+		return &VectorError{ID: id, Op: op, Err: ErrEmptyVector}
+	}
+	if hnsw.dim == 0 {
+		return hnsw.checkQuantizerDimension(op, id, got)
+	}
+	if got == hnsw.dim {
+		return nil
+	}
+
+	want := hnsw.dim
+	if p := hnsw.projection; p != nil && p.matrix != nil {
+		if got == p.inputDim {
+			return nil
+		}
+		want = p.inputDim
+	}
+	return &VectorError{ID: id, Op: op, Want: want, Got: got}
+}
+
+// checkQuantizerDimension returns a VectorError when the first vector of an
+// index would be stored with a different dimension than its quantizer was
+// trained on. The caller must hold the lock.
+func (hnsw *HNSW) checkQuantizerDimension(op, id string, got int) error {
+	if hnsw.quantizer == nil {
+		return nil
+	}
+	stored := got
+	if hnsw.projection != nil {
+		stored = hnsw.projection.targetDim
+	}
+	if stored != hnsw.quantizer.dim {
+		return &VectorError{ID: id, Op: op, Want: hnsw.quantizer.dim, Got: stored}
+	}
+	return nil
+}
+
+// checkMaxDimension returns a VectorError wrapping ErrDimensionTooLarge when
+// the vector is longer than the limit set with WithMaxDimension.
+func (hnsw *HNSW) checkMaxDimension(op, id string, vector Vector) error {
+	if hnsw.maxDimension > 0 && len(vector.Values) > hnsw.maxDimension {
+		return &VectorError{ID: id, Op: op, Want: hnsw.maxDimension, Got: len(vector.Values), Err: ErrDimensionTooLarge}
+	}
+	return nil
+}
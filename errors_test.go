@@ -0,0 +1,97 @@
+package gector
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+// Test for VectorError carrying the ID and dimensions of failed operations
+func TestVectorError(t *testing.T) {
+	hnswIndex := NewHNSW(3, 3)
+	hnswIndex.AddVector("vec-1", Vector{ID: "vec-1", Values: []float64{1, 2, 3}})
+
+	err := hnswIndex.AddVector("short", Vector{ID: "short", Values: []float64{1, 2}})
+	var vectorErr *VectorError
+	if !errors.As(err, &vectorErr) {
+		t.Fatalf("Expected a *VectorError for a dimension mismatch, but got %v", err)
+	}
+	if vectorErr.ID != "short" || vectorErr.Op != "add" || vectorErr.Want != 3 || vectorErr.Got != 2 {
+		t.Errorf("Expected short/add/3/2, but got %+v", vectorErr)
+	}
+	if _, ok := hnswIndex.GetVector("short"); ok {
+		t.Errorf("Expected the mismatched vector to be rejected")
+	}
+
+	err = hnswIndex.UpdateVector("missing", Vector{Values: []float64{1, 2, 3}})
+	vectorErr, ok := err.(*VectorError)
+	if !ok {
+		t.Fatalf("Expected a *VectorError for a missing vector, but got %v", err)
+	}
+	if vectorErr.ID != "missing" || vectorErr.Op != "update" || vectorErr.Want != 0 || vectorErr.Got != 0 {
+		t.Errorf("Expected missing/update with no dimensions, but got %+v", vectorErr)
+	}
+	if vectorErr.Error() != "update: vector with id missing not found" {
+		t.Errorf("Expected a not found message, but got %q", vectorErr.Error())
+	}
+
+	err = hnswIndex.UpdateVector("vec-1", Vector{Values: []float64{1}})
+	if vectorErr, ok := err.(*VectorError); !ok || vectorErr.Want != 3 || vectorErr.Got != 1 {
+		t.Errorf("Expected a dimension error from UpdateVector, but got %v", err)
+	}
+}
+
+// Test for WithMaxDimension rejecting oversized inserts and queries
+func TestWithMaxDimension(t *testing.T) {
+	hnswIndex := NewHNSW(5, 4, WithMaxDimension(8))
+
+	// The limit applies before the first insert fixes the index dimension
+	err := hnswIndex.AddVector("huge", generateRandomVector(1000))
+	if !errors.Is(err, ErrDimensionTooLarge) {
+		t.Fatalf("Expected ErrDimensionTooLarge, but got %v", err)
+	}
+	var vectorErr *VectorError
+	if !errors.As(err, &vectorErr) || vectorErr.ID != "huge" || vectorErr.Got != 1000 || vectorErr.Want != 8 {
+		t.Errorf("Expected a VectorError for huge with dimension 1000 over 8, but got %v", err)
+	}
+	if hnswIndex.Len() != 0 {
+		t.Errorf("Expected the oversized vector to be rejected, but the index holds %d", hnswIndex.Len())
+	}
+
+	if err := hnswIndex.AddVector("vec-1", generateRandomVector(8)); err != nil {
+		t.Fatalf("Error adding vector within the limit: %v", err)
+	}
+	if _, err := hnswIndex.SearchContext(context.Background(), generateRandomVector(9), 1); !errors.Is(err, ErrDimensionTooLarge) {
+		t.Errorf("Expected ErrDimensionTooLarge for an oversized query, but got %v", err)
+	}
+	if results := hnswIndex.NearestNeighbors(generateRandomVector(9), 1); results != nil {
+		t.Errorf("Expected no results for an oversized query, but got %v", results)
+	}
+	if results := hnswIndex.NearestNeighbors(generateRandomVector(8), 1); len(results) != 1 {
+		t.Errorf("Expected 1 result within the limit, but got %d", len(results))
+	}
+}
+
+// Test for the crashes found by FuzzIndex: empty vectors and mismatched queries
+func TestDimensionCrashes(t *testing.T) {
+	hnswIndex := NewHNSW(3, 3)
+
+	// An empty first vector used to fix the dimension at zero
+	err := hnswIndex.AddVector("empty", Vector{})
+	var emptyErr *VectorError
+	if !errors.Is(err, ErrEmptyVector) || !errors.As(err, &emptyErr) || emptyErr.ID != "empty" {
+		t.Errorf("Expected a VectorError wrapping ErrEmptyVector, but got %v", err)
+	}
+	if err := hnswIndex.AddVector("vec-1", generateRandomVector(5)); err != nil {
+		t.Fatalf("Error adding vector: %v", err)
+	}
+
+	// A query longer than the stored vectors used to index past their end
+	if results := hnswIndex.NearestNeighbors(generateRandomVector(6), 1); results != nil {
+		t.Errorf("Expected no results for a query of the wrong dimension, but got %v", results)
+	}
+	var vectorErr *VectorError
+	if _, err := hnswIndex.SearchContext(context.Background(), generateRandomVector(6), 1); !errors.As(err, &vectorErr) || vectorErr.Want != 5 {
+		t.Errorf("Expected a VectorError wanting dimension 5, but got %v", err)
+	}
+}
@@ -0,0 +1,245 @@
+package gector
+
+import "sort"
+
+// touch counts a search hit on the nodes of a search result, and marks them as
+// accessed for the eviction policy of WithMaxVectors. It only uses atomics, so
+// the caller may hold either lock.
+func (hnsw *HNSW) touch(results []SearchResult) {
+	if len(results) == 0 {
+		return
+	}
+
+	var tick int64
+	if hnsw.maxVectors > 0 {
+		tick = hnsw.accessClock.Add(1)
+	}
+	for _, result := range results {
+		if node, ok := hnsw.nodes[result.ID]; ok {
+			node.accessCount.Add(1)
+			if tick > 0 {
+				node.lastAccess.Store(tick)
+			}
+		}
+	}
+}
+
+// evictOverflow removes the least recently accessed vectors until the index
+// holds at most maxVectors, then repairs the neighbor lists that pointed at
+// them. Evictions are recorded as deletes in the delta log. The caller must
+// hold the write lock.
+func (hnsw *HNSW) evictOverflow() {
+	if hnsw.maxVectors <= 0 || len(hnsw.nodes) <= hnsw.maxVectors {
+		return
+	}
+
+	evicted := make(map[string]bool)
+	for len(hnsw.nodes) > hnsw.maxVectors {
+		entry := hnsw.accessOrder.pop()
+		if hnsw.nodes[entry.node.ID] != entry.node {
+			// Deleted or replaced since the entry was pushed
+			continue
+		}
+		if tick := entry.node.lastAccess.Load(); tick != entry.tick {
+			// Accessed since the entry was pushed
+			hnsw.accessOrder.push(accessEntry{node: entry.node, tick: tick})
+			continue
+		}
+		evicted[entry.node.ID] = true
+		hnsw.deleteVector(entry.node.ID)
+		hnsw.recordDelta(DeltaDelete, entry.node.ID, Vector{}, nil)
+	}
+
+	hnsw.recordRepair(evicted)
+	hnsw.repairNeighbors(evicted)
+}
+
+// trackAccess adds a newly registered node to the eviction order and to the
+// in-edge index. It is a no-op unless eviction is enabled. The caller must
+// hold the write lock.
+func (hnsw *HNSW) trackAccess(node *HNSWNode) {
+	if hnsw.maxVectors <= 0 {
+		return
+	}
+
+	// Entries of deleted and touched nodes are only dropped when they reach
+	// the root, so rebuild the heap before they outnumber the live ones
+	if len(hnsw.accessOrder) >= 2*len(hnsw.nodes)+16 {
+		hnsw.accessOrder = hnsw.accessOrder[:0]
+		for _, live := range hnsw.nodes {
+			hnsw.accessOrder = append(hnsw.accessOrder, accessEntry{node: live, tick: live.lastAccess.Load()})
+		}
+		hnsw.accessOrder.init()
+	} else {
+		hnsw.accessOrder.push(accessEntry{node: node, tick: node.lastAccess.Load()})
+	}
+
+	for _, neighborID := range node.Neighbors {
+		hnsw.linkIn(node, neighborID)
+	}
+}
+
+// untrackAccess removes a node's neighbors from the in-edge index; its heap
+// entry is dropped lazily. The caller must hold the write lock.
+func (hnsw *HNSW) untrackAccess(node *HNSWNode) {
+	for _, neighborID := range node.Neighbors {
+		hnsw.unlinkIn(node, neighborID)
+	}
+}
+
+// setNeighbors replaces a registered node's neighbors, keeping the in-edge
+// index in step. The caller must hold the write lock.
+func (hnsw *HNSW) setNeighbors(node *HNSWNode, neighbors []string) {
+	if hnsw.inEdges != nil {
+		for _, neighborID := range node.Neighbors {
+			hnsw.unlinkIn(node, neighborID)
+		}
+		for _, neighborID := range neighbors {
+			hnsw.linkIn(node, neighborID)
+		}
+	}
+	node.Neighbors = neighbors
+}
+
+// linkIn records that from lists to as a neighbor. The caller must hold the
+// write lock.
+func (hnsw *HNSW) linkIn(from *HNSWNode, to string) {
+	if hnsw.inEdges == nil {
+		return
+	}
+	sources := hnsw.inEdges[to]
+	if sources == nil {
+		sources = make(map[*HNSWNode]int)
+		hnsw.inEdges[to] = sources
+	}
+	sources[from]++
+}
+
+// unlinkIn forgets one listing of to as a neighbor of from. The caller must
+// hold the write lock.
+func (hnsw *HNSW) unlinkIn(from *HNSWNode, to string) {
+	sources := hnsw.inEdges[to]
+	if sources == nil {
+		return
+	}
+	if sources[from]--; sources[from] <= 0 {
+		delete(sources, from)
+	}
+	if len(sources) == 0 {
+		delete(hnsw.inEdges, to)
+	}
+}
+
+// accessEntry is a node in the eviction order together with its last access
+// tick at the time it was pushed.
+type accessEntry struct {
+	node *HNSWNode
+	tick int64
+}
+
+// accessHeap is a min-heap of nodes by last access tick, so the next vector
+// to evict sits at the root. Entries go stale when their node is touched or
+// deleted; evictOverflow skips or re-pushes them when they surface.
+type accessHeap []accessEntry
+
+// push adds an entry.
+func (h *accessHeap) push(entry accessEntry) {
+	*h = append(*h, entry)
+	h.up(len(*h) - 1)
+}
+
+// pop removes and returns the entry at the root.
+func (h *accessHeap) pop() accessEntry {
+	old := *h
+	root := old[0]
+	last := len(old) - 1
+	old[0] = old[last]
+	old[last] = accessEntry{}
+	*h = old[:last]
+	h.down(0)
+	return root
+}
+
+// init orders the entries after they were appended in bulk.
+func (h accessHeap) init() {
+	for i := len(h)/2 - 1; i >= 0; i-- {
+		h.down(i)
+	}
+}
+
+// up moves the entry at i towards the root until the heap is ordered.
+func (h accessHeap) up(i int) {
+	for i > 0 {
+		parent := (i - 1) / 2
+		if !entryBefore(h[i], h[parent]) {
+			break
+		}
+		h[parent], h[i] = h[i], h[parent]
+		i = parent
+	}
+}
+
+// down moves the entry at i away from the root until the heap is ordered.
+func (h accessHeap) down(i int) {
+	for {
+		oldest := 2*i + 1
+		if oldest >= len(h) {
+			return
+		}
+		if right := oldest + 1; right < len(h) && entryBefore(h[right], h[oldest]) {
+			oldest = right
+		}
+		if !entryBefore(h[oldest], h[i]) {
+			return
+		}
+		h[i], h[oldest] = h[oldest], h[i]
+		i = oldest
+	}
+}
+
+// entryBefore reports whether a was accessed before b, breaking ties by ID so
+// eviction is deterministic.
+func entryBefore(a, b accessEntry) bool {
+	if a.tick != b.tick {
+		return a.tick < b.tick
+	}
+	return a.node.ID < b.node.ID
+}
+
+// TrimToTop deletes every vector except the n most often returned by searches
+// and returns how many were deleted. Ties in access counts are broken by ID.
+// The neighbor lists that pointed at deleted vectors are repaired.
+func (hnsw *HNSW) TrimToTop(n int) (int, error) {
+	hnsw.mu.Lock()
+	defer hnsw.mu.Unlock()
+
+	if hnsw.frozen.Load() {
+		return 0, ErrFrozen
+	}
+	if len(hnsw.nodes) <= n {
+		return 0, nil
+	}
+
+	nodes := make([]*HNSWNode, 0, len(hnsw.nodes))
+	for _, node := range hnsw.nodes {
+		nodes = append(nodes, node)
+	}
+	sort.Slice(nodes, func(i, j int) bool {
+		ci, cj := nodes[i].accessCount.Load(), nodes[j].accessCount.Load()
+		if ci != cj {
+			return ci > cj
+		}
+		return nodes[i].ID < nodes[j].ID
+	})
+
+	trimmed := make(map[string]bool, len(nodes)-max(n, 0))
+	for _, node := range nodes[max(n, 0):] {
+		trimmed[node.ID] = true
+		hnsw.deleteVector(node.ID)
+		hnsw.recordDelta(DeltaDelete, node.ID, Vector{}, nil)
+	}
+
+	hnsw.recordRepair(trimmed)
+	hnsw.repairNeighbors(trimmed)
+	return len(trimmed), nil
+}
@@ -0,0 +1,139 @@
+package gector
+
+import (
+	"fmt"
+	"testing"
+)
+
+// Test for WithMaxVectors evicting the least recently searched vectors
+func TestWithMaxVectors(t *testing.T) {
+	hnswIndex := NewHNSW(3, 3, WithMaxVectors(5), WithSeed(1))
+	for i := 0; i < 5; i++ {
+		id := fmt.Sprintf("vec-%d", i)
+		hnswIndex.AddVector(id, Vector{ID: id, Values: []float64{float64(i), 0}})
+	}
+
+	// Search for vec-0 and vec-1 so vec-2 and vec-3 become the oldest accessed
+	hnswIndex.NearestNeighbors(Vector{Values: []float64{0, 0}}, 1)
+	hnswIndex.NearestNeighbors(Vector{Values: []float64{1, 0}}, 1)
+
+	for i := 5; i < 7; i++ {
+		id := fmt.Sprintf("vec-%d", i)
+		hnswIndex.AddVector(id, Vector{ID: id, Values: []float64{float64(i), 0}})
+	}
+
+	if len(hnswIndex.nodes) != 5 {
+		t.Fatalf("Expected 5 vectors after overflowing the cap, but got %d", len(hnswIndex.nodes))
+	}
+	for _, id := range []string{"vec-2", "vec-3"} {
+		if _, ok := hnswIndex.GetVector(id); ok {
+			t.Errorf("Expected %s to be evicted", id)
+		}
+	}
+	for _, id := range []string{"vec-0", "vec-1", "vec-4", "vec-5", "vec-6"} {
+		if _, ok := hnswIndex.GetVector(id); !ok {
+			t.Errorf("Expected %s to be kept", id)
+		}
+	}
+
+	// No neighbor list points at an evicted vector
+	for _, node := range hnswIndex.nodes {
+		for _, neighborID := range node.Neighbors {
+			if _, ok := hnswIndex.nodes[neighborID]; !ok {
+				t.Errorf("Expected %s to have no link to evicted vector %s", node.ID, neighborID)
+			}
+		}
+	}
+}
+
+// Test for TrimToTop keeping the most searched vectors
+func TestTrimToTop(t *testing.T) {
+	hnswIndex := NewHNSW(3, 3)
+	for i := 0; i < 20; i++ {
+		id := fmt.Sprintf("vec-%02d", i)
+		hnswIndex.AddVector(id, Vector{ID: id, Values: []float64{float64(i), 0}})
+	}
+
+	// vec-05 is the hottest, then vec-12, then vec-17
+	for hits, target := range []float64{17, 12, 5} {
+		for i := 0; i <= hits; i++ {
+			hnswIndex.NearestNeighbors(Vector{Values: []float64{target, 0}}, 1)
+		}
+	}
+
+	if trimmed, _ := hnswIndex.TrimToTop(3); trimmed != 17 {
+		t.Errorf("Expected 17 trimmed vectors, but got %d", trimmed)
+	}
+	for _, id := range []string{"vec-05", "vec-12", "vec-17"} {
+		if _, ok := hnswIndex.GetVector(id); !ok {
+			t.Errorf("Expected hot vector %s to survive", id)
+		}
+	}
+	for _, node := range hnswIndex.nodes {
+		for _, neighborID := range node.Neighbors {
+			if _, ok := hnswIndex.nodes[neighborID]; !ok {
+				t.Errorf("Expected %s to have no link to trimmed vector %s", node.ID, neighborID)
+			}
+		}
+	}
+	if trimmed, _ := hnswIndex.TrimToTop(10); trimmed != 0 {
+		t.Errorf("Expected nothing trimmed below the size, but got %d", trimmed)
+	}
+}
+
+// Test for WithMaxVectors keeping the eviction order and in-edges in step
+// with searches, deletes, renames and relinks
+func TestWithMaxVectorsAccessOrder(t *testing.T) {
+	hnswIndex := NewHNSW(3, 3, WithMaxVectors(10), WithSeed(1))
+	for i := 0; i < 200; i++ {
+		id := fmt.Sprintf("vec-%03d", i)
+		hnswIndex.AddVector(id, Vector{ID: id, Values: []float64{float64(i % 17), float64(i % 5)}})
+
+		// Keep vec-000 hot so it is never evicted
+		if _, ok := hnswIndex.GetVector("vec-000"); ok {
+			hnswIndex.NearestNeighbors(Vector{Values: []float64{0, 0}}, 1)
+		}
+		switch i % 7 {
+		case 3:
+			hnswIndex.DeleteVector(id)
+		case 5:
+			hnswIndex.RenameVector(id, id+"-renamed")
+		case 6:
+			hnswIndex.RelinkNode(id)
+		}
+	}
+
+	if len(hnswIndex.nodes) > 10 {
+		t.Fatalf("Expected at most 10 vectors, but got %d", len(hnswIndex.nodes))
+	}
+	if _, ok := hnswIndex.GetVector("vec-000"); !ok {
+		t.Errorf("Expected the searched vector vec-000 to be kept")
+	}
+	if _, ok := hnswIndex.GetVector("vec-198"); !ok {
+		t.Errorf("Expected the newest vector vec-198 to be kept")
+	}
+	if len(hnswIndex.accessOrder) > 2*len(hnswIndex.nodes)+16 {
+		t.Errorf("Expected the eviction heap to stay bounded, but got %d entries for %d vectors", len(hnswIndex.accessOrder), len(hnswIndex.nodes))
+	}
+
+	// The in-edge index matches the neighbor lists
+	want := make(map[string]map[*HNSWNode]int)
+	for _, node := range hnswIndex.nodes {
+		for _, neighborID := range node.Neighbors {
+			if want[neighborID] == nil {
+				want[neighborID] = make(map[*HNSWNode]int)
+			}
+			want[neighborID][node]++
+		}
+	}
+	if len(want) != len(hnswIndex.inEdges) {
+		t.Fatalf("Expected in-edges for %d IDs, but got %d", len(want), len(hnswIndex.inEdges))
+	}
+	for id, sources := range want {
+		for source, count := range sources {
+			if got := hnswIndex.inEdges[id][source]; got != count {
+				t.Errorf("Expected %d in-edges from %s to %s, but got %d", count, source.ID, id, got)
+			}
+		}
+	}
+}
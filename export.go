@@ -0,0 +1,25 @@
+package gector
+
+import (
+	"encoding/json"
+	"io"
+)
+
+// ExportAdjacency writes the neighbor graph as JSON without the vector data.
+// The output is an array indexed by level, each entry mapping the IDs of the
+// nodes on that level to their neighbor IDs.
+func (hnsw *HNSW) ExportAdjacency(w io.Writer) error {
+	defer hnsw.readUnlock(hnsw.readLock())
+
+	adjacency := make([]map[string][]string, hnsw.MaxLevels)
+	for level := 0; level < hnsw.MaxLevels; level++ {
+		adjacency[level] = make(map[string][]string, len(hnsw.levels[level]))
+		for id, node := range hnsw.levels[level] {
+			neighbors := make([]string, len(node.Neighbors))
+			copy(neighbors, node.Neighbors)
+			adjacency[level][id] = neighbors
+		}
+	}
+
+	return json.NewEncoder(w).Encode(adjacency)
+}
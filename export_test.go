@@ -0,0 +1,44 @@
+package gector
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"testing"
+)
+
+// Test for ExportAdjacency matching the in-memory neighbor lists
+func TestExportAdjacency(t *testing.T) {
+	hnswIndex := NewHNSW(3, 4)
+	for i := 0; i < 20; i++ {
+		hnswIndex.AddVector(fmt.Sprintf("vec-%d", i), generateRandomVector(5))
+	}
+
+	var buf bytes.Buffer
+	if err := hnswIndex.ExportAdjacency(&buf); err != nil {
+		t.Fatalf("Error exporting adjacency: %v", err)
+	}
+
+	var adjacency []map[string][]string
+	if err := json.Unmarshal(buf.Bytes(), &adjacency); err != nil {
+		t.Fatalf("Error decoding adjacency: %v", err)
+	}
+	if len(adjacency) != hnswIndex.MaxLevels {
+		t.Fatalf("Expected %d levels, but got %d", hnswIndex.MaxLevels, len(adjacency))
+	}
+
+	for level, nodes := range adjacency {
+		if len(nodes) != len(hnswIndex.levels[level]) {
+			t.Errorf("Expected %d nodes at level %d, but got %d", len(hnswIndex.levels[level]), level, len(nodes))
+		}
+		for id, neighbors := range nodes {
+			node, exists := hnswIndex.levels[level][id]
+			if !exists {
+				t.Fatalf("Expected node '%s' to be at level %d", id, level)
+			}
+			if fmt.Sprint(neighbors) != fmt.Sprint(node.Neighbors) {
+				t.Errorf("Expected neighbors %v for '%s', but got %v", node.Neighbors, id, neighbors)
+			}
+		}
+	}
+}
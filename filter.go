@@ -0,0 +1,124 @@
+package gector
+
+import "strings"
+
+// Filter restricts which vectors a search may return.
+type Filter interface {
+	Match(id string, meta map[string]any) bool
+}
+
+// FilterFunc adapts a predicate over IDs and metadata to a Filter.
+type FilterFunc func(id string, meta map[string]any) bool
+
+// Match calls f.
+func (f FilterFunc) Match(id string, meta map[string]any) bool {
+	return f(id, meta)
+}
+
+// CategoryFilter matches vectors whose metadata Field equals any of Values.
+// When the field is indexed with WithCategoricalIndex, searches walk the
+// matching bitmap instead of testing every vector.
+type CategoryFilter struct {
+	Field  string
+	Values []any
+}
+
+// Match reports whether the metadata field holds one of the filter values.
+func (f CategoryFilter) Match(id string, meta map[string]any) bool {
+	value, ok := meta[f.Field]
+	if !ok || !categoryKey(value) {
+		return false
+	}
+	for _, want := range f.Values {
+		if categoryKey(want) && value == want {
+			return true
+		}
+	}
+	return false
+}
+
+// SearchFilter returns the k nearest neighbors to the query among the vectors
+// accepted by the filter.
+func (hnsw *HNSW) SearchFilter(query Vector, k int, filter Filter) []SearchResult {
+	defer hnsw.readUnlock(hnsw.readLock())
+
+	if hnsw.checkDimension("search", query.ID, query) != nil {
+		return nil
+	}
+
+	if k <= 0 {
+		return nil
+	}
+
+	query = hnsw.transformQuery(query)
+	queryInvNorm := inverseNorm(vectorNorm(query))
+	best := make(resultHeap, 0, k)
+	offer := func(node *HNSWNode) {
+		best.offer(SearchResult{
+			ID:       node.ID,
+			Vector:   node.Vector,
+			Distance: hnsw.queryDistance(query, queryInvNorm, node),
+			Metadata: hnsw.resultMetadata(node),
+		}, k)
+	}
+
+	// Only visit the slots in the category bitmaps when the field is indexed
+	if category, ok := filter.(CategoryFilter); ok {
+		if matches, ok := hnsw.categoryMatches(category); ok {
+			matches.forEach(func(slot int) {
+				offer(hnsw.slots[slot])
+			})
+			results := best.sorted()
+			hnsw.touch(results)
+			return hnsw.processResults(results)
+		}
+	}
+
+	for id, node := range hnsw.nodes {
+		if filter.Match(id, node.Metadata) {
+			offer(node)
+		}
+	}
+	results := best.sorted()
+	hnsw.touch(results)
+	return hnsw.processResults(results)
+}
+
+// SearchPrefix returns the k nearest neighbors to the query among the vectors
+// whose ID starts with prefix, as a lightweight namespace. Fewer than k
+// results are returned only when fewer than k IDs have the prefix.
+func (hnsw *HNSW) SearchPrefix(query Vector, k int, prefix string) []SearchResult {
+	return hnsw.SearchFilter(query, k, FilterFunc(func(id string, meta map[string]any) bool {
+		return strings.HasPrefix(id, prefix)
+	}))
+}
+
+// SearchExcludingWhere returns the k nearest neighbors to the query among the
+// vectors for which exclude is false, such as those not by the author of the
+// query document. Fewer than k results are returned only when fewer than k
+// vectors remain.
+func (hnsw *HNSW) SearchExcludingWhere(query Vector, k int, exclude func(id string, meta map[string]any) bool) []SearchResult {
+	return hnsw.SearchFilter(query, k, FilterFunc(func(id string, meta map[string]any) bool {
+		return !exclude(id, meta)
+	}))
+}
+
+// categoryMatches returns the union of the bitmaps for the filter values, or
+// false when the field is not indexed. The caller must hold the lock.
+func (hnsw *HNSW) categoryMatches(filter CategoryFilter) (bitmap, bool) {
+	values, indexed := hnsw.categories[filter.Field]
+	if !indexed {
+		return nil, false
+	}
+
+	var matches bitmap
+	for _, value := range filter.Values {
+		if !categoryKey(value) {
+			continue
+		}
+		if slots := values[value]; slots != nil {
+			matches = matches.union(*slots)
+		}
+	}
+	return matches, true
+}
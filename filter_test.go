@@ -0,0 +1,146 @@
+package gector
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// Helper function to compute filtered results by brute force
+func bruteForceFilter(hnswIndex *HNSW, query Vector, k int, filter Filter) []SearchResult {
+	var results []SearchResult
+	for id, node := range hnswIndex.nodes {
+		if filter.Match(id, node.Metadata) {
+			results = append(results, SearchResult{ID: id, Distance: euclideanDistance(query, node.Vector)})
+		}
+	}
+	sortResults(results)
+	if len(results) > k {
+		results = results[:k]
+	}
+	return results
+}
+
+// Test for SearchFilter with a bitmap-indexed categorical field against brute force
+func TestSearchFilterCategorical(t *testing.T) {
+	hnswIndex := NewHNSW(5, 4, WithCategoricalIndex("tenant"))
+	for i := 0; i < 200; i++ {
+		id := fmt.Sprintf("vec-%d", i)
+		hnswIndex.AddVectorWithMetadata(id, generateRandomVector(5), map[string]any{"tenant": fmt.Sprintf("t%d", i%4)})
+	}
+
+	// Deletes free slots that later inserts reuse
+	for i := 0; i < 20; i++ {
+		hnswIndex.DeleteVector(fmt.Sprintf("vec-%d", i))
+	}
+	for i := 200; i < 210; i++ {
+		id := fmt.Sprintf("vec-%d", i)
+		hnswIndex.AddVectorWithMetadata(id, generateRandomVector(5), map[string]any{"tenant": "t1"})
+	}
+
+	for _, filter := range []CategoryFilter{
+		{Field: "tenant", Values: []any{"t1"}},
+		{Field: "tenant", Values: []any{"t0", "t3"}},
+		{Field: "tenant", Values: []any{"unknown"}},
+	} {
+		query := generateRandomVector(5)
+		results := hnswIndex.SearchFilter(query, 10, filter)
+		expected := bruteForceFilter(hnswIndex, query, 10, filter)
+
+		if len(results) != len(expected) {
+			t.Fatalf("Expected %d results for %v, but got %d", len(expected), filter.Values, len(results))
+		}
+		for i := range expected {
+			if results[i].ID != expected[i].ID {
+				t.Errorf("Expected result %d for %v to be '%s', but got '%s'", i, filter.Values, expected[i].ID, results[i].ID)
+			}
+		}
+	}
+}
+
+// Test for SearchFilter with a predicate
+func TestSearchFilterFunc(t *testing.T) {
+	hnswIndex := NewHNSW(5, 4)
+	for i := 0; i < 50; i++ {
+		id := fmt.Sprintf("vec-%d", i)
+		hnswIndex.AddVectorWithMetadata(id, generateRandomVector(5), map[string]any{"even": i%2 == 0})
+	}
+
+	filter := FilterFunc(func(id string, meta map[string]any) bool {
+		return meta["even"] == true
+	})
+	results := hnswIndex.SearchFilter(generateRandomVector(5), 10, filter)
+	if len(results) != 10 {
+		t.Fatalf("Expected 10 results, but got %d", len(results))
+	}
+	for _, result := range results {
+		if hnswIndex.nodes[result.ID].Metadata["even"] != true {
+			t.Errorf("Expected only even vectors, but got '%s'", result.ID)
+		}
+	}
+}
+
+// Test for SearchPrefix keeping results within the requested ID prefix
+func TestSearchPrefix(t *testing.T) {
+	hnswIndex := NewHNSW(5, 4)
+	for _, prefix := range []string{"userA:", "userB:", "userAB:"} {
+		for i := 0; i < 30; i++ {
+			hnswIndex.AddVector(fmt.Sprintf("%sdoc%d", prefix, i), generateRandomVector(5))
+		}
+	}
+
+	query := generateRandomVector(5)
+	results := hnswIndex.SearchPrefix(query, 10, "userA:")
+	if len(results) != 10 {
+		t.Fatalf("Expected 10 results, but got %d", len(results))
+	}
+	expected := bruteForceFilter(hnswIndex, query, 10, FilterFunc(func(id string, meta map[string]any) bool {
+		return strings.HasPrefix(id, "userA:")
+	}))
+	for i, result := range results {
+		if !strings.HasPrefix(result.ID, "userA:") {
+			t.Errorf("Expected only userA: results, but got %s", result.ID)
+		}
+		if result.ID != expected[i].ID {
+			t.Errorf("Expected %s at rank %d, but got %s", expected[i].ID, i, result.ID)
+		}
+	}
+
+	// Only as many results as IDs with the prefix
+	if results := hnswIndex.SearchPrefix(query, 50, "userB:"); len(results) != 30 {
+		t.Errorf("Expected 30 userB: results, but got %d", len(results))
+	}
+	if results := hnswIndex.SearchPrefix(query, 10, "userC:"); len(results) != 0 {
+		t.Errorf("Expected no results for an unknown prefix, but got %d", len(results))
+	}
+}
+
+// Test for SearchExcludingWhere excluding vectors by an author field
+func TestSearchExcludingWhere(t *testing.T) {
+	hnswIndex := NewHNSW(5, 4)
+	for i := 0; i < 60; i++ {
+		id := fmt.Sprintf("vec-%d", i)
+		hnswIndex.AddVectorWithMetadata(id, generateRandomVector(5), map[string]any{"author": fmt.Sprintf("author-%d", i%3)})
+	}
+
+	sameAuthor := func(id string, meta map[string]any) bool {
+		return meta["author"] == "author-0"
+	}
+	query := generateRandomVector(5)
+	results := hnswIndex.SearchExcludingWhere(query, 10, sameAuthor)
+	expected := bruteForceFilter(hnswIndex, query, 10, FilterFunc(func(id string, meta map[string]any) bool {
+		return !sameAuthor(id, meta)
+	}))
+
+	if len(results) != 10 {
+		t.Fatalf("Expected 10 results, but got %d", len(results))
+	}
+	for i := range expected {
+		if results[i].ID != expected[i].ID {
+			t.Errorf("Expected result %d to be '%s', but got '%s'", i, expected[i].ID, results[i].ID)
+		}
+		if hnswIndex.nodes[results[i].ID].Metadata["author"] == "author-0" {
+			t.Errorf("Expected %s by the excluded author to be filtered out", results[i].ID)
+		}
+	}
+}
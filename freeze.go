@@ -0,0 +1,37 @@
+package gector
+
+import "errors"
+
+// ErrFrozen is returned by mutations on an index after Freeze.
+var ErrFrozen = errors.New("index is frozen")
+
+// Freeze marks the index as immutable. Afterwards every mutation returns
+// ErrFrozen and reads skip locking entirely. Freezing cannot be undone.
+func (hnsw *HNSW) Freeze() {
+	hnsw.mu.Lock()
+	defer hnsw.mu.Unlock()
+
+	hnsw.frozen.Store(true)
+}
+
+// IsFrozen reports whether Freeze has been called.
+func (hnsw *HNSW) IsFrozen() bool {
+	return hnsw.frozen.Load()
+}
+
+// readLock takes the read lock unless the index is frozen, and reports
+// whether it did so it can be passed to readUnlock.
+func (hnsw *HNSW) readLock() bool {
+	if hnsw.frozen.Load() {
+		return false
+	}
+	hnsw.mu.RLock()
+	return true
+}
+
+// readUnlock releases a read lock taken by readLock.
+func (hnsw *HNSW) readUnlock(locked bool) {
+	if locked {
+		hnsw.mu.RUnlock()
+	}
+}
@@ -0,0 +1,75 @@
+package gector
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+)
+
+// Test for Freeze rejecting mutations while searches keep working
+func TestFreeze(t *testing.T) {
+	hnswIndex := NewHNSW(5, 4)
+
+	// Build concurrently
+	var wg sync.WaitGroup
+	for w := 0; w < 4; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for i := 0; i < 25; i++ {
+				hnswIndex.AddVector(fmt.Sprintf("vec-%d-%d", w, i), generateRandomVector(5))
+			}
+		}(w)
+	}
+	wg.Wait()
+
+	if hnswIndex.IsFrozen() {
+		t.Fatalf("Expected a new index not to be frozen")
+	}
+	hnswIndex.Freeze()
+	if !hnswIndex.IsFrozen() {
+		t.Fatalf("Expected the index to be frozen after Freeze")
+	}
+
+	if err := hnswIndex.AddVector("new", generateRandomVector(5)); err != ErrFrozen {
+		t.Errorf("Expected ErrFrozen from AddVector, but got %v", err)
+	}
+	if err := hnswIndex.UpdateVector("vec-0-0", generateRandomVector(5)); err != ErrFrozen {
+		t.Errorf("Expected ErrFrozen from UpdateVector, but got %v", err)
+	}
+	if err := hnswIndex.DeleteVector("vec-0-0"); err != ErrFrozen {
+		t.Errorf("Expected ErrFrozen from DeleteVector, but got %v", err)
+	}
+	if _, err := hnswIndex.DeleteWhere(func(string, map[string]any) bool { return true }); err != ErrFrozen {
+		t.Errorf("Expected ErrFrozen from DeleteWhere, but got %v", err)
+	}
+	if _, _, err := hnswIndex.UpdateMetadataBatch(map[string]map[string]any{"vec-0-0": nil}); err != ErrFrozen {
+		t.Errorf("Expected ErrFrozen from UpdateMetadataBatch, but got %v", err)
+	}
+	if _, err := hnswIndex.PruneNeighbors(); err != ErrFrozen {
+		t.Errorf("Expected ErrFrozen from PruneNeighbors, but got %v", err)
+	}
+	if _, err := hnswIndex.TrimToTop(0); err != ErrFrozen {
+		t.Errorf("Expected ErrFrozen from TrimToTop, but got %v", err)
+	}
+	if _, err := hnswIndex.MergeDuplicates(0); err != ErrFrozen {
+		t.Errorf("Expected ErrFrozen from MergeDuplicates, but got %v", err)
+	}
+	if len(hnswIndex.nodes) != 100 {
+		t.Errorf("Expected 100 vectors after rejected mutations, but got %d", len(hnswIndex.nodes))
+	}
+
+	// Concurrent searches run without locking
+	for w := 0; w < 4; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 25; i++ {
+				if len(hnswIndex.NearestNeighbors(generateRandomVector(5), 3)) != 3 {
+					t.Errorf("Expected 3 nearest neighbors from a frozen index")
+				}
+			}
+		}()
+	}
+	wg.Wait()
+}
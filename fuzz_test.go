@@ -0,0 +1,81 @@
+package gector
+
+import (
+	"fmt"
+	"testing"
+)
+
+// FuzzIndex applies a sequence of adds, updates, deletes and searches with
+// varying dimensions decoded from the input, and checks that nothing panics
+// and the index stays consistent.
+func FuzzIndex(f *testing.F) {
+	f.Add([]byte{0, 1, 3, 0, 2, 3, 3, 0, 3, 1, 1, 3, 2, 1, 0})
+	f.Add([]byte{0, 1, 0, 0, 2, 5, 3, 1, 2, 1, 1, 4, 2, 1, 0, 3, 4, 9})
+	f.Add([]byte{3, 0, 4, 0, 0, 4, 0, 0, 7, 1, 0, 2, 3, 200, 4})
+
+	f.Fuzz(func(t *testing.T, ops []byte) {
+		hnswIndex := NewHNSW(3, 3, WithSeed(1))
+		for len(ops) >= 3 {
+			op, id, dim := ops[0]%4, fmt.Sprintf("vec-%d", ops[1]%16), int(ops[2]%6)
+			ops = ops[3:]
+
+			values := make([]float64, dim)
+			for i := range values {
+				values[i] = float64(int(id[len(id)-1])*(i+1)%7) - 3
+			}
+			vector := Vector{ID: id, Values: values}
+
+			switch op {
+			case 0:
+				hnswIndex.AddVector(id, vector)
+			case 1:
+				hnswIndex.UpdateVector(id, vector)
+			case 2:
+				hnswIndex.DeleteVector(id)
+			case 3:
+				k := int(ops2int(ops))
+				hnswIndex.NearestNeighbors(vector, k)
+				hnswIndex.SearchByID(id, k)
+				hnswIndex.SearchFilter(vector, k, FilterFunc(func(string, map[string]any) bool { return true }))
+			}
+			checkIndexInvariants(t, hnswIndex)
+		}
+	})
+}
+
+// ops2int returns a small search size taken from the remaining input.
+func ops2int(ops []byte) int {
+	if len(ops) == 0 {
+		return 1
+	}
+	return int(ops[0] % 8)
+}
+
+// Helper function to check that the maps of an index agree with each other
+func checkIndexInvariants(t *testing.T, hnswIndex *HNSW) {
+	t.Helper()
+	if hnswIndex.Len() != len(hnswIndex.nodes) {
+		t.Fatalf("Expected Len %d, but got %d", len(hnswIndex.nodes), hnswIndex.Len())
+	}
+	for id, node := range hnswIndex.nodes {
+		if node.ID != id {
+			t.Fatalf("Expected node %s to carry its ID, but got %s", id, node.ID)
+		}
+		if len(node.Vector.Values) != hnswIndex.dim {
+			t.Fatalf("Expected %s to have dimension %d, but got %d", id, hnswIndex.dim, len(node.Vector.Values))
+		}
+		if hnswIndex.levels[hnswIndex.MaxLevels-1][id] != node {
+			t.Fatalf("Expected %s on the bottom level", id)
+		}
+		if hnswIndex.slots[node.slot] != node {
+			t.Fatalf("Expected %s in slot %d", id, node.slot)
+		}
+	}
+	for level, nodes := range hnswIndex.levels {
+		for id, node := range nodes {
+			if hnswIndex.nodes[id] != node {
+				t.Fatalf("Expected %s on level %d to be stored", id, level)
+			}
+		}
+	}
+}
@@ -1 +1,3 @@
 module gector
+
+go 1.27.1
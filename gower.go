@@ -0,0 +1,59 @@
+package gector
+
+import "math"
+
+// GowerDimension describes how one dimension of a mixed record is compared
+// by GowerDistance. Numeric dimensions are scaled by their range, Max - Min;
+// categorical dimensions hold a category code and only match or differ.
+type GowerDimension struct {
+	Categorical bool
+	Min, Max    float64
+}
+
+// NumericDimension describes a numeric dimension whose values span [min, max].
+func NumericDimension(min, max float64) GowerDimension {
+	return GowerDimension{Min: min, Max: max}
+}
+
+// CategoricalDimension describes a dimension holding category codes.
+func CategoricalDimension() GowerDimension {
+	return GowerDimension{Categorical: true}
+}
+
+// GowerDistance returns a distance function over records laid out as dims,
+// for use with RegisterMetric. Each dimension contributes a score in [0, 1]:
+// the absolute difference divided by the range for numeric dimensions
+// (clamped to 1, and 0 for an empty range) and 0 or 1 for a categorical
+// match or mismatch. The distance is the mean score, so it lies in [0, 1].
+// Dimensions beyond the shorter of the two vectors and dims are ignored.
+func GowerDistance(dims []GowerDimension) DistanceFunc {
+	dims = append([]GowerDimension(nil), dims...)
+	return func(a, b Vector) float64 {
+		n := min(len(dims), len(a.Values), len(b.Values))
+		if n == 0 {
+			return 0
+		}
+
+		var sum float64
+		for i := 0; i < n; i++ {
+			sum += dims[i].score(a.Values[i], b.Values[i])
+		}
+		return sum / float64(n)
+	}
+}
+
+// score returns the contribution of one dimension to the Gower distance.
+func (d GowerDimension) score(a, b float64) float64 {
+	if d.Categorical {
+		if a == b {
+			return 0
+		}
+		return 1
+	}
+
+	span := d.Max - d.Min
+	if span <= 0 {
+		return 0
+	}
+	return math.Min(math.Abs(a-b)/span, 1)
+}
@@ -0,0 +1,42 @@
+package gector
+
+import (
+	"math"
+	"testing"
+)
+
+// Test for GowerDistance on records mixing numeric and categorical features
+func TestGowerDistance(t *testing.T) {
+	// Age in [0, 100], color code, income in [0, 1000]
+	gower := GowerDistance([]GowerDimension{
+		NumericDimension(0, 100),
+		CategoricalDimension(),
+		NumericDimension(0, 1000),
+	})
+
+	base := Vector{Values: []float64{30, 1, 500}}
+	cases := []struct {
+		other    []float64
+		expected float64
+	}{
+		{[]float64{30, 1, 500}, 0},
+		{[]float64{50, 1, 700}, (0.2 + 0 + 0.2) / 3},
+		{[]float64{30, 2, 500}, (0 + 1 + 0) / 3.0},
+		{[]float64{100, 2, 0}, (0.7 + 1 + 0.5) / 3},
+		// Differences beyond the range are clamped
+		{[]float64{300, 2, 3000}, 1},
+	}
+
+	for _, c := range cases {
+		distance := gower(base, Vector{Values: c.other})
+		if math.Abs(distance-c.expected) > 1e-9 {
+			t.Errorf("Expected Gower distance %f to %v, but got %f", c.expected, c.other, distance)
+		}
+	}
+
+	// A numeric dimension with an empty range never contributes
+	flat := GowerDistance([]GowerDimension{NumericDimension(5, 5)})
+	if distance := flat(Vector{Values: []float64{1}}, Vector{Values: []float64{9}}); distance != 0 {
+		t.Errorf("Expected distance 0 for an empty range, but got %f", distance)
+	}
+}
@@ -0,0 +1,43 @@
+package gector
+
+// growthObserverInterval is the number of inserts between two reports of a
+// graph growth observer. Counting the edges is linear in the size of the
+// index, so it is not done on every insert.
+const growthObserverInterval = 1000
+
+// growthObserver periodically reports the number of nodes and edges.
+type growthObserver struct {
+	fn func(nodes, edges int)
+	// Number of inserts since the index was created
+	inserts int
+}
+
+// observeGrowth counts an insert for the growth observer and measures the
+// graph when a report is due, returning the callback to run or nil. The
+// caller must hold the write lock.
+func (hnsw *HNSW) observeGrowth() func() {
+	observer := hnsw.growthObserver
+	if observer == nil {
+		return nil
+	}
+
+	observer.inserts++
+	if observer.inserts%growthObserverInterval != 0 {
+		return nil
+	}
+
+	nodes, edges := len(hnsw.nodes), hnsw.countEdges()
+	return func() {
+		observer.fn(nodes, edges)
+	}
+}
+
+// countEdges returns the total length of the neighbor lists. The caller must
+// hold the lock.
+func (hnsw *HNSW) countEdges() int {
+	edges := 0
+	for _, node := range hnsw.nodes {
+		edges += len(node.Neighbors)
+	}
+	return edges
+}
@@ -0,0 +1,33 @@
+package gector
+
+import (
+	"fmt"
+	"testing"
+)
+
+// Test for WithGraphGrowthObserver reporting monotonically growing edge counts
+func TestGraphGrowthObserver(t *testing.T) {
+	var nodes, edges []int
+	hnswIndex := NewHNSW(5, 4, WithMaxInsertCandidates(16), WithGraphGrowthObserver(func(n, e int) {
+		nodes = append(nodes, n)
+		edges = append(edges, e)
+	}))
+	for i := 0; i < 3*growthObserverInterval+10; i++ {
+		hnswIndex.AddVector(fmt.Sprintf("vec-%d", i), generateRandomVector(5))
+	}
+
+	if len(edges) != 3 {
+		t.Fatalf("Expected 3 reports, but got %d", len(edges))
+	}
+	for i := range edges {
+		if nodes[i] != (i+1)*growthObserverInterval {
+			t.Errorf("Expected %d nodes in report %d, but got %d", (i+1)*growthObserverInterval, i, nodes[i])
+		}
+		if i > 0 && edges[i] <= edges[i-1] {
+			t.Errorf("Expected edges to grow, but got %d after %d", edges[i], edges[i-1])
+		}
+	}
+	if edges[0] == 0 {
+		t.Errorf("Expected edges after %d inserts, but got 0", growthObserverInterval)
+	}
+}
@@ -0,0 +1,30 @@
+package gector
+
+// Health reports the state of an index for liveness and readiness checks.
+type Health struct {
+	// Live is true for any usable index
+	Live bool
+	// Ready is false while the graph is being rebuilt, when traffic should
+	// be held back
+	Ready bool
+	// Number of stored vectors
+	Size int
+	// Whether a full rebuild, such as ReindexWithMetric, is in progress
+	Rebuilding bool
+	// Whether a background snapshot is being written; snapshots do not
+	// block searches, so they do not affect readiness
+	Snapshotting bool
+}
+
+// Health returns the current health of the index. It never waits for the
+// index lock, so it can be polled while a rebuild holds it.
+func (hnsw *HNSW) Health() Health {
+	rebuilding := hnsw.rebuilding.Load()
+	return Health{
+		Live:         true,
+		Ready:        !rebuilding,
+		Size:         int(hnsw.size.Load()),
+		Rebuilding:   rebuilding,
+		Snapshotting: hnsw.snapshotting.Load() > 0,
+	}
+}
@@ -0,0 +1,52 @@
+package gector
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+)
+
+// Test for Health reporting not ready while the graph is rebuilt
+func TestHealth(t *testing.T) {
+	hnswIndex := NewHNSW(3, 3)
+	for i := 0; i < 20; i++ {
+		hnswIndex.AddVector(fmt.Sprintf("vec-%d", i), generateRandomVector(3))
+	}
+
+	health := hnswIndex.Health()
+	if !health.Live || !health.Ready || health.Size != 20 || health.Rebuilding {
+		t.Fatalf("Expected a live, ready index of 20 vectors, but got %+v", health)
+	}
+
+	// A distance function that blocks holds the rebuild in progress
+	started := make(chan struct{})
+	release := make(chan struct{})
+	var once sync.Once
+	blocking := func(a, b Vector) float64 {
+		once.Do(func() {
+			close(started)
+			<-release
+		})
+		return EuclideanDistance(a, b)
+	}
+
+	done := make(chan error)
+	go func() {
+		done <- hnswIndex.ReindexWithMetric(blocking)
+	}()
+
+	<-started
+	health = hnswIndex.Health()
+	if health.Ready || !health.Rebuilding || !health.Live {
+		t.Errorf("Expected a live index that is not ready during the rebuild, but got %+v", health)
+	}
+	close(release)
+	if err := <-done; err != nil {
+		t.Fatalf("Error reindexing: %v", err)
+	}
+
+	health = hnswIndex.Health()
+	if !health.Ready || health.Rebuilding || health.Size != 20 {
+		t.Errorf("Expected a ready index of 20 vectors after the rebuild, but got %+v", health)
+	}
+}
@@ -0,0 +1,79 @@
+package gector
+
+import "slices"
+
+// resultHeap is a max-heap of search results keyed on distance, so the worst
+// of the current best results sits at the root. It is maintained by hand
+// rather than through container/heap to avoid boxing results on every push.
+type resultHeap []SearchResult
+
+// offer adds the result if fewer than k results are held or it beats the worst one.
+func (h *resultHeap) offer(result SearchResult, k int) {
+	if len(*h) < k {
+		*h = append(*h, result)
+		h.up(len(*h) - 1)
+		return
+	}
+	if resultLess(result, (*h)[0]) {
+		(*h)[0] = result
+		h.down(0)
+	}
+}
+
+// up moves the result at i towards the root until the heap is ordered.
+func (h resultHeap) up(i int) {
+	for i > 0 {
+		parent := (i - 1) / 2
+		if !resultLess(h[parent], h[i]) {
+			break
+		}
+		h[parent], h[i] = h[i], h[parent]
+		i = parent
+	}
+}
+
+// down moves the result at i away from the root until the heap is ordered.
+func (h resultHeap) down(i int) {
+	for {
+		worst := 2*i + 1
+		if worst >= len(h) {
+			return
+		}
+		if right := worst + 1; right < len(h) && resultLess(h[worst], h[right]) {
+			worst = right
+		}
+		if !resultLess(h[i], h[worst]) {
+			return
+		}
+		h[i], h[worst] = h[worst], h[i]
+		i = worst
+	}
+}
+
+// sorted orders the held results by ascending distance in place and returns them.
+func (h resultHeap) sorted() []SearchResult {
+	results := []SearchResult(h)
+	sortResults(results)
+	return results
+}
+
+// resultLess orders results by distance, breaking ties by ID so results are deterministic.
+func resultLess(a, b SearchResult) bool {
+	if a.Distance != b.Distance {
+		return a.Distance < b.Distance
+	}
+	return a.ID < b.ID
+}
+
+// sortResults sorts results by ascending distance.
+func sortResults(results []SearchResult) {
+	slices.SortFunc(results, func(a, b SearchResult) int {
+		if resultLess(a, b) {
+			return -1
+		}
+		if resultLess(b, a) {
+			return 1
+		}
+		return 0
+	})
+}
@@ -0,0 +1,121 @@
+package gector
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+)
+
+// hnswlibEfConstruction is the ef_construction written to hnswlib headers.
+// The index has no such parameter, so hnswlib's default is used.
+const hnswlibEfConstruction = 200
+
+// ExportHNSWLib writes the index in the on-disk layout of hnswlib's
+// HierarchicalNSW<float>, for loading with hnswlib.Index.load_index under the
+// "l2" space, or the "cosine" space for Cosine indexes (vectors are written
+// normalized, as hnswlib stores them). Indexes using a custom distance cannot
+// be exported.
+//
+// hnswlib labels are integers: the label of a vector is the position of its
+// ID in ascending order. hnswlib counts levels up from the bottom, and every
+// level of a node is written with the node's single neighbor list.
+func (hnsw *HNSW) ExportHNSWLib(w io.Writer) error {
+	locked := hnsw.readLock()
+	if hnsw.distance != nil {
+		hnsw.readUnlock(locked)
+		return fmt.Errorf("indexes with a custom distance cannot be exported to hnswlib")
+	}
+	s := hnsw.snapshot()
+	hnsw.readUnlock(locked)
+
+	index := make(map[string]uint32, len(s.Nodes))
+	for i, n := range s.Nodes {
+		if len(n.Values) != s.Dim {
+			return &VectorError{ID: n.ID, Op: "export", Want: s.Dim, Got: len(n.Values)}
+		}
+		index[n.ID] = uint32(i)
+	}
+
+	// Sizes of hnswlib's per-element blocks: a uint32 link count followed by
+	// the links, then float32 data and a size_t label on the bottom level
+	maxM := s.MaxNeighbors
+	maxM0 := 2 * maxM
+	sizeLinks0 := 4 + 4*maxM0
+	sizeLinks := 4 + 4*maxM
+	dataSize := 4 * s.Dim
+	sizePerElement := sizeLinks0 + dataSize + 8
+
+	maxLevel := -1
+	enterPoint := uint32(math.MaxUint32)
+	for i, n := range s.Nodes {
+		if level := s.MaxLevels - 1 - n.Level; level > maxLevel {
+			maxLevel = level
+			enterPoint = uint32(i)
+		}
+	}
+
+	var buf []byte
+	count := uint64(len(s.Nodes))
+	buf = binary.LittleEndian.AppendUint64(buf, 0) // offsetLevel0_
+	buf = binary.LittleEndian.AppendUint64(buf, count)
+	buf = binary.LittleEndian.AppendUint64(buf, count)
+	buf = binary.LittleEndian.AppendUint64(buf, uint64(sizePerElement))
+	buf = binary.LittleEndian.AppendUint64(buf, uint64(sizeLinks0+dataSize)) // label_offset_
+	buf = binary.LittleEndian.AppendUint64(buf, uint64(sizeLinks0))          // offsetData_
+	buf = binary.LittleEndian.AppendUint32(buf, uint32(int32(maxLevel)))
+	buf = binary.LittleEndian.AppendUint32(buf, enterPoint)
+	buf = binary.LittleEndian.AppendUint64(buf, uint64(maxM))
+	buf = binary.LittleEndian.AppendUint64(buf, uint64(maxM0))
+	buf = binary.LittleEndian.AppendUint64(buf, uint64(maxM))
+	buf = binary.LittleEndian.AppendUint64(buf, math.Float64bits(1/math.Log(float64(max(maxM, 2)))))
+	buf = binary.LittleEndian.AppendUint64(buf, hnswlibEfConstruction)
+
+	// links resolves a node's neighbor list to element indices, capped at limit
+	links := func(n snapshotNode, limit int) []uint32 {
+		out := make([]uint32, 0, limit)
+		for _, neighborID := range n.Neighbors {
+			if i, exists := index[neighborID]; exists && len(out) < limit {
+				out = append(out, i)
+			}
+		}
+		return out
+	}
+	appendLinks := func(buf []byte, ids []uint32, capacity int) []byte {
+		buf = binary.LittleEndian.AppendUint32(buf, uint32(len(ids)))
+		for i := 0; i < capacity; i++ {
+			var id uint32
+			if i < len(ids) {
+				id = ids[i]
+			}
+			buf = binary.LittleEndian.AppendUint32(buf, id)
+		}
+		return buf
+	}
+
+	// Bottom level block: links, data and label of every element
+	for i, n := range s.Nodes {
+		buf = appendLinks(buf, links(n, maxM0), maxM0)
+		scale := 1.0
+		if s.Metric == Cosine {
+			scale = inverseNorm(NormRaw(n.Values))
+		}
+		for _, value := range n.Values {
+			buf = binary.LittleEndian.AppendUint32(buf, math.Float32bits(float32(value*scale)))
+		}
+		buf = binary.LittleEndian.AppendUint64(buf, uint64(i))
+	}
+
+	// Upper level link lists, prefixed by their total size in bytes
+	for _, n := range s.Nodes {
+		levels := s.MaxLevels - 1 - n.Level
+		buf = binary.LittleEndian.AppendUint32(buf, uint32(levels*sizeLinks))
+		ids := links(n, maxM)
+		for level := 0; level < levels; level++ {
+			buf = appendLinks(buf, ids, maxM)
+		}
+	}
+
+	_, err := w.Write(buf)
+	return err
+}
@@ -0,0 +1,93 @@
+package gector
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"testing"
+)
+
+// hnswlibHeader mirrors the header fields written by hnswlib's saveIndex.
+type hnswlibHeader struct {
+	OffsetLevel0       uint64
+	MaxElements        uint64
+	CurElementCount    uint64
+	SizeDataPerElement uint64
+	LabelOffset        uint64
+	OffsetData         uint64
+	MaxLevel           int32
+	EnterPoint         uint32
+	MaxM               uint64
+	MaxM0              uint64
+	M                  uint64
+	Mult               float64
+	EfConstruction     uint64
+}
+
+// Test for ExportHNSWLib writing hnswlib's header and block layout
+func TestExportHNSWLib(t *testing.T) {
+	const dim = 6
+	hnswIndex := NewHNSW(4, 3, WithSeed(1))
+	for i := 0; i < 30; i++ {
+		hnswIndex.AddVector(fmt.Sprintf("vec-%02d", i), generateRandomVector(dim))
+	}
+
+	var buf bytes.Buffer
+	if err := hnswIndex.ExportHNSWLib(&buf); err != nil {
+		t.Fatalf("Error exporting to hnswlib: %v", err)
+	}
+
+	var header hnswlibHeader
+	if err := binary.Read(&buf, binary.LittleEndian, &header); err != nil {
+		t.Fatalf("Error reading header: %v", err)
+	}
+	sizeLinks0 := uint64(4 + 4*8)
+	if header.OffsetLevel0 != 0 || header.MaxElements != 30 || header.CurElementCount != 30 {
+		t.Errorf("Expected 30 elements at offset 0, but got %+v", header)
+	}
+	if header.MaxM != 4 || header.M != 4 || header.MaxM0 != 8 {
+		t.Errorf("Expected M 4 and maxM0 8, but got %+v", header)
+	}
+	if header.OffsetData != sizeLinks0 || header.LabelOffset != sizeLinks0+4*dim || header.SizeDataPerElement != sizeLinks0+4*dim+8 {
+		t.Errorf("Expected hnswlib element offsets, but got %+v", header)
+	}
+	if math.Abs(header.Mult-1/math.Log(4)) > 1e-12 {
+		t.Errorf("Expected mult 1/ln(4), but got %f", header.Mult)
+	}
+
+	// The entry point sits on the highest hnswlib level
+	nodes := hnswIndex.snapshot().Nodes
+	entry := nodes[header.EnterPoint]
+	if int(header.MaxLevel) != hnswIndex.MaxLevels-1-entry.Level {
+		t.Errorf("Expected the entry point on level %d, but got %d", header.MaxLevel, hnswIndex.MaxLevels-1-entry.Level)
+	}
+
+	// The data block holds every element's vector and label
+	level0 := buf.Next(int(header.CurElementCount * header.SizeDataPerElement))
+	for i, n := range nodes {
+		element := level0[uint64(i)*header.SizeDataPerElement:]
+		value := math.Float32frombits(binary.LittleEndian.Uint32(element[header.OffsetData:]))
+		if value != float32(n.Values[0]) {
+			t.Errorf("Expected the first value of %s to be %f, but got %f", n.ID, n.Values[0], value)
+		}
+		if label := binary.LittleEndian.Uint64(element[header.LabelOffset:]); label != uint64(i) {
+			t.Errorf("Expected label %d for %s, but got %d", i, n.ID, label)
+		}
+	}
+
+	// Every element is followed by its upper level link lists
+	for _, n := range nodes {
+		var size uint32
+		if err := binary.Read(&buf, binary.LittleEndian, &size); err != nil {
+			t.Fatalf("Error reading link list size: %v", err)
+		}
+		if expected := uint32((hnswIndex.MaxLevels - 1 - n.Level) * (4 + 4*4)); size != expected {
+			t.Errorf("Expected link list size %d for %s, but got %d", expected, n.ID, size)
+		}
+		buf.Next(int(size))
+	}
+	if buf.Len() != 0 {
+		t.Errorf("Expected no trailing bytes, but got %d", buf.Len())
+	}
+}
@@ -0,0 +1,31 @@
+package gector
+
+import "sync/atomic"
+
+// IndexHolder holds the index currently serving queries and swaps it for a
+// new one atomically, for reloads without downtime: build the replacement in
+// the background, then Store it. Readers that called Load before the swap
+// keep using the old index until they finish, and the old index is collected
+// once they drop it. The zero value holds no index and is ready to use.
+type IndexHolder struct {
+	current atomic.Pointer[HNSW]
+}
+
+// NewIndexHolder returns a holder serving hnsw.
+func NewIndexHolder(hnsw *HNSW) *IndexHolder {
+	holder := &IndexHolder{}
+	holder.Store(hnsw)
+	return holder
+}
+
+// Load returns the index currently held, or nil when none was stored. A
+// request should call it once and use the returned index throughout, so that
+// it sees a single index even if a swap happens meanwhile.
+func (holder *IndexHolder) Load() *HNSW {
+	return holder.current.Load()
+}
+
+// Store replaces the held index. It never waits for searches on the old one.
+func (holder *IndexHolder) Store(hnsw *HNSW) {
+	holder.current.Store(hnsw)
+}
@@ -0,0 +1,59 @@
+package gector
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+)
+
+// Test for IndexHolder swapping indexes under concurrent searches, meant to
+// run with -race
+func TestIndexHolder(t *testing.T) {
+	build := func(generation int) *HNSW {
+		hnswIndex := NewHNSW(5, 3)
+		hnswIndex.Tag = fmt.Sprintf("gen-%d", generation)
+		for i := 0; i < 50; i++ {
+			id := fmt.Sprintf("gen-%d-%d", generation, i)
+			hnswIndex.AddVector(id, Vector{ID: id, Values: []float64{float64(i), float64(generation)}})
+		}
+		return hnswIndex
+	}
+
+	var empty IndexHolder
+	if empty.Load() != nil {
+		t.Errorf("Expected the zero holder to hold no index")
+	}
+
+	holder := NewIndexHolder(build(0))
+	var wg sync.WaitGroup
+	errs := make(chan error, 8)
+	for w := 0; w < 8; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 200; i++ {
+				hnswIndex := holder.Load()
+				results, _ := hnswIndex.SearchWithStats(Vector{Values: []float64{1, 0}}, 3)
+				// Every result comes from the index loaded for this query
+				for _, result := range results {
+					if _, exists := hnswIndex.nodes[result.ID]; !exists || len(results) != 3 {
+						errs <- fmt.Errorf("result %s is not from the loaded index %s", result.ID, hnswIndex.Version())
+						return
+					}
+				}
+			}
+		}()
+	}
+	for generation := 1; generation <= 20; generation++ {
+		holder.Store(build(generation))
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Error(err)
+	}
+
+	if version := holder.Load().Version(); version != "gen-20" {
+		t.Errorf("Expected the last stored index gen-20, but got %s", version)
+	}
+}
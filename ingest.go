@@ -0,0 +1,83 @@
+package gector
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ingestBatchSize is the max number of items IngestChannel inserts under one
+// acquisition of the write lock.
+const ingestBatchSize = 256
+
+// IDVector is an item of a streaming ingest.
+type IDVector struct {
+	ID     string
+	Vector Vector
+}
+
+// IngestChannel inserts the items received from ch until it is closed. Items
+// that are already waiting in the channel are inserted together in batches of
+// up to ingestBatchSize under a single write lock. Items with an empty ID or a
+// dimension different from the index are skipped, and their errors are
+// returned together once the channel is closed. A frozen index is a fatal
+// error: IngestChannel returns ErrFrozen immediately and leaves the rest of
+// the items in the channel.
+func (hnsw *HNSW) IngestChannel(ch <-chan IDVector) error {
+	var errs []error
+	batch := make([]IDVector, 0, ingestBatchSize)
+	for item := range ch {
+		batch = append(batch[:0], item)
+	drain:
+		for len(batch) < ingestBatchSize {
+			select {
+			case next, ok := <-ch:
+				if !ok {
+					break drain
+				}
+				batch = append(batch, next)
+			default:
+				break drain
+			}
+		}
+
+		batchErrs, err := hnsw.ingestBatch(batch)
+		if err != nil {
+			return err
+		}
+		errs = append(errs, batchErrs...)
+	}
+	return errors.Join(errs...)
+}
+
+// ingestBatch inserts a batch of items under one write lock and returns the
+// errors of the skipped items, or a fatal error when nothing could be inserted.
+func (hnsw *HNSW) ingestBatch(batch []IDVector) ([]error, error) {
+	hnsw.mu.Lock()
+	if hnsw.frozen.Load() {
+		hnsw.mu.Unlock()
+		return nil, ErrFrozen
+	}
+
+	var errs []error
+	notifies := make([]func(), 0, len(batch))
+	for _, item := range batch {
+		if item.ID == "" {
+			errs = append(errs, fmt.Errorf("vector without an id"))
+			continue
+		}
+		if err := hnsw.checkDimension("ingest", item.ID, item.Vector); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+
+		hnsw.addVector(item.ID, item.Vector, nil)
+		hnsw.recordDelta(DeltaAdd, item.ID, item.Vector, nil)
+		notifies = append(notifies, hnsw.afterInsert())
+	}
+	hnsw.mu.Unlock()
+
+	for _, notify := range notifies {
+		notify()
+	}
+	return errs, nil
+}
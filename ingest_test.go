@@ -0,0 +1,47 @@
+package gector
+
+import (
+	"fmt"
+	"testing"
+)
+
+// Test for IngestChannel inserting every item fed through a channel
+func TestIngestChannel(t *testing.T) {
+	hnswIndex := NewHNSW(5, 4)
+	ch := make(chan IDVector, 64)
+	go func() {
+		for i := 0; i < 1000; i++ {
+			id := fmt.Sprintf("vec-%d", i)
+			ch <- IDVector{ID: id, Vector: Vector{ID: id, Values: generateRandomVector(8).Values}}
+		}
+		// Non-fatal errors are skipped and reported at the end
+		ch <- IDVector{ID: "short", Vector: Vector{Values: []float64{1}}}
+		ch <- IDVector{Vector: generateRandomVector(8)}
+		close(ch)
+	}()
+
+	err := hnswIndex.IngestChannel(ch)
+	if err == nil {
+		t.Errorf("Expected the skipped items to be reported, but got nil")
+	}
+	if len(hnswIndex.nodes) != 1000 {
+		t.Fatalf("Expected 1000 vectors, but got %d", len(hnswIndex.nodes))
+	}
+	for i := 0; i < 1000; i++ {
+		if _, ok := hnswIndex.GetVector(fmt.Sprintf("vec-%d", i)); !ok {
+			t.Errorf("Expected vec-%d to be ingested", i)
+		}
+	}
+	if _, ok := hnswIndex.GetVector("short"); ok {
+		t.Errorf("Expected the vector with the wrong dimension to be skipped")
+	}
+
+	// A frozen index stops the ingest
+	hnswIndex.Freeze()
+	ch = make(chan IDVector, 1)
+	ch <- IDVector{ID: "late", Vector: generateRandomVector(8)}
+	close(ch)
+	if err := hnswIndex.IngestChannel(ch); err != ErrFrozen {
+		t.Errorf("Expected ErrFrozen, but got %v", err)
+	}
+}
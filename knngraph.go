@@ -0,0 +1,99 @@
+package gector
+
+import (
+	"runtime"
+	"sort"
+	"sync"
+)
+
+// BuildKNNGraph returns the k nearest neighbors of every stored vector,
+// excluding the vector itself, keyed by ID. Nodes are spread across
+// GOMAXPROCS workers, each running a search per node under the read lock.
+// Results do not go through the query cache and do not count as search hits.
+func (hnsw *HNSW) BuildKNNGraph(k int) map[string][]SearchResult {
+	defer hnsw.readUnlock(hnsw.readLock())
+	return hnsw.knnGraph(k)
+}
+
+// knnGraph is BuildKNNGraph without locking. The caller must hold the lock.
+func (hnsw *HNSW) knnGraph(k int) map[string][]SearchResult {
+	graph := make(map[string][]SearchResult, len(hnsw.nodes))
+	if k <= 0 {
+		return graph
+	}
+
+	nodes := make(chan *HNSWNode)
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for w := 0; w < runtime.GOMAXPROCS(0); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for node := range nodes {
+				// Ask for one extra result to make up for dropping the node itself
+				results, _ := hnsw.scan(node.Vector, k+1)
+				neighbors := make([]SearchResult, 0, k)
+				for _, result := range results {
+					if result.ID != node.ID && len(neighbors) < k {
+						neighbors = append(neighbors, result)
+					}
+				}
+
+				mu.Lock()
+				graph[node.ID] = neighbors
+				mu.Unlock()
+			}
+		}()
+	}
+
+	for _, node := range hnsw.nodes {
+		nodes <- node
+	}
+	close(nodes)
+	wg.Wait()
+
+	return graph
+}
+
+// Outliers returns the topN stored vectors with the largest mean distance to
+// their k nearest neighbors, the ones farthest from everything around them,
+// most isolated first. The Distance of each result holds that mean. It builds
+// the k-NN graph of the whole index, so it costs a search per stored vector.
+// Vectors without neighbors, as in an index of one, are not ranked.
+func (hnsw *HNSW) Outliers(k int, topN int) []SearchResult {
+	defer hnsw.readUnlock(hnsw.readLock())
+
+	if k <= 0 || topN <= 0 {
+		return nil
+	}
+
+	graph := hnsw.knnGraph(k)
+	outliers := make([]SearchResult, 0, len(graph))
+	for id, neighbors := range graph {
+		if len(neighbors) == 0 {
+			continue
+		}
+		var total float64
+		for _, neighbor := range neighbors {
+			total += neighbor.Distance
+		}
+		node := hnsw.nodes[id]
+		outliers = append(outliers, SearchResult{
+			ID:       id,
+			Vector:   node.Vector,
+			Distance: total / float64(len(neighbors)),
+			Metadata: hnsw.resultMetadata(node),
+		})
+	}
+
+	sort.Slice(outliers, func(i, j int) bool {
+		if outliers[i].Distance != outliers[j].Distance {
+			return outliers[i].Distance > outliers[j].Distance
+		}
+		return outliers[i].ID < outliers[j].ID
+	})
+	if len(outliers) > topN {
+		outliers = outliers[:topN]
+	}
+	return outliers
+}
@@ -0,0 +1,70 @@
+package gector
+
+import (
+	"fmt"
+	"testing"
+)
+
+// Test for BuildKNNGraph against brute-force all-pairs distances
+func TestBuildKNNGraph(t *testing.T) {
+	hnswIndex := NewHNSW(5, 4)
+	vectors := make([]Vector, 60)
+	for i := range vectors {
+		vectors[i] = generateRandomVector(5)
+		vectors[i].ID = fmt.Sprintf("vec-%d", i)
+		hnswIndex.AddVector(vectors[i].ID, vectors[i])
+	}
+
+	graph := hnswIndex.BuildKNNGraph(4)
+	if len(graph) != len(vectors) {
+		t.Fatalf("Expected %d entries, but got %d", len(vectors), len(graph))
+	}
+
+	matrix := PairwiseDistances(vectors, EuclideanDistance)
+	for i, vector := range vectors {
+		var expected []SearchResult
+		for j := range vectors {
+			if j != i {
+				expected = append(expected, SearchResult{ID: vectors[j].ID, Distance: matrix[i][j]})
+			}
+		}
+		sortResults(expected)
+		expected = expected[:4]
+
+		neighbors := graph[vector.ID]
+		if len(neighbors) != len(expected) {
+			t.Fatalf("Expected %d neighbors for %s, but got %d", len(expected), vector.ID, len(neighbors))
+		}
+		for r := range expected {
+			if neighbors[r].ID != expected[r].ID {
+				t.Errorf("Expected neighbor %d of %s to be %s, but got %s", r, vector.ID, expected[r].ID, neighbors[r].ID)
+			}
+		}
+	}
+}
+
+// Test for Outliers ranking a planted isolated vector first
+func TestOutliers(t *testing.T) {
+	hnswIndex := NewHNSW(5, 4)
+	for i := 0; i < 80; i++ {
+		hnswIndex.AddVector(fmt.Sprintf("vec-%d", i), generateRandomVector(3))
+	}
+	hnswIndex.AddVector("outlier", Vector{ID: "outlier", Values: []float64{1000, 1000, 1000}})
+
+	outliers := hnswIndex.Outliers(5, 3)
+	if len(outliers) != 3 {
+		t.Fatalf("Expected 3 outliers, but got %d", len(outliers))
+	}
+	if outliers[0].ID != "outlier" {
+		t.Errorf("Expected the planted outlier first, but got %s", outliers[0].ID)
+	}
+	for i := 1; i < len(outliers); i++ {
+		if outliers[i].Distance > outliers[i-1].Distance {
+			t.Errorf("Expected outliers ordered by descending mean distance, but got %v after %v", outliers[i].Distance, outliers[i-1].Distance)
+		}
+	}
+
+	if outliers := NewHNSW(5, 4).Outliers(5, 3); len(outliers) != 0 {
+		t.Errorf("Expected no outliers for an empty index, but got %v", outliers)
+	}
+}
@@ -0,0 +1,93 @@
+package gector
+
+import (
+	"math"
+	"math/bits"
+	"sync/atomic"
+	"time"
+)
+
+// latencySubBits sets the precision of the latency histogram: every power of
+// two range of nanoseconds is split into 2^latencySubBits buckets, so a
+// reported latency is within about 6% of the true one.
+const latencySubBits = 4
+
+// latencySubBuckets is the number of buckets per power of two.
+const latencySubBuckets = 1 << latencySubBits
+
+// latencyBuckets covers every int64 duration in nanoseconds.
+const latencyBuckets = (64 - latencySubBits) * latencySubBuckets
+
+// latencyPercentiles are the percentiles reported by LatencyPercentiles.
+var latencyPercentiles = []float64{0.5, 0.9, 0.99}
+
+// latencyHistogram counts search latencies in log-linear buckets, in the
+// style of an HDR histogram. Searches record concurrently under the read
+// lock, so the counters are atomic.
+type latencyHistogram struct {
+	counts [latencyBuckets]atomic.Int64
+}
+
+// observe records the time elapsed since start.
+func (h *latencyHistogram) observe(start time.Time) {
+	h.counts[latencyBucket(uint64(max(time.Since(start), 0)))].Add(1)
+}
+
+// latencyBucket returns the bucket of a latency in nanoseconds. Values below
+// latencySubBuckets have a bucket each; larger ones keep their top
+// latencySubBits+1 bits.
+func latencyBucket(ns uint64) int {
+	if ns < latencySubBuckets {
+		return int(ns)
+	}
+	shift := bits.Len64(ns) - latencySubBits - 1
+	return latencySubBuckets + shift*latencySubBuckets + int(ns>>shift) - latencySubBuckets
+}
+
+// latencyBucketMax returns the largest latency in nanoseconds that falls in
+// the bucket.
+func latencyBucketMax(bucket int) uint64 {
+	if bucket < latencySubBuckets {
+		return uint64(bucket)
+	}
+	shift := (bucket - latencySubBuckets) / latencySubBuckets
+	top := uint64(bucket%latencySubBuckets + latencySubBuckets)
+	return (top+1)<<shift - 1
+}
+
+// LatencyPercentiles returns the p50, p90 and p99 latencies of the searches
+// run so far, keyed by 0.5, 0.9 and 0.99. Each is the upper bound of the
+// histogram bucket holding the percentile. Only searches that go through
+// the standard search path, such as NearestNeighbors and SearchWithStats,
+// are recorded. It returns nil unless the index was created with
+// WithLatencyHistogram, or before the first search.
+func (hnsw *HNSW) LatencyPercentiles() map[float64]time.Duration {
+	h := hnsw.latency
+	if h == nil {
+		return nil
+	}
+
+	var counts [latencyBuckets]int64
+	var total int64
+	for i := range counts {
+		counts[i] = h.counts[i].Load()
+		total += counts[i]
+	}
+	if total == 0 {
+		return nil
+	}
+
+	percentiles := make(map[float64]time.Duration, len(latencyPercentiles))
+	for _, p := range latencyPercentiles {
+		rank := int64(math.Ceil(p * float64(total)))
+		var seen int64
+		for bucket, count := range counts {
+			seen += count
+			if seen >= rank {
+				percentiles[p] = time.Duration(min(latencyBucketMax(bucket), math.MaxInt64))
+				break
+			}
+		}
+	}
+	return percentiles
+}
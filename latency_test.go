@@ -0,0 +1,55 @@
+package gector
+
+import (
+	"fmt"
+	"math"
+	"testing"
+	"time"
+)
+
+// Test for latency buckets bounding their values within the histogram precision
+func TestLatencyBucket(t *testing.T) {
+	for _, ns := range []uint64{0, 1, 15, 16, 17, 31, 32, 1000, 123456789, math.MaxInt64} {
+		bucket := latencyBucket(ns)
+		if bucket < 0 || bucket >= latencyBuckets {
+			t.Fatalf("Expected a bucket in [0, %d) for %d, but got %d", latencyBuckets, ns, bucket)
+		}
+		upper := latencyBucketMax(bucket)
+		if upper < ns || float64(upper-ns) > float64(ns)/latencySubBuckets {
+			t.Errorf("Expected bucket bound %d to be just above %d", upper, ns)
+		}
+		if bucket > 0 && latencyBucketMax(bucket-1) >= ns {
+			t.Errorf("Expected %d to be above the previous bucket bound %d", ns, latencyBucketMax(bucket-1))
+		}
+	}
+}
+
+// Test for LatencyPercentiles after many searches
+func TestLatencyPercentiles(t *testing.T) {
+	hnswIndex := NewHNSW(5, 4, WithLatencyHistogram())
+	if hnswIndex.LatencyPercentiles() != nil {
+		t.Errorf("Expected no percentiles before the first search")
+	}
+	for i := 0; i < 200; i++ {
+		hnswIndex.AddVector(fmt.Sprintf("vec-%d", i), generateRandomVector(8))
+	}
+	for i := 0; i < 500; i++ {
+		hnswIndex.NearestNeighbors(generateRandomVector(8), 10)
+	}
+
+	percentiles := hnswIndex.LatencyPercentiles()
+	if len(percentiles) != 3 {
+		t.Fatalf("Expected 3 percentiles, but got %v", percentiles)
+	}
+	p50, p90, p99 := percentiles[0.5], percentiles[0.9], percentiles[0.99]
+	if p50 <= 0 || p50 > p90 || p90 > p99 {
+		t.Errorf("Expected 0 < p50 <= p90 <= p99, but got %v, %v, %v", p50, p90, p99)
+	}
+	if p99 > time.Second {
+		t.Errorf("Expected p99 well under a second, but got %v", p99)
+	}
+
+	if NewHNSW(5, 4).LatencyPercentiles() != nil {
+		t.Errorf("Expected no percentiles without WithLatencyHistogram")
+	}
+}
@@ -0,0 +1,151 @@
+package gector
+
+import (
+	"math"
+	"slices"
+	"sort"
+)
+
+// DeleteWhere removes every vector whose ID and metadata match the predicate
+// and returns how many were deleted. The write lock is held for the whole
+// operation, and the neighbor lists that pointed at deleted vectors are
+// repaired once at the end.
+func (hnsw *HNSW) DeleteWhere(pred func(id string, meta map[string]any) bool) (int, error) {
+	hnsw.mu.Lock()
+	defer hnsw.mu.Unlock()
+
+	if hnsw.frozen.Load() {
+		return 0, ErrFrozen
+	}
+
+	deleted := make(map[string]bool)
+	for id, node := range hnsw.nodes {
+		if pred(id, node.Metadata) {
+			deleted[id] = true
+		}
+	}
+	for id := range deleted {
+		hnsw.deleteVector(id)
+		hnsw.recordDelta(DeltaDelete, id, Vector{}, nil)
+	}
+
+	hnsw.recordRepair(deleted)
+	hnsw.repairNeighbors(deleted)
+	return len(deleted), nil
+}
+
+// UpdateMetadataBatch replaces the metadata of many vectors under a single
+// write lock, keyed by ID. Vectors and neighbor lists are untouched, while
+// the categorical bitmaps follow the new values. It returns how many vectors
+// were updated and the IDs that were not found, in sorted order.
+func (hnsw *HNSW) UpdateMetadataBatch(updates map[string]map[string]any) (int, []string, error) {
+	hnsw.mu.Lock()
+	defer hnsw.mu.Unlock()
+
+	if hnsw.frozen.Load() {
+		return 0, nil, ErrFrozen
+	}
+
+	updated := 0
+	var missing []string
+	for id, metadata := range updates {
+		node, exists := hnsw.nodes[id]
+		if !exists {
+			missing = append(missing, id)
+			continue
+		}
+		hnsw.setMetadata(node, metadata)
+		hnsw.recordDelta(DeltaMetadata, id, Vector{}, metadata)
+		updated++
+	}
+	sort.Strings(missing)
+	return updated, missing, nil
+}
+
+// setMetadata replaces a node's metadata and moves it between the categorical
+// bitmaps. The caller must hold the write lock.
+func (hnsw *HNSW) setMetadata(node *HNSWNode, metadata map[string]any) {
+	hnsw.invalidateCache()
+	hnsw.unindexCategories(node)
+	node.Metadata = metadata
+	hnsw.indexCategories(node)
+}
+
+// repairNeighbors relinks every remaining node that pointed at a removed ID.
+// Relinking adds back-edges that change later nodes, so nodes are visited in
+// ID order for replicas replaying the repair to reach the same graph. The
+// caller must hold the write lock.
+func (hnsw *HNSW) repairNeighbors(removed map[string]bool) {
+	if len(removed) == 0 {
+		return
+	}
+	var ids []string
+	if hnsw.inEdges != nil {
+		for removedID := range removed {
+			for source := range hnsw.inEdges[removedID] {
+				ids = append(ids, source.ID)
+			}
+		}
+	} else {
+		for id, node := range hnsw.nodes {
+			for _, neighborID := range node.Neighbors {
+				if removed[neighborID] {
+					ids = append(ids, id)
+					break
+				}
+			}
+		}
+	}
+	sort.Strings(ids)
+	ids = slices.Compact(ids)
+	for _, id := range ids {
+		node := hnsw.nodes[id]
+		// An earlier relink may already have replaced the removed neighbors
+		for _, neighborID := range node.Neighbors {
+			if removed[neighborID] {
+				hnsw.relinkNode(node)
+				break
+			}
+		}
+	}
+}
+
+// EstimateMatches estimates how many vectors match the predicate by testing a
+// random sample of sampleSize vectors, drawn with replacement from the
+// index's sampling source (see WithSeed), and extrapolating to the index size.
+// When sampleSize covers the whole index the count is exact.
+func (hnsw *HNSW) EstimateMatches(pred func(id string, meta map[string]any) bool, sampleSize int) int {
+	defer hnsw.readUnlock(hnsw.readLock())
+
+	if sampleSize <= 0 || len(hnsw.nodes) == 0 {
+		return 0
+	}
+	if sampleSize >= len(hnsw.nodes) {
+		count := 0
+		for id, node := range hnsw.nodes {
+			if pred(id, node.Metadata) {
+				count++
+			}
+		}
+		return count
+	}
+
+	// Concurrent readers share the random source
+	hnsw.rngMu.Lock()
+	sample := make([]*HNSWNode, 0, sampleSize)
+	for len(sample) < sampleSize {
+		// Slots freed by deletes are empty, so redraw
+		if node := hnsw.slots[hnsw.sampleIntn(len(hnsw.slots))]; node != nil {
+			sample = append(sample, node)
+		}
+	}
+	hnsw.rngMu.Unlock()
+
+	matches := 0
+	for _, node := range sample {
+		if pred(node.ID, node.Metadata) {
+			matches++
+		}
+	}
+	return int(math.Round(float64(matches) / float64(sampleSize) * float64(len(hnsw.nodes))))
+}
@@ -0,0 +1,178 @@
+package gector
+
+import (
+	"fmt"
+	"slices"
+	"testing"
+)
+
+// Test for DeleteWhere removing exactly the vectors matching a metadata predicate
+func TestDeleteWhere(t *testing.T) {
+	hnswIndex := NewHNSW(5, 4)
+	for i := 0; i < 30; i++ {
+		tenant := "a"
+		if i%3 == 0 {
+			tenant = "b"
+		}
+		id := fmt.Sprintf("vec-%d", i)
+		hnswIndex.AddVectorWithMetadata(id, generateRandomVector(5), map[string]any{"tenant": tenant})
+	}
+
+	deleted, err := hnswIndex.DeleteWhere(func(id string, meta map[string]any) bool {
+		return meta["tenant"] == "b"
+	})
+	if err != nil {
+		t.Fatalf("Error deleting by predicate: %v", err)
+	}
+	if deleted != 10 {
+		t.Errorf("Expected 10 vectors to be deleted, but got %d", deleted)
+	}
+	if len(hnswIndex.nodes) != 20 {
+		t.Errorf("Expected 20 vectors to remain, but got %d", len(hnswIndex.nodes))
+	}
+
+	for id, node := range hnswIndex.nodes {
+		if node.Metadata["tenant"] != "a" {
+			t.Errorf("Expected only tenant 'a' to remain, but '%s' has %v", id, node.Metadata)
+		}
+		// No remaining node may point at a deleted one
+		for _, neighborID := range node.Neighbors {
+			if _, exists := hnswIndex.nodes[neighborID]; !exists {
+				t.Errorf("Expected '%s' to have no dangling neighbor, but found '%s'", id, neighborID)
+			}
+		}
+	}
+}
+
+// Test for WithIncludeMetadata attaching metadata to search results
+func TestWithIncludeMetadata(t *testing.T) {
+	hnswIndex := NewHNSW(5, 3, WithIncludeMetadata())
+	for i := 0; i < 20; i++ {
+		id := fmt.Sprintf("vec-%d", i)
+		hnswIndex.AddVectorWithMetadata(id, Vector{ID: id, Values: []float64{float64(i), 0}}, map[string]any{"index": i})
+	}
+	hnswIndex.AddVector("plain", Vector{ID: "plain", Values: []float64{100, 0}})
+
+	results, _ := hnswIndex.SearchWithStats(Vector{Values: []float64{3, 0}}, 5)
+	for _, result := range results {
+		expected := fmt.Sprintf("vec-%d", result.Metadata["index"])
+		if result.ID != expected {
+			t.Errorf("Expected metadata of %s, but got %v", result.ID, result.Metadata)
+		}
+	}
+
+	// Vectors without metadata have none in their results
+	results, _ = hnswIndex.SearchWithStats(Vector{Values: []float64{100, 0}}, 1)
+	if results[0].ID != "plain" || results[0].Metadata != nil {
+		t.Errorf("Expected plain without metadata, but got %v", results[0])
+	}
+
+	// Without the option results carry no metadata
+	plainIndex := NewHNSW(5, 3)
+	plainIndex.AddVectorWithMetadata("vec-1", Vector{ID: "vec-1", Values: []float64{1, 0}}, map[string]any{"index": 1})
+	results, _ = plainIndex.SearchWithStats(Vector{Values: []float64{1, 0}}, 1)
+	if results[0].Metadata != nil {
+		t.Errorf("Expected no metadata without WithIncludeMetadata, but got %v", results[0].Metadata)
+	}
+}
+
+// Test for EstimateMatches on a dataset with a known match fraction
+func TestEstimateMatches(t *testing.T) {
+	build := func() *HNSW {
+		hnswIndex := NewHNSW(5, 3, WithSeed(1), WithMaxInsertCandidates(16))
+		for i := 0; i < 5000; i++ {
+			id := fmt.Sprintf("vec-%d", i)
+			hnswIndex.AddVectorWithMetadata(id, generateRandomVector(2), map[string]any{"bucket": i % 4})
+		}
+		return hnswIndex
+	}
+	inFirstBucket := func(id string, meta map[string]any) bool {
+		return meta["bucket"] == 0
+	}
+
+	// A quarter of the vectors match
+	hnswIndex := build()
+	estimate := hnswIndex.EstimateMatches(inFirstBucket, 1000)
+	if estimate < 1000 || estimate > 1500 {
+		t.Errorf("Expected an estimate near 1250, but got %d", estimate)
+	}
+
+	// The same seed gives the same estimate
+	if again := build().EstimateMatches(inFirstBucket, 1000); again != estimate {
+		t.Errorf("Expected the same estimate %d for the same seed, but got %d", estimate, again)
+	}
+
+	// Sampling the whole index counts exactly
+	if exact := hnswIndex.EstimateMatches(inFirstBucket, 5000); exact != 1250 {
+		t.Errorf("Expected the exact count 1250, but got %d", exact)
+	}
+	if empty := hnswIndex.EstimateMatches(inFirstBucket, 0); empty != 0 {
+		t.Errorf("Expected 0 for an empty sample, but got %d", empty)
+	}
+
+	// Estimating does not draw from the level source, so later inserts land
+	// on the same levels as in an index that never estimated
+	untouched := build()
+	for i := 0; i < 100; i++ {
+		id := fmt.Sprintf("late-%d", i)
+		vector := generateRandomVector(2)
+		hnswIndex.AddVector(id, vector)
+		untouched.AddVector(id, vector)
+		if got, want := hnswIndex.topLevel(id), untouched.topLevel(id); got != want {
+			t.Fatalf("Expected %s on level %d after estimating, but got %d", id, want, got)
+		}
+	}
+}
+
+// Test for UpdateMetadataBatch with known and unknown IDs
+func TestUpdateMetadataBatch(t *testing.T) {
+	hnswIndex := NewHNSW(5, 4, WithCategoricalIndex("tenant"))
+	for i := 0; i < 20; i++ {
+		id := fmt.Sprintf("vec-%d", i)
+		hnswIndex.AddVectorWithMetadata(id, generateRandomVector(5), map[string]any{"tenant": "old"})
+	}
+	before := make(map[string][]string)
+	for id, node := range hnswIndex.nodes {
+		before[id] = slices.Clone(node.Neighbors)
+	}
+	vector := hnswIndex.nodes["vec-1"].Vector
+
+	updated, missing, err := hnswIndex.UpdateMetadataBatch(map[string]map[string]any{
+		"vec-1":     {"tenant": "new"},
+		"vec-2":     {"tenant": "new"},
+		"missing-b": {"tenant": "new"},
+		"missing-a": {"tenant": "new"},
+	})
+	if err != nil {
+		t.Fatalf("Error updating metadata: %v", err)
+	}
+	if updated != 2 {
+		t.Errorf("Expected 2 updates, but got %d", updated)
+	}
+	if !slices.Equal(missing, []string{"missing-a", "missing-b"}) {
+		t.Errorf("Expected missing IDs [missing-a missing-b], but got %v", missing)
+	}
+	if got := hnswIndex.nodes["vec-1"].Metadata["tenant"]; got != "new" {
+		t.Errorf("Expected tenant new, but got %v", got)
+	}
+
+	// Vectors and neighbor lists are untouched
+	if !VectorsAlmostEqual(hnswIndex.nodes["vec-1"].Vector, vector, 0) {
+		t.Errorf("Expected the vector of vec-1 to be unchanged")
+	}
+	for id, node := range hnswIndex.nodes {
+		if !slices.Equal(node.Neighbors, before[id]) {
+			t.Errorf("Expected the neighbors of %s to be unchanged", id)
+		}
+	}
+
+	// The categorical bitmaps follow the new values
+	results := hnswIndex.SearchFilter(generateRandomVector(5), 20, CategoryFilter{Field: "tenant", Values: []any{"new"}})
+	if len(results) != 2 {
+		t.Errorf("Expected 2 vectors in tenant new, but got %d", len(results))
+	}
+	results = hnswIndex.SearchFilter(generateRandomVector(5), 20, CategoryFilter{Field: "tenant", Values: []any{"old"}})
+	if len(results) != 18 {
+		t.Errorf("Expected 18 vectors in tenant old, but got %d", len(results))
+	}
+}
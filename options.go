@@ -0,0 +1,281 @@
+package gector
+
+import (
+	"math"
+	"math/rand"
+)
+
+// levelProbability is the chance that a node is promoted to the next level up.
+const levelProbability = 0.5
+
+// Option configures an HNSW index at construction time.
+type Option func(*HNSW)
+
+// WithExpectedSize derives MaxLevels from the anticipated number of vectors.
+//
+// Each node is promoted one level up with probability p, so about n·p^l nodes
+// reach level l above the bottom and the top level is expected to hold a single
+// node when l = log(n)/log(1/p). The index therefore uses
+//
+//	MaxLevels = floor(log(n)/log(1/p)) + 1
+//
+// levels, with at least one level for n <= 1.
+func WithExpectedSize(n int) Option {
+	return func(hnsw *HNSW) {
+		hnsw.MaxLevels = levelsForSize(n)
+	}
+}
+
+// levelsForSize returns the number of levels suited to an index of n vectors.
+func levelsForSize(n int) int {
+	if n <= 1 {
+		return 1
+	}
+	// The small epsilon keeps exact powers of 1/p from rounding down
+	return int(math.Floor(math.Log(float64(n))/math.Log(1/levelProbability)+1e-9)) + 1
+}
+
+// WithSeed makes level promotion use a private source seeded with seed, so
+// indexes built from the same inputs in the same order are identical.
+// KeepRandom neighbor selection and sampling reads such as EstimateMatches
+// draw from their own sources derived from the seed, so they are repeatable
+// too without affecting the levels.
+func WithSeed(seed int64) Option {
+	return func(hnsw *HNSW) {
+		hnsw.rng = rand.New(rand.NewSource(seed))
+		hnsw.selectRng = rand.New(rand.NewSource(seed ^ selectSeedSalt))
+		hnsw.sampleRng = rand.New(rand.NewSource(seed ^ sampleSeedSalt))
+	}
+}
+
+// selectSeedSalt and sampleSeedSalt derive the seeds of the selection and
+// sampling sources from the WithSeed seed.
+const (
+	selectSeedSalt = 0x2f81_c6d0_93ae_47b5
+	sampleSeedSalt = 0x5a3c_9e17_d2b4_6f01
+)
+
+// WithNoCopy stores inserted vectors without copying their Values. This saves
+// an allocation per insert, but the index then aliases the caller's slices, so
+// callers must guarantee they never modify a slice once it has been inserted.
+func WithNoCopy() Option {
+	return func(hnsw *HNSW) {
+		hnsw.noCopy = true
+	}
+}
+
+// WithDeltaLog records every mutation with a sequence number so replicas can
+// follow the index through DeltaSince and Apply. The log is kept in memory.
+func WithDeltaLog() Option {
+	return func(hnsw *HNSW) {
+		hnsw.deltaLog = true
+	}
+}
+
+// WithQueryCache caches the results of up to size distinct queries, evicting
+// the least recently used. The cache is cleared whenever the index changes.
+func WithQueryCache(size int) Option {
+	return func(hnsw *HNSW) {
+		hnsw.cache = newQueryCache(size)
+	}
+}
+
+// WithMaxInsertCandidates bounds how many nodes are examined per level when
+// linking a newly inserted node, which bounds insert time regardless of index
+// size. The candidates are an arbitrary subset of the level, so a node's links
+// are its nearest among that subset rather than the true nearest, lowering
+// graph quality and the recall of anything that follows links. Zero examines
+// every node.
+func WithMaxInsertCandidates(n int) Option {
+	return func(hnsw *HNSW) {
+		hnsw.maxInsertCandidates = n
+	}
+}
+
+// WithRecallProbe measures recall every everyN inserts by sampling numQueries
+// stored vectors as queries and comparing the index's search results against
+// an exact brute-force search, then reports the mean recall@10 to cb. The
+// probe only reads the index, and cb runs after the write lock is released.
+func WithRecallProbe(everyN, numQueries int, cb func(recall float64)) Option {
+	return func(hnsw *HNSW) {
+		hnsw.recallProbe = &recallProbe{
+			everyN:     everyN,
+			numQueries: numQueries,
+			cb:         cb,
+			rng:        rand.New(rand.NewSource(1)),
+		}
+	}
+}
+
+// WithCategoricalIndex maintains a bitmap of matching vectors for every value
+// of the given metadata field, so SearchFilter with a CategoryFilter on that
+// field only visits matching vectors. Only string, bool and numeric values
+// are indexed.
+func WithCategoricalIndex(field string) Option {
+	return func(hnsw *HNSW) {
+		if hnsw.categories == nil {
+			hnsw.categories = make(map[string]map[any]*bitmap)
+		}
+		hnsw.categories[field] = make(map[any]*bitmap)
+	}
+}
+
+// WithMaxVectors caps the index at n vectors. An insert that would exceed the
+// cap evicts the vectors least recently returned by a search (or inserted, if
+// never returned) and repairs the neighbor lists that pointed at them.
+// Evicted vectors are gone: they are not persisted or kept anywhere else, and
+// a replica following the delta log sees them as deletes. Zero means no cap.
+func WithMaxVectors(n int) Option {
+	return func(hnsw *HNSW) {
+		hnsw.maxVectors = n
+	}
+}
+
+// WithIncludeMetadata attaches each vector's metadata to its search results,
+// saving callers a lookup per result.
+func WithIncludeMetadata() Option {
+	return func(hnsw *HNSW) {
+		hnsw.includeMetadata = true
+	}
+}
+
+// WithEpsilon sets the tolerance used wherever the index compares vectors for
+// equality, such as detecting unchanged updates in UpdateVector and comparing
+// vectors in StructurallyEqual, so embeddings that differ only in their last
+// bits count as equal. The default of zero requires exact equality.
+func WithEpsilon(eps float64) Option {
+	return func(hnsw *HNSW) {
+		hnsw.epsilon = eps
+	}
+}
+
+// WithNeighborSelection sets how a new node chooses its neighbors when there
+// are more candidates than MaxNeighbors. The default is KeepClosest.
+func WithNeighborSelection(strategy NeighborSelection) Option {
+	return func(hnsw *HNSW) {
+		hnsw.neighborSelection = strategy
+	}
+}
+
+// WithRandomProjection reduces every inserted and queried vector to targetDim
+// dimensions through a fixed Gaussian random matrix generated from seed,
+// trading some recall for memory and distance speed. The matrix is generated
+// on the first insert from its dimension, and only vectors of that dimension
+// are projected. Stored vectors and the vectors in search results are the
+// projected ones. The seed and dimensions are saved with the index, so a
+// loaded index regenerates the matrix and keeps projecting queries.
+func WithRandomProjection(targetDim int, seed int64) Option {
+	return func(hnsw *HNSW) {
+		hnsw.projection = &randomProjection{targetDim: targetDim, seed: seed}
+	}
+}
+
+// WithDeterministicLevels derives the level of every inserted vector from a
+// hash of its ID rather than from the random source, so the level assignment
+// is a pure function of the set of IDs regardless of insertion order or seed.
+// Neighbor lists are still chosen among the vectors present at insert time,
+// so they depend on insertion order unless every node is relinked afterwards.
+func WithDeterministicLevels() Option {
+	return func(hnsw *HNSW) {
+		hnsw.deterministicLevels = true
+	}
+}
+
+// WithWhitener whitens every inserted and queried vector with the fitted w,
+// before any random projection. Stored vectors and the vectors in search
+// results are the whitened ones. The whitener is saved by Save and restored
+// by Load.
+func WithWhitener(w *Whitener) Option {
+	return func(hnsw *HNSW) {
+		hnsw.whitener = w
+	}
+}
+
+// WithMaxExpansionsPerLevel caps the number of nodes a search scores on each
+// level it visits, for predictable tail latency. Searches scan the bottom
+// level, which holds every node, so the cap bounds the distance evaluations
+// of a whole search (including those of the recall probe). Once the cap is
+// reached the best results found so far are returned: with a cap of n over
+// an index of N vectors, recall drops to about n/N. Zero means no cap.
+func WithMaxExpansionsPerLevel(n int) Option {
+	return func(hnsw *HNSW) {
+		hnsw.maxExpansions = n
+	}
+}
+
+// WithParallelDistance spreads the distance computations of a search over
+// workers goroutines once the index holds at least parallelDistanceThreshold
+// vectors. It pays off for high-dimensional vectors, where each distance is
+// expensive; for small dimensions the coordination overhead dominates, so it
+// is opt-in. It is not used together with WithMaxExpansionsPerLevel, and a
+// custom distance function must then be safe for concurrent use.
+func WithParallelDistance(workers int) Option {
+	return func(hnsw *HNSW) {
+		hnsw.distanceWorkers = workers
+	}
+}
+
+// WithGraphGrowthObserver reports the number of nodes and directed edges to
+// fn every growthObserverInterval inserts, for projecting the memory a full
+// load will need. Like the recall probe, fn runs after the write lock is
+// released.
+func WithGraphGrowthObserver(fn func(nodes, edges int)) Option {
+	return func(hnsw *HNSW) {
+		hnsw.growthObserver = &growthObserver{fn: fn}
+	}
+}
+
+// WithExactBelow makes searches of an index holding fewer than n vectors use
+// BruteForceSearch, giving exact results on small collections where the
+// search caps and parallel scans do not pay off.
+func WithExactBelow(n int) Option {
+	return func(hnsw *HNSW) {
+		hnsw.exactBelow = n
+	}
+}
+
+// WithMaxDimension rejects vectors longer than d, before anything is
+// allocated for them: inserts return an error wrapping ErrDimensionTooLarge,
+// as do the searches that return errors, while the others return no results.
+// It guards public-facing services against oversized input.
+func WithMaxDimension(d int) Option {
+	return func(hnsw *HNSW) {
+		hnsw.maxDimension = d
+	}
+}
+
+// WithQuantizer stores the product quantization code of every vector next to
+// the original. Searches then rank all vectors by their codes, which is
+// cheaper for high dimensions, and re-score the best rerank candidates with
+// the original vectors so the final order is exact among them; a rerank of 0
+// returns the quantized ranking and distances. The quantizer must be trained
+// on vectors as stored, after any whitening or projection. It applies to the
+// Euclidean metric only and not together with WithMaxExpansionsPerLevel.
+// The quantizer and rerank setting are saved with the index.
+func WithQuantizer(pq *ProductQuantizer, rerank int) Option {
+	return func(hnsw *HNSW) {
+		hnsw.quantizer = pq
+		hnsw.rerank = rerank
+	}
+}
+
+// WithLatencyHistogram records the latency of every search in a histogram
+// read by LatencyPercentiles. It is opt-in, as it reads the clock twice per
+// search.
+func WithLatencyHistogram() Option {
+	return func(hnsw *HNSW) {
+		hnsw.latency = &latencyHistogram{}
+	}
+}
+
+// WithResultProcessor passes the results of every search through fn before
+// they are returned, to boost, filter or annotate them without changing the
+// search itself. It runs after distance ranking, on the final results sorted
+// by ascending distance, and may reorder, trim or modify them. The results
+// are not shared with the query cache. fn runs while the index is locked for
+// reading, so it must not call methods of the index.
+func WithResultProcessor(fn func([]SearchResult) []SearchResult) Option {
+	return func(hnsw *HNSW) {
+		hnsw.resultProcessor = fn
+	}
+}
@@ -0,0 +1,178 @@
+package gector
+
+import (
+	"fmt"
+	"testing"
+)
+
+// Test for WithExpectedSize deriving the number of levels
+func TestWithExpectedSize(t *testing.T) {
+	cases := []struct {
+		size     int
+		min, max int
+	}{
+		{0, 1, 1},
+		{1, 1, 1},
+		{1000, 10, 11},
+		{1000000, 20, 21},
+	}
+
+	for _, c := range cases {
+		hnswIndex := NewHNSW(5, 4, WithExpectedSize(c.size))
+		if hnswIndex.MaxLevels < c.min || hnswIndex.MaxLevels > c.max {
+			t.Errorf("Expected between %d and %d levels for size %d, but got %d", c.min, c.max, c.size, hnswIndex.MaxLevels)
+		}
+		if len(hnswIndex.levels) != hnswIndex.MaxLevels {
+			t.Errorf("Expected %d allocated levels for size %d, but got %d", hnswIndex.MaxLevels, c.size, len(hnswIndex.levels))
+		}
+	}
+
+	// The derived index is usable
+	hnswIndex := NewHNSW(5, 4, WithExpectedSize(100))
+	hnswIndex.AddVector("vec-1", generateRandomVector(5))
+	if len(hnswIndex.NearestNeighbors(generateRandomVector(5), 1)) != 1 {
+		t.Errorf("Expected 1 nearest neighbor from the derived index")
+	}
+}
+
+// Test for WithDeterministicLevels assigning levels independently of insertion order
+func TestWithDeterministicLevels(t *testing.T) {
+	vectors := make([]Vector, 200)
+	for i := range vectors {
+		vectors[i] = generateRandomVector(4)
+		vectors[i].ID = fmt.Sprintf("vec-%d", i)
+	}
+
+	forward := NewHNSW(5, 4, WithDeterministicLevels(), WithSeed(1))
+	for _, vector := range vectors {
+		forward.AddVector(vector.ID, vector)
+	}
+	backward := NewHNSW(5, 4, WithDeterministicLevels(), WithSeed(2))
+	for i := len(vectors) - 1; i >= 0; i-- {
+		backward.AddVector(vectors[i].ID, vectors[i])
+	}
+
+	for level := 0; level < forward.MaxLevels; level++ {
+		if len(forward.levels[level]) != len(backward.levels[level]) {
+			t.Fatalf("Expected %d nodes on level %d, but got %d", len(forward.levels[level]), level, len(backward.levels[level]))
+		}
+		for id := range forward.levels[level] {
+			if _, ok := backward.levels[level][id]; !ok {
+				t.Errorf("Expected %s on level %d regardless of insertion order", id, level)
+			}
+		}
+	}
+
+	// Levels are still spread out by the promotion probability
+	if top := len(forward.levels[0]); top == 0 || top > 100 {
+		t.Errorf("Expected a sparse but non-empty top level, but got %d nodes", top)
+	}
+}
+
+// Test for WithMaxExpansionsPerLevel bounding distance evaluations
+func TestWithMaxExpansionsPerLevel(t *testing.T) {
+	hnswIndex := NewHNSW(5, 4, WithMaxExpansionsPerLevel(100), WithMaxInsertCandidates(16))
+	for i := 0; i < 1000; i++ {
+		hnswIndex.AddVector(fmt.Sprintf("vec-%d", i), generateRandomVector(4))
+	}
+
+	results, evaluations := hnswIndex.scan(generateRandomVector(4), 10)
+	if evaluations != 100 {
+		t.Errorf("Expected 100 distance evaluations, but got %d", evaluations)
+	}
+	if len(results) != 10 {
+		t.Errorf("Expected 10 results within the cap, but got %d", len(results))
+	}
+
+	// Without the cap every node is scored
+	hnswIndex.maxExpansions = 0
+	if _, evaluations := hnswIndex.scan(generateRandomVector(4), 10); evaluations != 1000 {
+		t.Errorf("Expected 1000 distance evaluations without a cap, but got %d", evaluations)
+	}
+}
+
+// Test for WithExactBelow switching from brute force to the capped scan
+func TestWithExactBelow(t *testing.T) {
+	hnswIndex := NewHNSW(5, 4, WithExactBelow(100), WithMaxExpansionsPerLevel(10))
+	for i := 0; i < 50; i++ {
+		hnswIndex.AddVector(fmt.Sprintf("vec-%d", i), generateRandomVector(4))
+	}
+	if hnswIndex.Len() != 50 {
+		t.Fatalf("Expected Len 50, but got %d", hnswIndex.Len())
+	}
+
+	// Below the threshold results are exact despite the cap
+	for q := 0; q < 5; q++ {
+		query := generateRandomVector(4)
+		results, _ := hnswIndex.SearchWithStats(query, 5)
+		expected := hnswIndex.BruteForceSearch(query, 5)
+		for i := range expected {
+			if results[i].ID != expected[i].ID {
+				t.Errorf("Expected exact result %s at rank %d, but got %s", expected[i].ID, i, results[i].ID)
+			}
+		}
+	}
+
+	// Above it the capped scan is used and misses some true neighbors
+	for i := 50; i < 200; i++ {
+		hnswIndex.AddVector(fmt.Sprintf("vec-%d", i), generateRandomVector(4))
+	}
+	inexact := false
+	for q := 0; q < 5; q++ {
+		query := generateRandomVector(4)
+		results, _ := hnswIndex.SearchWithStats(query, 5)
+		expected := hnswIndex.BruteForceSearch(query, 5)
+		for i := range expected {
+			if results[i].ID != expected[i].ID {
+				inexact = true
+			}
+		}
+	}
+	if !inexact {
+		t.Errorf("Expected the capped scan to miss some neighbors above the threshold")
+	}
+}
+
+// Test for WithResultProcessor dropping results beyond a distance
+func TestWithResultProcessor(t *testing.T) {
+	const maxDistance = 2.5
+	calls := 0
+	hnswIndex := NewHNSW(5, 3, WithQueryCache(10), WithResultProcessor(func(results []SearchResult) []SearchResult {
+		calls++
+		kept := results[:0]
+		for _, result := range results {
+			if result.Distance <= maxDistance {
+				kept = append(kept, result)
+			}
+		}
+		return kept
+	}))
+	for i := 0; i < 10; i++ {
+		id := fmt.Sprintf("vec-%d", i)
+		hnswIndex.AddVector(id, Vector{ID: id, Values: []float64{float64(i), 0}})
+	}
+
+	query := Vector{Values: []float64{0, 0}}
+	for round := 0; round < 2; round++ {
+		results, _ := hnswIndex.SearchWithStats(query, 5)
+		if len(results) != 3 {
+			t.Fatalf("Expected 3 results within %v in round %d, but got %d", maxDistance, round, len(results))
+		}
+		for i, result := range results {
+			if expected := fmt.Sprintf("vec-%d", i); result.ID != expected {
+				t.Errorf("Expected %s at rank %d, but got %s", expected, i, result.ID)
+			}
+		}
+	}
+	if calls != 2 {
+		t.Errorf("Expected the processor to run once per search, cached or not, but got %d calls", calls)
+	}
+
+	// The processor sees results ranked by the final distance
+	results := hnswIndex.SearchWithMetric(query, 5, func(a, b Vector) float64 {
+		return 4 - EuclideanDistance(a, b)
+	})
+	if len(results) != 3 || results[0].ID != "vec-4" || results[2].ID != "vec-2" {
+		t.Errorf("Expected vec-4 to vec-2 after re-ranking and processing, but got %v", results)
+	}
+}
@@ -0,0 +1,40 @@
+package gector
+
+import (
+	"runtime"
+	"sync"
+)
+
+// PairwiseDistances computes the symmetric matrix of distances between every
+// pair of vectors. Only the upper triangle is computed and mirrored, and rows
+// are spread across GOMAXPROCS workers.
+func PairwiseDistances(vectors []Vector, dist DistanceFunc) [][]float64 {
+	matrix := make([][]float64, len(vectors))
+	for i := range matrix {
+		matrix[i] = make([]float64, len(vectors))
+	}
+
+	rows := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < runtime.GOMAXPROCS(0); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range rows {
+				for j := i + 1; j < len(vectors); j++ {
+					d := dist(vectors[i], vectors[j])
+					matrix[i][j] = d
+					matrix[j][i] = d
+				}
+			}
+		}()
+	}
+
+	for i := range vectors {
+		rows <- i
+	}
+	close(rows)
+	wg.Wait()
+
+	return matrix
+}
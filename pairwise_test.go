@@ -0,0 +1,43 @@
+package gector
+
+import (
+	"math"
+	"testing"
+)
+
+// Test for PairwiseDistances with hand-computed distances
+func TestPairwiseDistances(t *testing.T) {
+	vectors := []Vector{
+		{ID: "a", Values: []float64{0, 0}},
+		{ID: "b", Values: []float64{3, 0}},
+		{ID: "c", Values: []float64{0, 4}},
+	}
+
+	matrix := PairwiseDistances(vectors, EuclideanDistance)
+	want := [][]float64{
+		{0, 3, 4},
+		{3, 0, 5},
+		{4, 5, 0},
+	}
+
+	if len(matrix) != len(want) {
+		t.Fatalf("Expected a %dx%d matrix, but got %d rows", len(want), len(want), len(matrix))
+	}
+	for i := range want {
+		for j := range want[i] {
+			if math.Abs(matrix[i][j]-want[i][j]) > 1e-9 {
+				t.Errorf("Expected distance %f at [%d][%d], but got %f", want[i][j], i, j, matrix[i][j])
+			}
+		}
+	}
+
+	// Cosine distances between orthogonal vectors are 1
+	cosine := PairwiseDistances(vectors[1:], CosineDistance)
+	if math.Abs(cosine[0][1]-1) > 1e-9 || math.Abs(cosine[1][0]-1) > 1e-9 {
+		t.Errorf("Expected a cosine distance of 1 between orthogonal vectors, but got %v", cosine)
+	}
+
+	if len(PairwiseDistances(nil, EuclideanDistance)) != 0 {
+		t.Errorf("Expected an empty matrix for no vectors")
+	}
+}
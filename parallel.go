@@ -0,0 +1,71 @@
+package gector
+
+import (
+	"math"
+	"sync"
+)
+
+// parallelDistanceThreshold is the min number of stored vectors for which a
+// search fans its distance computations out to workers; below it the cost of
+// starting them outweighs the gain.
+const parallelDistanceThreshold = 1024
+
+// parallelScan is scanInto with the nodes split across the configured number
+// of workers, each keeping its own best k before they are merged. The caller
+// must hold the lock.
+func (hnsw *HNSW) parallelScan(query Vector, k int, buf []SearchResult) ([]SearchResult, int) {
+	queryNorm := vectorNorm(query)
+	queryInvNorm := inverseNorm(queryNorm)
+	preFilter := hnsw.PreFilter && hnsw.Metric == Euclidean && hnsw.distance == nil
+
+	workers := hnsw.distanceWorkers
+	chunk := (len(hnsw.slots) + workers - 1) / workers
+	heaps := make([]resultHeap, workers)
+	evaluations := make([]int, workers)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		start, end := w*chunk, min((w+1)*chunk, len(hnsw.slots))
+		if start >= end {
+			continue
+		}
+
+		wg.Add(1)
+		go func(w int, nodes []*HNSWNode) {
+			defer wg.Done()
+			best := make(resultHeap, 0, k)
+			for _, node := range nodes {
+				if node == nil {
+					continue
+				}
+				// Each worker prunes against its own k-th distance, which is
+				// never below the global one, so pruning stays exact
+				if preFilter && len(best) == k && math.Abs(queryNorm-node.norm) > best[0].Distance {
+					continue
+				}
+
+				evaluations[w]++
+				best.offer(SearchResult{
+					ID:       node.ID,
+					Vector:   node.Vector,
+					Distance: hnsw.queryDistance(query, queryInvNorm, node),
+					Metadata: hnsw.resultMetadata(node),
+				}, k)
+			}
+			heaps[w] = best
+		}(w, hnsw.slots[start:end])
+	}
+	wg.Wait()
+
+	best := resultHeap(buf[:0])
+	if cap(best) < k {
+		best = make(resultHeap, 0, k)
+	}
+	total := 0
+	for w, heap := range heaps {
+		for _, result := range heap {
+			best.offer(result, k)
+		}
+		total += evaluations[w]
+	}
+	return best.sorted(), total
+}
@@ -0,0 +1,51 @@
+package gector
+
+import (
+	"fmt"
+	"testing"
+)
+
+// Test for WithParallelDistance returning the same results as a sequential scan
+func TestWithParallelDistance(t *testing.T) {
+	hnswIndex := NewHNSW(5, 4, WithParallelDistance(4), WithMaxInsertCandidates(16))
+	for i := 0; i < 3000; i++ {
+		hnswIndex.AddVector(fmt.Sprintf("vec-%d", i), generateRandomVector(16))
+	}
+	// Freed slots are skipped
+	for i := 0; i < 3000; i += 7 {
+		hnswIndex.DeleteVector(fmt.Sprintf("vec-%d", i))
+	}
+
+	for _, preFilter := range []bool{false, true} {
+		hnswIndex.PreFilter = preFilter
+		query := generateRandomVector(16)
+		parallel, _ := hnswIndex.scan(query, 10)
+		expected := hnswIndex.bruteForce(query, 10)
+		for i := range expected {
+			if parallel[i].ID != expected[i].ID {
+				t.Errorf("Expected %s at rank %d with prefilter=%v, but got %s", expected[i].ID, i, preFilter, parallel[i].ID)
+			}
+		}
+	}
+}
+
+// Benchmark for parallel distance computation on high-dimensional vectors
+func BenchmarkParallelDistance(b *testing.B) {
+	vectors := make([]Vector, 5000)
+	for i := range vectors {
+		vectors[i] = generateRandomVector(768)
+	}
+	query := generateRandomVector(768)
+
+	for _, workers := range []int{1, 4} {
+		hnswIndex := NewHNSW(5, 4, WithParallelDistance(workers), WithMaxInsertCandidates(8))
+		for i, vector := range vectors {
+			hnswIndex.AddVector(fmt.Sprintf("vec-%d", i), vector)
+		}
+		b.Run(fmt.Sprintf("workers=%d", workers), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				hnswIndex.scan(query, 10)
+			}
+		})
+	}
+}
@@ -0,0 +1,407 @@
+package gector
+
+import (
+	"encoding/gob"
+	"fmt"
+	"io"
+	"math"
+	"reflect"
+	"sort"
+)
+
+// snapshotVersion is the version of the Save format. Snapshots written
+// before the format was versioned decode with version 0 and are accepted, as
+// are version 1 snapshots, which stored metadata as maps, version 2
+// snapshots, which had no quantizer or projection, and version 3 snapshots,
+// which had no weights.
+const snapshotVersion = 4
+
+// maxSnapshotLevels bounds the number of levels accepted from a file, so a
+// corrupt header cannot allocate an arbitrary number of levels. Each level
+// holds a fraction of the one below it, so real indexes use far fewer.
+const maxSnapshotLevels = 1 << 10
+
+// snapshot is the serialized form of an index used by Save and Load.
+type snapshot struct {
+	// Format version and the user tag of the index, see HNSW.Tag
+	FormatVersion int
+	Tag           string
+	MaxNeighbors  int
+	MaxLevels     int
+	Metric        Metric
+	Dim           int
+	Nodes         []snapshotNode
+	// Whitening applied to inserts and queries, if any
+	Whitener *Whitener
+	// Product quantizer ranking search candidates, if any
+	Quantizer *snapshotQuantizer
+	// Random projection applied to inserts and queries after whitening, if any
+	Projection *snapshotProjection
+}
+
+// snapshotNode is a serialized node. Level is the highest level (lowest
+// index) the node was promoted to; it is present on every level below it.
+// Metadata is written as Fields sorted by key, since gob encodes maps in
+// iteration order; the Metadata map is only read from older snapshots.
+// Weight is the SetWeight weight, 0 when unset.
+type snapshotNode struct {
+	ID        string
+	Values    []float64
+	Metadata  map[string]any
+	Fields    []snapshotField
+	Neighbors []string
+	Level     int
+	Weight    float64
+}
+
+// snapshotField is a serialized metadata entry.
+type snapshotField struct {
+	Key   string
+	Value any
+}
+
+// metadata returns the node's metadata from whichever form it was stored in.
+func (n snapshotNode) metadata() map[string]any {
+	if n.Metadata != nil || n.Fields == nil {
+		return n.Metadata
+	}
+	metadata := make(map[string]any, len(n.Fields))
+	for _, field := range n.Fields {
+		metadata[field.Key] = field.Value
+	}
+	return metadata
+}
+
+// SaveOptions controls what SaveWithOptions writes.
+type SaveOptions struct {
+	// DropTombstones leaves out the neighbor references to deleted vectors.
+	// Deletes remove vectors right away but leave their IDs in the neighbor
+	// lists that pointed at them until the lists are repaired (see
+	// PruneNeighbors); the live index keeps them, while the saved file and
+	// the index loaded from it do not.
+	DropTombstones bool
+}
+
+// Save writes the index to w using gob. Custom distance functions selected
+// by name are not saved and must be selected again after Load.
+func (hnsw *HNSW) Save(w io.Writer) error {
+	return hnsw.SaveWithOptions(w, SaveOptions{})
+}
+
+// SaveWithOptions is Save with control over what is written.
+func (hnsw *HNSW) SaveWithOptions(w io.Writer, opts SaveOptions) error {
+	defer hnsw.readUnlock(hnsw.readLock())
+
+	s := hnsw.snapshot()
+	if opts.DropTombstones {
+		for i := range s.Nodes {
+			neighbors := s.Nodes[i].Neighbors[:0]
+			for _, neighborID := range s.Nodes[i].Neighbors {
+				if _, exists := hnsw.nodes[neighborID]; exists {
+					neighbors = append(neighbors, neighborID)
+				}
+			}
+			s.Nodes[i].Neighbors = neighbors
+		}
+	}
+	return gob.NewEncoder(w).Encode(s)
+}
+
+// Load reads an index written by Save. Snapshots written by an incompatible
+// version of the format are rejected.
+func Load(r io.Reader) (*HNSW, error) {
+	var s snapshot
+	if err := gob.NewDecoder(r).Decode(&s); err != nil {
+		return nil, err
+	}
+	return restoreSnapshot(s)
+}
+
+// LoadMulti reads several indexes written by Save, such as shards built
+// separately, into one index. The shards must agree on the number of levels,
+// the metric, the dimension and the whitening, and must not share IDs; the
+// tag and max neighbors are those of the first shard. Each shard keeps its
+// own graph, so no neighbor links cross shards until nodes are relinked (see
+// RelinkNode); searches score every vector and are unaffected.
+func LoadMulti(readers []io.Reader) (*HNSW, error) {
+	if len(readers) == 0 {
+		return nil, fmt.Errorf("no shards given")
+	}
+
+	var hnsw *HNSW
+	for i, r := range readers {
+		var s snapshot
+		if err := gob.NewDecoder(r).Decode(&s); err != nil {
+			return nil, fmt.Errorf("shard %d: %w", i, err)
+		}
+		if hnsw == nil {
+			first, err := restoreSnapshot(s)
+			if err != nil {
+				return nil, fmt.Errorf("shard %d: %w", i, err)
+			}
+			hnsw = first
+			continue
+		}
+
+		if err := hnsw.checkShard(s); err != nil {
+			return nil, fmt.Errorf("shard %d: %w", i, err)
+		}
+		for _, n := range s.Nodes {
+			if _, exists := hnsw.nodes[n.ID]; exists {
+				return nil, fmt.Errorf("shard %d: vector with id %s is in an earlier shard", i, n.ID)
+			}
+			if err := hnsw.restoreNode(n); err != nil {
+				return nil, fmt.Errorf("shard %d: %w", i, err)
+			}
+		}
+		if hnsw.dim == 0 {
+			hnsw.dim = s.Dim
+		}
+	}
+	return hnsw, nil
+}
+
+// checkShard reports whether a snapshot can be merged into the index by
+// LoadMulti. An empty index or shard has no dimension and matches any.
+func (hnsw *HNSW) checkShard(s snapshot) error {
+	if err := checkSnapshotFormat(s); err != nil {
+		return err
+	}
+	if s.MaxLevels != hnsw.MaxLevels {
+		return fmt.Errorf("has %d levels, expected %d", s.MaxLevels, hnsw.MaxLevels)
+	}
+	if s.Metric != hnsw.Metric {
+		return fmt.Errorf("uses metric %d, expected %d", s.Metric, hnsw.Metric)
+	}
+	if s.Dim != 0 && hnsw.dim != 0 && s.Dim != hnsw.dim {
+		return fmt.Errorf("has dimension %d, expected %d", s.Dim, hnsw.dim)
+	}
+	if !reflect.DeepEqual(s.Whitener, hnsw.whitener) {
+		return fmt.Errorf("uses a different whitener")
+	}
+	if !reflect.DeepEqual(s.Quantizer, hnsw.quantizerSnapshot()) {
+		return fmt.Errorf("uses a different quantizer")
+	}
+	if !reflect.DeepEqual(s.Projection, hnsw.projectionSnapshot()) {
+		return fmt.Errorf("uses a different projection")
+	}
+	return nil
+}
+
+// snapshot captures the index with nodes, neighbor lists and metadata keys
+// sorted, so that saving equal indexes produces identical bytes. Neighbor
+// lists are copied. The caller must hold the lock.
+func (hnsw *HNSW) snapshot() snapshot {
+	s := snapshot{
+		FormatVersion: snapshotVersion,
+		Tag:           hnsw.Tag,
+		MaxNeighbors:  hnsw.MaxNeighbors,
+		MaxLevels:     hnsw.MaxLevels,
+		Metric:        hnsw.Metric,
+		Dim:           hnsw.dim,
+		Nodes:         make([]snapshotNode, 0, len(hnsw.nodes)),
+		Whitener:      hnsw.whitener,
+		Quantizer:     hnsw.quantizerSnapshot(),
+		Projection:    hnsw.projectionSnapshot(),
+	}
+	for id, node := range hnsw.nodes {
+		neighbors := append([]string(nil), node.Neighbors...)
+		sort.Strings(neighbors)
+		s.Nodes = append(s.Nodes, snapshotNode{
+			ID:        id,
+			Values:    node.Vector.Values,
+			Fields:    sortedFields(node.Metadata),
+			Neighbors: neighbors,
+			Level:     hnsw.topLevel(id),
+			Weight:    node.weight,
+		})
+	}
+	sort.Slice(s.Nodes, func(i, j int) bool {
+		return s.Nodes[i].ID < s.Nodes[j].ID
+	})
+	return s
+}
+
+// sortedFields returns the metadata entries sorted by key, or nil when there
+// is no metadata.
+func sortedFields(metadata map[string]any) []snapshotField {
+	if len(metadata) == 0 {
+		return nil
+	}
+	fields := make([]snapshotField, 0, len(metadata))
+	for key, value := range metadata {
+		fields = append(fields, snapshotField{Key: key, Value: value})
+	}
+	sort.Slice(fields, func(i, j int) bool {
+		return fields[i].Key < fields[j].Key
+	})
+	return fields
+}
+
+// topLevel returns the highest level (lowest index) holding the node.
+// The caller must hold the lock.
+func (hnsw *HNSW) topLevel(id string) int {
+	for level := 0; level < hnsw.MaxLevels; level++ {
+		if _, exists := hnsw.levels[level][id]; exists {
+			return level
+		}
+	}
+	return hnsw.MaxLevels - 1
+}
+
+// restoreSnapshot rebuilds an index from a snapshot without relinking, so the
+// graph is exactly the one that was saved.
+func restoreSnapshot(s snapshot) (*HNSW, error) {
+	if err := checkSnapshotFormat(s); err != nil {
+		return nil, err
+	}
+
+	hnsw := NewHNSW(s.MaxNeighbors, s.MaxLevels)
+	hnsw.Tag = s.Tag
+	hnsw.Metric = s.Metric
+	hnsw.dim = s.Dim
+	hnsw.whitener = s.Whitener
+	if err := hnsw.restoreQuantizer(s.Quantizer, s.Dim); err != nil {
+		return nil, err
+	}
+	if err := hnsw.restoreProjection(s.Projection); err != nil {
+		return nil, err
+	}
+
+	for _, n := range s.Nodes {
+		if err := hnsw.restoreNode(n); err != nil {
+			return nil, err
+		}
+	}
+	return hnsw, nil
+}
+
+// checkSnapshotFormat rejects snapshots of an unsupported version or with an
+// invalid number of levels.
+func checkSnapshotFormat(s snapshot) error {
+	if s.FormatVersion < 0 || s.FormatVersion > snapshotVersion {
+		return fmt.Errorf("unsupported snapshot version %d, expected %d", s.FormatVersion, snapshotVersion)
+	}
+	if s.MaxLevels <= 0 || s.MaxLevels > maxSnapshotLevels {
+		return fmt.Errorf("invalid number of levels %d", s.MaxLevels)
+	}
+	return nil
+}
+
+// AddVectorWithMeta adds a vector with a known top level and neighbor list,
+// restoring the structure of a previous build exactly instead of drawing a
+// level and searching for neighbors, as Load does for a whole snapshot. It
+// suits bulk imports from other sources: the vector is stored as given,
+// without whitening or projection, so it must come from an index built the
+// same way. Neighbor IDs need not exist yet. Level 0 is the top of the
+// pyramid. An existing vector with the same ID is replaced, and kept when
+// an error is returned.
+func (hnsw *HNSW) AddVectorWithMeta(id string, vector Vector, level int, neighbors []string) error {
+	hnsw.mu.Lock()
+	if hnsw.frozen.Load() {
+		hnsw.mu.Unlock()
+		return ErrFrozen
+	}
+	if err := hnsw.checkDimension("add", id, vector); err != nil {
+		hnsw.mu.Unlock()
+		return err
+	}
+
+	values := vector.Values
+	if !hnsw.noCopy {
+		values = append([]float64(nil), values...)
+	}
+	if err := hnsw.addRestored(id, values, level, neighbors); err != nil {
+		hnsw.mu.Unlock()
+		return err
+	}
+	hnsw.recordRestoredDelta(id, Vector{ID: id, Values: values}, level, neighbors)
+	notify := hnsw.afterInsert()
+	hnsw.mu.Unlock()
+
+	notify()
+	return nil
+}
+
+// addRestored implements AddVectorWithMeta once the vector is validated,
+// replacing an existing vector with the same ID unless the level is invalid.
+// The caller must hold the write lock.
+func (hnsw *HNSW) addRestored(id string, values []float64, level int, neighbors []string) error {
+	node, err := hnsw.decodeNode(snapshotNode{
+		ID:        id,
+		Values:    values,
+		Neighbors: append([]string(nil), neighbors...),
+		Level:     level,
+	})
+	if err != nil {
+		return err
+	}
+	if hnsw.maxVectors > 0 {
+		node.lastAccess.Store(hnsw.accessClock.Add(1))
+	}
+
+	if _, exists := hnsw.nodes[id]; exists {
+		hnsw.deleteVector(id)
+	}
+	hnsw.placeNode(node, level)
+	if hnsw.dim == 0 {
+		hnsw.dim = len(values)
+	}
+	hnsw.invalidateCache()
+	return nil
+}
+
+// restoreNode adds a serialized node on the levels it was saved on, keeping
+// its neighbor list. The caller must hold the write lock.
+func (hnsw *HNSW) restoreNode(n snapshotNode) error {
+	node, err := hnsw.decodeNode(n)
+	if err != nil {
+		return err
+	}
+	hnsw.placeNode(node, n.Level)
+	return nil
+}
+
+// decodeNode builds the node for a serialized node without adding it to the
+// index, failing when its level does not exist. The caller must hold the lock.
+func (hnsw *HNSW) decodeNode(n snapshotNode) (*HNSWNode, error) {
+	if n.Level < 0 || n.Level >= hnsw.MaxLevels {
+		return nil, fmt.Errorf("vector with id %s has invalid level %d", n.ID, n.Level)
+	}
+	if n.Weight < 0 || math.IsNaN(n.Weight) {
+		return nil, fmt.Errorf("vector with id %s has invalid weight %f", n.ID, n.Weight)
+	}
+
+	vector := Vector{ID: n.ID, Values: n.Values}
+	norm := vectorNorm(vector)
+	node := &HNSWNode{
+		ID:        n.ID,
+		Neighbors: n.Neighbors,
+		Vector:    vector,
+		Metadata:  n.metadata(),
+		weight:    n.Weight,
+		norm:      norm,
+		invNorm:   inverseNorm(norm),
+	}
+	if hnsw.quantizer != nil && len(n.Values) == hnsw.quantizer.dim {
+		node.codes = hnsw.quantizer.Encode(vector)
+	}
+	return node, nil
+}
+
+// placeNode adds a node on its top level and every level below it, keeping
+// its neighbor list. The caller must hold the write lock.
+func (hnsw *HNSW) placeNode(node *HNSWNode, top int) {
+	for level := top; level < hnsw.MaxLevels; level++ {
+		if hnsw.levels[level] == nil {
+			hnsw.levels[level] = make(map[string]*HNSWNode)
+		}
+		hnsw.levels[level][node.ID] = node
+	}
+	hnsw.registerNode(node)
+}
+
+// Version returns the tag the index was saved with, see HNSW.Tag.
+func (hnsw *HNSW) Version() string {
+	return hnsw.Tag
+}
@@ -0,0 +1,396 @@
+package gector
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/gob"
+	"fmt"
+	"io"
+	"math"
+	"slices"
+	"testing"
+)
+
+// Helper function to build an index for persistence tests
+func buildPersistIndex(n int) *HNSW {
+	hnswIndex := NewHNSW(16, 4)
+	for i := 0; i < n; i++ {
+		id := fmt.Sprintf("vec-%d", i)
+		hnswIndex.AddVectorWithMetadata(id, generateRandomVector(32), map[string]any{"n": i})
+	}
+	return hnswIndex
+}
+
+// Test for Save and Load round-tripping an index
+func TestSaveLoad(t *testing.T) {
+	hnswIndex := buildPersistIndex(100)
+
+	var buf bytes.Buffer
+	if err := hnswIndex.Save(&buf); err != nil {
+		t.Fatalf("Error saving index: %v", err)
+	}
+	loaded, err := Load(&buf)
+	if err != nil {
+		t.Fatalf("Error loading index: %v", err)
+	}
+
+	if !hnswIndex.StructurallyEqual(loaded) {
+		t.Errorf("Expected the loaded index to be structurally equal to the saved one")
+	}
+	if loaded.nodes["vec-7"].Metadata["n"] != 7 {
+		t.Errorf("Expected metadata to round-trip, but got %v", loaded.nodes["vec-7"].Metadata)
+	}
+}
+
+// Test for SaveCompact round-tripping an index in a smaller file
+func TestSaveCompact(t *testing.T) {
+	hnswIndex := buildPersistIndex(500)
+	hnswIndex.Tag = "v1.2.3"
+
+	var plain bytes.Buffer
+	if err := hnswIndex.Save(&plain); err != nil {
+		t.Fatalf("Error saving index: %v", err)
+	}
+
+	for _, compress := range []bool{false, true} {
+		var compact bytes.Buffer
+		if err := hnswIndex.SaveCompact(&compact, compress); err != nil {
+			t.Fatalf("Error saving compact index: %v", err)
+		}
+		if compact.Len() >= plain.Len() {
+			t.Errorf("Expected the compact file (gzip=%v) to be smaller than %d bytes, but got %d", compress, plain.Len(), compact.Len())
+		}
+
+		loaded, err := LoadCompact(&compact)
+		if err != nil {
+			t.Fatalf("Error loading compact index (gzip=%v): %v", compress, err)
+		}
+		if !hnswIndex.StructurallyEqual(loaded) {
+			t.Errorf("Expected the compact index (gzip=%v) to round-trip", compress)
+		}
+		if loaded.nodes["vec-42"].Metadata["n"] != 42 {
+			t.Errorf("Expected metadata to round-trip, but got %v", loaded.nodes["vec-42"].Metadata)
+		}
+		if loaded.Tag != "v1.2.3" {
+			t.Errorf("Expected the tag to round-trip, but got '%s'", loaded.Tag)
+		}
+	}
+
+	if _, err := LoadCompact(bytes.NewReader([]byte("nope!"))); err == nil {
+		t.Errorf("Expected an error loading a non-compact file, but got nil")
+	}
+}
+
+// compactColor is a metadata value type that gob only encodes once registered.
+type compactColor struct {
+	Name string
+}
+
+// Test for SaveCompact needing custom metadata types registered with gob
+func TestSaveCompactMetadataTypes(t *testing.T) {
+	hnswIndex := NewHNSW(5, 3)
+	hnswIndex.AddVectorWithMetadata("vec-0", generateRandomVector(4), map[string]any{"color": compactColor{Name: "red"}})
+
+	var buf bytes.Buffer
+	if err := hnswIndex.SaveCompact(&buf, false); err == nil {
+		t.Fatalf("Expected an error saving an unregistered metadata type, but got nil")
+	}
+
+	gob.Register(compactColor{})
+	buf.Reset()
+	if err := hnswIndex.SaveCompact(&buf, false); err != nil {
+		t.Fatalf("Error saving a registered metadata type: %v", err)
+	}
+	loaded, err := LoadCompact(&buf)
+	if err != nil {
+		t.Fatalf("Error loading a registered metadata type: %v", err)
+	}
+	if color := loaded.nodes["vec-0"].Metadata["color"]; color != (compactColor{Name: "red"}) {
+		t.Errorf("Expected the registered metadata value to round-trip, but got %v", color)
+	}
+}
+
+// Test for LoadCompact rejecting corrupt counts without allocating for them
+func TestLoadCompactCorrupt(t *testing.T) {
+	header := func(maxLevels, nodes uint64) []byte {
+		buf := append([]byte(compactMagic), compactVersion)
+		for _, field := range []uint64{5, maxLevels, 0, 3, nodes} {
+			buf = binary.AppendUvarint(buf, field)
+		}
+		return append(buf, 0)
+	}
+	node := func(buf []byte, idLength uint64) []byte {
+		buf = binary.AppendUvarint(buf, idLength)
+		return append(buf, "id"...)
+	}
+
+	cases := map[string][]byte{
+		"levels":       header(math.MaxUint32, 1),
+		"nodes":        header(4, math.MaxInt32),
+		"id length":    node(header(4, 1), math.MaxInt64),
+		"huge id":      node(header(4, 1), math.MaxUint64),
+		"vector count": binary.AppendUvarint(binary.AppendUvarint(append(node(header(4, 1), 2), 0), 0), math.MaxInt64),
+		"neighbors":    binary.AppendUvarint(append(node(header(4, 1), 2), 0), 1000),
+	}
+	for name, data := range cases {
+		if _, err := LoadCompact(bytes.NewReader(data)); err == nil {
+			t.Errorf("Expected an error for a corrupt %s, but got nil", name)
+		}
+	}
+}
+
+// Test for the snapshot tag round-tripping and the format version being checked
+func TestSaveTag(t *testing.T) {
+	hnswIndex := buildPersistIndex(10)
+	hnswIndex.Tag = "release-2.3+abc123"
+
+	var buf bytes.Buffer
+	if err := hnswIndex.Save(&buf); err != nil {
+		t.Fatalf("Error saving index: %v", err)
+	}
+	loaded, err := Load(&buf)
+	if err != nil {
+		t.Fatalf("Error loading index: %v", err)
+	}
+	if loaded.Version() != "release-2.3+abc123" {
+		t.Errorf("Expected tag release-2.3+abc123, but got %q", loaded.Version())
+	}
+
+	// A snapshot from another format version is rejected
+	s := hnswIndex.snapshot()
+	s.FormatVersion = snapshotVersion + 1
+	if _, err := restoreSnapshot(s); err == nil {
+		t.Errorf("Expected an error for format version %d, but got nil", s.FormatVersion)
+	}
+}
+
+// Test for Save producing identical bytes for equal indexes
+func TestSaveDeterministic(t *testing.T) {
+	hnswIndex := NewHNSW(5, 4)
+	for i := 0; i < 100; i++ {
+		id := fmt.Sprintf("vec-%d", i)
+		hnswIndex.AddVectorWithMetadata(id, generateRandomVector(5), map[string]any{"a": i, "b": "x", "c": i % 3, "d": 1.5})
+	}
+
+	var first, second bytes.Buffer
+	if err := hnswIndex.Save(&first); err != nil {
+		t.Fatalf("Error saving index: %v", err)
+	}
+	if err := hnswIndex.Save(&second); err != nil {
+		t.Fatalf("Error saving index: %v", err)
+	}
+	if !bytes.Equal(first.Bytes(), second.Bytes()) {
+		t.Fatalf("Expected two saves of the same index to be identical")
+	}
+
+	// Reordering neighbor lists does not change the graph or the saved bytes
+	for _, node := range hnswIndex.nodes {
+		slices.Reverse(node.Neighbors)
+	}
+	var reordered bytes.Buffer
+	if err := hnswIndex.Save(&reordered); err != nil {
+		t.Fatalf("Error saving index: %v", err)
+	}
+	if !bytes.Equal(first.Bytes(), reordered.Bytes()) {
+		t.Errorf("Expected reordered neighbor lists to save identically")
+	}
+
+	loaded, err := Load(&first)
+	if err != nil {
+		t.Fatalf("Error loading index: %v", err)
+	}
+	if got := loaded.nodes["vec-7"].Metadata["a"]; got != 7 {
+		t.Errorf("Expected metadata a=7 after Load, but got %v", got)
+	}
+}
+
+// Test for Load reading version 1 snapshots with metadata maps
+func TestLoadVersion1Metadata(t *testing.T) {
+	hnswIndex := NewHNSW(5, 4)
+	hnswIndex.AddVectorWithMetadata("vec-1", generateRandomVector(5), map[string]any{"color": "red"})
+
+	s := hnswIndex.snapshot()
+	s.FormatVersion = 1
+	s.Nodes[0].Metadata = map[string]any{"color": "red"}
+	s.Nodes[0].Fields = nil
+	loaded, err := restoreSnapshot(s)
+	if err != nil {
+		t.Fatalf("Error restoring version 1 snapshot: %v", err)
+	}
+	if got := loaded.nodes["vec-1"].Metadata["color"]; got != "red" {
+		t.Errorf("Expected metadata color=red, but got %v", got)
+	}
+}
+
+// Test for SaveWithOptions dropping the references to deleted vectors
+func TestSaveDropTombstones(t *testing.T) {
+	hnswIndex := buildPersistIndex(200)
+	for i := 0; i < 200; i += 3 {
+		hnswIndex.DeleteVector(fmt.Sprintf("vec-%d", i))
+	}
+	dangling := func(index *HNSW) int {
+		count := 0
+		for _, node := range index.nodes {
+			for _, neighborID := range node.Neighbors {
+				if _, exists := index.nodes[neighborID]; !exists {
+					count++
+				}
+			}
+		}
+		return count
+	}
+	if dangling(hnswIndex) == 0 {
+		t.Fatalf("Expected deletes to leave dangling neighbor references")
+	}
+
+	var kept, dropped bytes.Buffer
+	if err := hnswIndex.SaveWithOptions(&kept, SaveOptions{}); err != nil {
+		t.Fatalf("Error saving index: %v", err)
+	}
+	if err := hnswIndex.SaveWithOptions(&dropped, SaveOptions{DropTombstones: true}); err != nil {
+		t.Fatalf("Error saving index: %v", err)
+	}
+	if dropped.Len() >= kept.Len() {
+		t.Errorf("Expected a smaller file without tombstones, but got %d bytes vs %d", dropped.Len(), kept.Len())
+	}
+
+	withTombstones, err := Load(&kept)
+	if err != nil {
+		t.Fatalf("Error loading index: %v", err)
+	}
+	withoutTombstones, err := Load(&dropped)
+	if err != nil {
+		t.Fatalf("Error loading index: %v", err)
+	}
+	if withTombstones.Len() != hnswIndex.Len() || withoutTombstones.Len() != hnswIndex.Len() {
+		t.Errorf("Expected Len %d after both loads, but got %d and %d", hnswIndex.Len(), withTombstones.Len(), withoutTombstones.Len())
+	}
+	if dangling(withTombstones) == 0 {
+		t.Errorf("Expected the default save to keep the dangling references")
+	}
+	if got := dangling(withoutTombstones); got != 0 {
+		t.Errorf("Expected no dangling references after dropping tombstones, but got %d", got)
+	}
+	// The live index keeps its references
+	if dangling(hnswIndex) == 0 {
+		t.Errorf("Expected the live index to be unchanged by the save")
+	}
+}
+
+// Test for rebuilding an index exactly with AddVectorWithMeta
+func TestAddVectorWithMeta(t *testing.T) {
+	original := buildPersistIndex(200)
+	s := original.snapshot()
+
+	rebuilt := NewHNSW(original.MaxNeighbors, original.MaxLevels)
+	for _, n := range s.Nodes {
+		if err := rebuilt.AddVectorWithMeta(n.ID, Vector{ID: n.ID, Values: n.Values}, n.Level, n.Neighbors); err != nil {
+			t.Fatalf("Error adding vector %s: %v", n.ID, err)
+		}
+	}
+	if !original.StructurallyEqual(rebuilt) {
+		t.Errorf("Expected the rebuilt index to equal the original")
+	}
+
+	if err := rebuilt.AddVectorWithMeta("bad", generateRandomVector(len(s.Nodes[0].Values)), original.MaxLevels, nil); err == nil {
+		t.Errorf("Expected an error for a level out of range, but got nil")
+	}
+	if _, exists := rebuilt.GetVector("bad"); exists {
+		t.Errorf("Expected the rejected vector not to be stored")
+	}
+
+	// A rejected replacement keeps the existing vector
+	id := s.Nodes[0].ID
+	if err := rebuilt.AddVectorWithMeta(id, generateRandomVector(len(s.Nodes[0].Values)), -1, nil); err == nil {
+		t.Errorf("Expected an error for a negative level, but got nil")
+	}
+	if stored, exists := rebuilt.GetVector(id); !exists || !VectorsAlmostEqual(stored, Vector{Values: s.Nodes[0].Values}, 0) {
+		t.Errorf("Expected %s to keep its vector after a rejected replacement", id)
+	}
+}
+
+// Test for LoadMulti merging two saved shards into one index
+func TestLoadMulti(t *testing.T) {
+	shards := []*HNSW{NewHNSW(16, 4), NewHNSW(16, 4)}
+	for i := 0; i < 60; i++ {
+		id := fmt.Sprintf("vec-%d", i)
+		shards[i%2].AddVectorWithMetadata(id, generateRandomVector(8), map[string]any{"n": i})
+	}
+	save := func(index *HNSW) *bytes.Buffer {
+		var buf bytes.Buffer
+		if err := index.Save(&buf); err != nil {
+			t.Fatalf("Error saving shard: %v", err)
+		}
+		return &buf
+	}
+
+	merged, err := LoadMulti([]io.Reader{save(shards[0]), save(shards[1])})
+	if err != nil {
+		t.Fatalf("Error loading shards: %v", err)
+	}
+	if merged.Len() != 60 {
+		t.Fatalf("Expected 60 vectors after the merge, but got %d", merged.Len())
+	}
+	for _, shard := range shards {
+		for id, node := range shard.nodes {
+			loaded := merged.nodes[id]
+			if !VectorsAlmostEqual(node.Vector, loaded.Vector, 0) || loaded.Metadata["n"] != node.Metadata["n"] {
+				t.Errorf("Expected %s to keep its vector and metadata", id)
+			}
+			if merged.topLevel(id) != shard.topLevel(id) || !sameNeighbors(loaded.Neighbors, node.Neighbors) {
+				t.Errorf("Expected %s to keep its level and neighbors", id)
+			}
+		}
+	}
+	results, _ := merged.SearchWithStats(shards[1].nodes["vec-5"].Vector, 1)
+	if len(results) != 1 || results[0].ID != "vec-5" {
+		t.Errorf("Expected a search to find vec-5 from the second shard, but got %v", results)
+	}
+
+	// Shards must not overlap or disagree on the dimension
+	if _, err := LoadMulti([]io.Reader{save(shards[0]), save(shards[0])}); err == nil {
+		t.Errorf("Expected an error for shards sharing IDs, but got nil")
+	}
+	other := NewHNSW(16, 4)
+	other.AddVector("other", generateRandomVector(4))
+	if _, err := LoadMulti([]io.Reader{save(shards[0]), save(other)}); err == nil {
+		t.Errorf("Expected an error for shards of different dimensions, but got nil")
+	}
+	if _, err := LoadMulti(nil); err == nil {
+		t.Errorf("Expected an error for no shards, but got nil")
+	}
+}
+
+// Test for AddVectorWithMeta counting as a fresh access and replicating exactly
+func TestAddVectorWithMetaEvictionAndReplication(t *testing.T) {
+	capped := NewHNSW(5, 4, WithMaxVectors(5))
+	for i := 0; i < 5; i++ {
+		capped.AddVector(fmt.Sprintf("vec-%d", i), generateRandomVector(4))
+	}
+	if err := capped.AddVectorWithMeta("restored", generateRandomVector(4), 3, []string{"vec-1"}); err != nil {
+		t.Fatalf("Error adding vector: %v", err)
+	}
+	if _, exists := capped.GetVector("restored"); !exists {
+		t.Errorf("Expected the restored vector to survive eviction")
+	}
+	if _, exists := capped.GetVector("vec-0"); exists {
+		t.Errorf("Expected the least recently accessed vector to be evicted")
+	}
+
+	primary := NewHNSW(5, 4, WithSeed(1), WithDeltaLog(), WithRandomProjection(2, 1))
+	replica := NewHNSW(5, 4, WithSeed(1), WithRandomProjection(2, 1))
+	for i := 0; i < 20; i++ {
+		primary.AddVector(fmt.Sprintf("vec-%d", i), generateRandomVector(8))
+	}
+	if err := primary.AddVectorWithMeta("restored", Vector{Values: []float64{1, 2}}, 1, []string{"vec-3", "vec-4"}); err != nil {
+		t.Fatalf("Error adding vector: %v", err)
+	}
+	for _, delta := range primary.DeltaSince(0) {
+		if err := replica.Apply(delta); err != nil {
+			t.Fatalf("Error applying delta %d: %v", delta.Seq, err)
+		}
+	}
+	if !primary.StructurallyEqual(replica) {
+		t.Errorf("Expected the replica to match the primary after a restored add")
+	}
+}
@@ -0,0 +1,98 @@
+package gector
+
+// QueryPlan is a reusable query shape: the number of results, candidate pool
+// size, filter, distance override and excluded IDs are fixed when the plan is
+// built, and each Run only supplies the query vector.
+type QueryPlan struct {
+	hnsw *HNSW
+	k    int
+	// Number of candidates retrieved under the index metric before they are
+	// re-ranked by dist
+	ef       int
+	filter   Filter
+	dist     DistanceFunc
+	excluded map[string]struct{}
+}
+
+// NewQueryPlan builds a plan returning the k nearest neighbors among the
+// vectors accepted by filter and not listed in exclude. When dist is not nil,
+// the max(ef, k) nearest candidates under the index metric are re-ranked by
+// it, as in SearchWithMetric. filter and dist may be nil.
+func (hnsw *HNSW) NewQueryPlan(k, ef int, filter Filter, dist DistanceFunc, exclude []string) *QueryPlan {
+	excluded := make(map[string]struct{}, len(exclude))
+	for _, id := range exclude {
+		excluded[id] = struct{}{}
+	}
+	return &QueryPlan{
+		hnsw:     hnsw,
+		k:        k,
+		ef:       max(ef, k),
+		filter:   filter,
+		dist:     dist,
+		excluded: excluded,
+	}
+}
+
+// Run executes the plan for the query.
+func (plan *QueryPlan) Run(query Vector) []SearchResult {
+	hnsw := plan.hnsw
+	defer hnsw.readUnlock(hnsw.readLock())
+
+	if hnsw.checkDimension("search", query.ID, query) != nil {
+		return nil
+	}
+
+	if plan.k <= 0 {
+		return nil
+	}
+
+	query = hnsw.transformQuery(query)
+	queryInvNorm := inverseNorm(vectorNorm(query))
+	pool := plan.k
+	if plan.dist != nil {
+		pool = plan.ef
+	}
+	best := make(resultHeap, 0, pool)
+	offer := func(node *HNSWNode) {
+		if _, ok := plan.excluded[node.ID]; ok {
+			return
+		}
+		best.offer(SearchResult{
+			ID:       node.ID,
+			Vector:   node.Vector,
+			Distance: hnsw.queryDistance(query, queryInvNorm, node),
+			Metadata: hnsw.resultMetadata(node),
+		}, pool)
+	}
+
+	if category, ok := plan.filter.(CategoryFilter); ok {
+		if matches, ok := hnsw.categoryMatches(category); ok {
+			matches.forEach(func(slot int) {
+				offer(hnsw.slots[slot])
+			})
+			return plan.finish(query, best.sorted())
+		}
+	}
+	for id, node := range hnsw.nodes {
+		if plan.filter == nil || plan.filter.Match(id, node.Metadata) {
+			offer(node)
+		}
+	}
+	return plan.finish(query, best.sorted())
+}
+
+// finish re-ranks the candidates by the plan's distance, if any, and keeps
+// the k nearest. The caller must hold the lock.
+func (plan *QueryPlan) finish(query Vector, results []SearchResult) []SearchResult {
+	if plan.dist != nil {
+		for i := range results {
+			results[i].Distance = plan.dist(query, results[i].Vector)
+		}
+		sortResults(results)
+		if len(results) > plan.k {
+			results = results[:plan.k]
+		}
+	}
+	plan.hnsw.touch(results)
+	return plan.hnsw.processResults(results)
+}
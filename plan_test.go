@@ -0,0 +1,66 @@
+package gector
+
+import (
+	"fmt"
+	"testing"
+)
+
+// Test for running one QueryPlan with several query vectors
+func TestQueryPlanRun(t *testing.T) {
+	hnswIndex := NewHNSW(5, 4, WithCategoricalIndex("tenant"))
+	for i := 0; i < 200; i++ {
+		id := fmt.Sprintf("vec-%d", i)
+		hnswIndex.AddVectorWithMetadata(id, generateRandomVector(5), map[string]any{"tenant": fmt.Sprintf("t%d", i%4)})
+	}
+
+	filter := CategoryFilter{Field: "tenant", Values: []any{"t1", "t2"}}
+	exclude := []string{"vec-1", "vec-2", "vec-5", "vec-6"}
+	plan := hnswIndex.NewQueryPlan(10, 40, filter, nil, exclude)
+	withoutExcluded := FilterFunc(func(id string, meta map[string]any) bool {
+		for _, excluded := range exclude {
+			if id == excluded {
+				return false
+			}
+		}
+		return filter.Match(id, meta)
+	})
+
+	for q := 0; q < 5; q++ {
+		query := generateRandomVector(5)
+		results := plan.Run(query)
+		expected := bruteForceFilter(hnswIndex, query, 10, withoutExcluded)
+		if len(results) != len(expected) {
+			t.Fatalf("Expected %d results, but got %d", len(expected), len(results))
+		}
+		for i := range expected {
+			if results[i].ID != expected[i].ID {
+				t.Errorf("Expected result %d of query %d to be '%s', but got '%s'", i, q, expected[i].ID, results[i].ID)
+			}
+		}
+	}
+}
+
+// Test for a QueryPlan re-ranking its candidates by a distance override
+func TestQueryPlanDistance(t *testing.T) {
+	hnswIndex := NewHNSW(5, 4)
+	for i := 0; i < 100; i++ {
+		hnswIndex.AddVector(fmt.Sprintf("vec-%d", i), generateRandomVector(5))
+	}
+
+	plan := hnswIndex.NewQueryPlan(5, 20, nil, CosineDistance, nil)
+	for q := 0; q < 3; q++ {
+		query := generateRandomVector(5)
+		results := plan.Run(query)
+		if len(results) != 5 {
+			t.Fatalf("Expected 5 results, but got %d", len(results))
+		}
+
+		// The results are the nearest of the 20 Euclidean candidates under cosine distance
+		candidates := hnswIndex.SearchWithMetric(query, 20, CosineDistance)
+		for i := range results {
+			if results[i].ID != candidates[i].ID || results[i].Distance != candidates[i].Distance {
+				t.Errorf("Expected result %d of query %d to be '%s', but got '%s'", i, q, candidates[i].ID, results[i].ID)
+			}
+		}
+	}
+}
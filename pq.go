@@ -0,0 +1,176 @@
+package gector
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+)
+
+// pqIterations is the number of k-means iterations used to train each
+// subquantizer.
+const pqIterations = 20
+
+// ProductQuantizer compresses vectors by splitting them into equal
+// subvectors and replacing each with the index of its nearest centroid in a
+// per-subspace codebook, so a vector is stored as one byte per subspace.
+type ProductQuantizer struct {
+	dim, subspaces, subDim int
+	// centroids[s][c] is centroid c of subspace s
+	centroids [][][]float64
+}
+
+// TrainProductQuantizer learns codebooks of centroids per subspace (at most
+// 256) from the training vectors with k-means. The dimension must be
+// divisible by subspaces. A nil rng uses the global source.
+func TrainProductQuantizer(vectors []Vector, subspaces, centroids int, rng *rand.Rand) (*ProductQuantizer, error) {
+	if len(vectors) == 0 {
+		return nil, fmt.Errorf("no training vectors")
+	}
+	dim := len(vectors[0].Values)
+	if subspaces <= 0 || dim%subspaces != 0 {
+		return nil, fmt.Errorf("dimension %d is not divisible into %d subspaces", dim, subspaces)
+	}
+	if centroids <= 0 || centroids > 256 || centroids > len(vectors) {
+		return nil, fmt.Errorf("invalid number of centroids %d for %d training vectors", centroids, len(vectors))
+	}
+	for _, v := range vectors {
+		if len(v.Values) != dim {
+			return nil, &VectorError{ID: v.ID, Op: "train", Want: dim, Got: len(v.Values)}
+		}
+	}
+	if rng == nil {
+		rng = rand.New(rand.NewSource(rand.Int63()))
+	}
+
+	pq := &ProductQuantizer{
+		dim:       dim,
+		subspaces: subspaces,
+		subDim:    dim / subspaces,
+		centroids: make([][][]float64, subspaces),
+	}
+	for s := range pq.centroids {
+		points := make([][]float64, len(vectors))
+		for i, v := range vectors {
+			points[i] = v.Values[s*pq.subDim : (s+1)*pq.subDim]
+		}
+		pq.centroids[s] = kMeans(points, centroids, rng)
+	}
+	return pq, nil
+}
+
+// kMeans clusters points into k centroids with Lloyd's algorithm, starting
+// from k distinct points chosen at random.
+func kMeans(points [][]float64, k int, rng *rand.Rand) [][]float64 {
+	centroids := make([][]float64, k)
+	for i, p := range rng.Perm(len(points))[:k] {
+		centroids[i] = append([]float64(nil), points[p]...)
+	}
+
+	assignment := make([]int, len(points))
+	for iteration := 0; iteration < pqIterations; iteration++ {
+		for i, p := range points {
+			assignment[i] = nearestCentroid(centroids, p)
+		}
+
+		sums := make([][]float64, k)
+		counts := make([]int, k)
+		for i, p := range points {
+			c := assignment[i]
+			if sums[c] == nil {
+				sums[c] = make([]float64, len(p))
+			}
+			for j, value := range p {
+				sums[c][j] += value
+			}
+			counts[c]++
+		}
+		// Empty clusters keep their previous centroid
+		for c := range centroids {
+			if counts[c] == 0 {
+				continue
+			}
+			for j := range centroids[c] {
+				centroids[c][j] = sums[c][j] / float64(counts[c])
+			}
+		}
+	}
+	return centroids
+}
+
+// nearestCentroid returns the index of the centroid closest to p.
+func nearestCentroid(centroids [][]float64, p []float64) int {
+	best, bestDistance := 0, math.Inf(1)
+	for c, centroid := range centroids {
+		if distance := squaredL2(centroid, p); distance < bestDistance {
+			best, bestDistance = c, distance
+		}
+	}
+	return best
+}
+
+// squaredL2 returns the squared Euclidean distance between two equal-length slices.
+func squaredL2(a, b []float64) float64 {
+	var sum float64
+	for i := range a {
+		diff := a[i] - b[i]
+		sum += diff * diff
+	}
+	return sum
+}
+
+// Encode returns the code of v: the nearest centroid of each subvector.
+func (pq *ProductQuantizer) Encode(v Vector) []byte {
+	codes := make([]byte, pq.subspaces)
+	for s := range codes {
+		codes[s] = byte(nearestCentroid(pq.centroids[s], v.Values[s*pq.subDim:(s+1)*pq.subDim]))
+	}
+	return codes
+}
+
+// Decode reconstructs the approximate vector a code stands for.
+func (pq *ProductQuantizer) Decode(codes []byte) Vector {
+	values := make([]float64, 0, pq.dim)
+	for s, c := range codes {
+		values = append(values, pq.centroids[s][c]...)
+	}
+	return Vector{Values: values}
+}
+
+// ADCTable holds the squared distances from one query to every centroid of
+// every subspace, for asymmetric distance computation against codes.
+type ADCTable struct {
+	subspaces int
+	// distances[s*centroids+c] is the squared distance from subvector s of
+	// the query to centroid c of subspace s
+	distances []float64
+	centroids int
+}
+
+// Table precomputes the distances from the query to every centroid. Building
+// it costs about as much as decoding and comparing one code per centroid, and
+// it is then reused for every candidate of the query.
+func (pq *ProductQuantizer) Table(query Vector) *ADCTable {
+	centroids := len(pq.centroids[0])
+	table := &ADCTable{
+		subspaces: pq.subspaces,
+		distances: make([]float64, pq.subspaces*centroids),
+		centroids: centroids,
+	}
+	for s := 0; s < pq.subspaces; s++ {
+		sub := query.Values[s*pq.subDim : (s+1)*pq.subDim]
+		for c, centroid := range pq.centroids[s] {
+			table.distances[s*centroids+c] = squaredL2(centroid, sub)
+		}
+	}
+	return table
+}
+
+// Distance returns the Euclidean distance from the table's query to the
+// vector a code stands for, by summing one table lookup per subspace.
+func (t *ADCTable) Distance(codes []byte) float64 {
+	var sum float64
+	for s, c := range codes {
+		sum += t.distances[s*t.centroids+int(c)]
+	}
+	return math.Sqrt(sum)
+}
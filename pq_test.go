@@ -0,0 +1,87 @@
+package gector
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+)
+
+// generatePQData returns n random vectors of the given dimension from rng.
+func generatePQData(n, dim int, rng *rand.Rand) []Vector {
+	vectors := make([]Vector, n)
+	for i := range vectors {
+		values := make([]float64, dim)
+		for j := range values {
+			values[j] = rng.Float64() * 100
+		}
+		vectors[i] = Vector{Values: values}
+	}
+	return vectors
+}
+
+// Test for ADC table distances matching decode-then-compute distances
+func TestProductQuantizerADC(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	vectors := generatePQData(500, 16, rng)
+	pq, err := TrainProductQuantizer(vectors, 4, 32, rng)
+	if err != nil {
+		t.Fatalf("Error training quantizer: %v", err)
+	}
+
+	query := generatePQData(1, 16, rng)[0]
+	table := pq.Table(query)
+	for _, v := range vectors[:50] {
+		codes := pq.Encode(v)
+		if len(codes) != 4 {
+			t.Fatalf("Expected 4 codes, but got %d", len(codes))
+		}
+		expected := EuclideanDistance(query, pq.Decode(codes))
+		if distance := table.Distance(codes); math.Abs(distance-expected) > 1e-9 {
+			t.Errorf("Expected ADC distance %f, but got %f", expected, distance)
+		}
+	}
+
+	// Reconstructions are closer to their vectors than the data spread
+	var errorSum float64
+	for _, v := range vectors {
+		errorSum += EuclideanDistance(v, pq.Decode(pq.Encode(v)))
+	}
+	if mean := errorSum / float64(len(vectors)); mean > 60 {
+		t.Errorf("Expected a mean reconstruction error below 60, but got %f", mean)
+	}
+
+	if _, err := TrainProductQuantizer(vectors, 5, 32, rng); err == nil {
+		t.Errorf("Expected an error for a dimension not divisible into subspaces, but got nil")
+	}
+}
+
+// Benchmark for table-lookup distances against decode-then-compute
+func BenchmarkProductQuantizerADC(b *testing.B) {
+	rng := rand.New(rand.NewSource(1))
+	vectors := generatePQData(2000, 128, rng)
+	pq, err := TrainProductQuantizer(vectors[:1000], 16, 256, rng)
+	if err != nil {
+		b.Fatalf("Error training quantizer: %v", err)
+	}
+	codes := make([][]byte, len(vectors))
+	for i, v := range vectors {
+		codes[i] = pq.Encode(v)
+	}
+	query := generatePQData(1, 128, rng)[0]
+
+	b.Run("table", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			table := pq.Table(query)
+			for _, code := range codes {
+				table.Distance(code)
+			}
+		}
+	})
+	b.Run("decode", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			for _, code := range codes {
+				EuclideanDistance(query, pq.Decode(code))
+			}
+		}
+	})
+}
@@ -0,0 +1,136 @@
+package gector
+
+import "math/rand"
+
+// recallProbeK is the number of neighbors compared by the recall probe.
+const recallProbeK = 10
+
+// recallProbe periodically compares search results against brute force.
+type recallProbe struct {
+	everyN     int
+	numQueries int
+	cb         func(recall float64)
+	// Number of inserts since the index was created
+	inserts int
+	// Private source for sampling queries, so probing never changes the
+	// random level assignment of the index
+	rng *rand.Rand
+}
+
+// afterInsert does the bookkeeping for a completed insert and returns the
+// callbacks to run once the write lock is released. The caller must hold the
+// write lock.
+func (hnsw *HNSW) afterInsert() func() {
+	hnsw.evictOverflow()
+
+	recall := hnsw.probeRecall()
+	growth := hnsw.observeGrowth()
+	if recall == nil && growth == nil {
+		return func() {}
+	}
+	return func() {
+		if recall != nil {
+			recall()
+		}
+		if growth != nil {
+			growth()
+		}
+	}
+}
+
+// probeRecall counts an insert for the recall probe and measures recall when
+// it is due, returning the callback to run or nil. The caller must hold the
+// write lock.
+func (hnsw *HNSW) probeRecall() func() {
+	probe := hnsw.recallProbe
+	if probe == nil || probe.everyN <= 0 {
+		return nil
+	}
+
+	probe.inserts++
+	if probe.inserts%probe.everyN != 0 {
+		return nil
+	}
+
+	recall := hnsw.measureRecall(probe.numQueries, probe.rng)
+	return func() {
+		probe.cb(recall)
+	}
+}
+
+// measureRecall samples stored vectors as queries and returns the mean
+// recall of the search path against brute force. The caller must hold the lock.
+func (hnsw *HNSW) measureRecall(numQueries int, rng *rand.Rand) float64 {
+	if numQueries <= 0 || len(hnsw.nodes) == 0 {
+		return 1
+	}
+
+	ids := make([]string, 0, len(hnsw.nodes))
+	for id := range hnsw.nodes {
+		ids = append(ids, id)
+	}
+
+	var total float64
+	for i := 0; i < numQueries; i++ {
+		query := hnsw.nodes[ids[rng.Intn(len(ids))]].Vector
+		approx, _ := hnsw.scan(query, recallProbeK)
+		exact := hnsw.bruteForce(query, recallProbeK)
+		total += recallOf(approx, exact)
+	}
+	return total / float64(numQueries)
+}
+
+// recallOf returns the fraction of the exact results found in the approximate ones.
+func recallOf(approx, exact []SearchResult) float64 {
+	if len(exact) == 0 {
+		return 1
+	}
+	found := make(map[string]bool, len(approx))
+	for _, result := range approx {
+		found[result.ID] = true
+	}
+	hits := 0
+	for _, result := range exact {
+		if found[result.ID] {
+			hits++
+		}
+	}
+	return float64(hits) / float64(len(exact))
+}
+
+// RecallCurve returns the mean recall@k of the queries against brute force
+// for each ef value. Each query is searched with ef as the cap on distance
+// evaluations, as with WithMaxExpansionsPerLevel, so the curve shows the
+// recall the index would reach under that option. Searches visit the vectors
+// in no fixed order, so nearby ef values may trade places; with a cap of ef
+// over N vectors recall is about ef/N. Budgets below k are raised to k.
+// Queries whose dimension does not match the index are skipped, and the
+// curve is empty when none match.
+func (hnsw *HNSW) RecallCurve(queries []Vector, k int, efValues []int) map[int]float64 {
+	defer hnsw.readUnlock(hnsw.readLock())
+
+	curve := make(map[int]float64, len(efValues))
+	if len(queries) == 0 || k <= 0 {
+		return curve
+	}
+
+	valid := 0
+	for _, query := range queries {
+		if hnsw.checkDimension("search", query.ID, query) != nil {
+			continue
+		}
+		valid++
+
+		query = hnsw.transformQuery(query)
+		exact := hnsw.bruteForce(query, k)
+		for _, ef := range efValues {
+			approx, _ := hnsw.scanCapped(query, k, max(ef, k), nil)
+			curve[ef] += recallOf(approx, exact)
+		}
+	}
+
+	for ef := range curve {
+		curve[ef] /= float64(valid)
+	}
+	return curve
+}
@@ -0,0 +1,81 @@
+package gector
+
+import (
+	"fmt"
+	"testing"
+)
+
+// Test for the recall probe firing with plausible values during a build
+func TestRecallProbe(t *testing.T) {
+	var recalls []float64
+	hnswIndex := NewHNSW(5, 4, WithRecallProbe(25, 5, func(recall float64) {
+		recalls = append(recalls, recall)
+	}))
+
+	for i := 0; i < 100; i++ {
+		hnswIndex.AddVector(fmt.Sprintf("vec-%d", i), generateRandomVector(5))
+	}
+
+	if len(recalls) != 4 {
+		t.Fatalf("Expected the probe to fire 4 times, but got %d", len(recalls))
+	}
+	for _, recall := range recalls {
+		if recall < 0 || recall > 1 {
+			t.Errorf("Expected recall in [0, 1], but got %f", recall)
+		}
+	}
+
+	// The callback may use the index since the lock is released
+	probed := false
+	var probeIndex *HNSW
+	probeIndex = NewHNSW(5, 4, WithRecallProbe(1, 1, func(recall float64) {
+		probeIndex.NearestNeighbors(generateRandomVector(5), 1)
+		probed = true
+	}))
+	probeIndex.AddVector("vec-1", generateRandomVector(5))
+	if !probed {
+		t.Errorf("Expected the probe callback to run")
+	}
+
+	if len(hnswIndex.nodes) != 100 {
+		t.Errorf("Expected 100 vectors after probing, but got %d", len(hnswIndex.nodes))
+	}
+}
+
+// Test for RecallCurve measuring the search under each expansion cap
+func TestRecallCurve(t *testing.T) {
+	hnswIndex := NewHNSW(5, 4, WithMaxInsertCandidates(16))
+	for i := 0; i < 500; i++ {
+		hnswIndex.AddVector(fmt.Sprintf("vec-%d", i), generateRandomVector(5))
+	}
+	queries := make([]Vector, 20)
+	for i := range queries {
+		queries[i] = generateRandomVector(5)
+	}
+
+	efValues := []int{10, 100, 500}
+	curve := hnswIndex.RecallCurve(queries, 10, efValues)
+	if len(curve) != len(efValues) {
+		t.Fatalf("Expected %d points, but got %d", len(efValues), len(curve))
+	}
+	for i := 1; i < len(efValues); i++ {
+		if curve[efValues[i]] <= curve[efValues[i-1]] {
+			t.Errorf("Expected recall at ef=%d to be above %f, but got %f", efValues[i], curve[efValues[i-1]], curve[efValues[i]])
+		}
+	}
+	if curve[500] != 1 {
+		t.Errorf("Expected full recall when every vector is scored, but got %f", curve[500])
+	}
+	if curve[10] >= 0.5 {
+		t.Errorf("Expected recall near 10/500 at ef=10, but got %f", curve[10])
+	}
+
+	// Queries of the wrong dimension are skipped rather than scored
+	mixed := hnswIndex.RecallCurve(append(queries[:1:1], generateRandomVector(3)), 5, []int{500})
+	if mixed[500] != 1 {
+		t.Errorf("Expected a mismatched query to be skipped, but got recall %f", mixed[500])
+	}
+	if curve := hnswIndex.RecallCurve([]Vector{generateRandomVector(3)}, 5, []int{10}); len(curve) != 0 {
+		t.Errorf("Expected an empty curve when no query matches, but got %v", curve)
+	}
+}
@@ -0,0 +1,86 @@
+package gector
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+)
+
+// randomProjection maps vectors to a lower dimension through a fixed
+// Gaussian matrix, scaled by 1/sqrt(targetDim) so distances are preserved in
+// expectation.
+type randomProjection struct {
+	targetDim int
+	seed      int64
+	// Dimension of the input vectors, captured from the first insert
+	inputDim int
+	// Row-major targetDim x inputDim matrix, generated on the first insert
+	matrix []float64
+}
+
+// project returns the vector projected to the target dimension. Vectors that
+// already have the target dimension, or that do not match the input dimension
+// of the matrix, are returned unchanged.
+func (p *randomProjection) project(v Vector) Vector {
+	if p == nil || p.matrix == nil || len(v.Values) == p.targetDim || len(v.Values) != p.inputDim {
+		return v
+	}
+
+	values := make([]float64, p.targetDim)
+	for row := range values {
+		values[row] = DotRaw(p.matrix[row*p.inputDim:(row+1)*p.inputDim], v.Values)
+	}
+	return Vector{ID: v.ID, Values: values}
+}
+
+// init generates the matrix for the given input dimension from the seed, so
+// the same seed and dimension always give the same projection.
+func (p *randomProjection) init(inputDim int) {
+	if p.matrix != nil || inputDim == 0 || inputDim == p.targetDim {
+		return
+	}
+
+	rng := rand.New(rand.NewSource(p.seed))
+	scale := 1 / math.Sqrt(float64(p.targetDim))
+	p.inputDim = inputDim
+	p.matrix = make([]float64, p.targetDim*inputDim)
+	for i := range p.matrix {
+		p.matrix[i] = rng.NormFloat64() * scale
+	}
+}
+
+// maxProjectionEntries bounds the size of a projection matrix restored from a
+// file, so a corrupt dimension cannot allocate an arbitrary amount of memory.
+const maxProjectionEntries = 1 << 26
+
+// snapshotProjection is the serialized form of a random projection. The
+// matrix is not stored, since the seed and dimensions regenerate it.
+type snapshotProjection struct {
+	TargetDim int
+	Seed      int64
+	InputDim  int
+}
+
+// projectionSnapshot returns the serialized projection of the index, or nil
+// without one. The caller must hold the lock.
+func (hnsw *HNSW) projectionSnapshot() *snapshotProjection {
+	p := hnsw.projection
+	if p == nil {
+		return nil
+	}
+	return &snapshotProjection{TargetDim: p.targetDim, Seed: p.seed, InputDim: p.inputDim}
+}
+
+// restoreProjection sets the projection of an index being loaded,
+// regenerating its matrix when the input dimension is known.
+func (hnsw *HNSW) restoreProjection(s *snapshotProjection) error {
+	if s == nil {
+		return nil
+	}
+	if s.TargetDim <= 0 || s.InputDim < 0 || s.InputDim > maxProjectionEntries/s.TargetDim {
+		return fmt.Errorf("invalid projection from %d to %d dimensions", s.InputDim, s.TargetDim)
+	}
+	hnsw.projection = &randomProjection{targetDim: s.TargetDim, seed: s.Seed}
+	hnsw.projection.init(s.InputDim)
+	return nil
+}
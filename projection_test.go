@@ -0,0 +1,102 @@
+package gector
+
+import (
+	"bytes"
+	"fmt"
+	"math/rand"
+	"testing"
+)
+
+// Test for WithRandomProjection reducing the dimension and keeping neighbors near
+func TestWithRandomProjection(t *testing.T) {
+	const inputDim, targetDim = 256, 32
+	rng := rand.New(rand.NewSource(1))
+	hnswIndex := NewHNSW(5, 3, WithRandomProjection(targetDim, 7))
+
+	// Ten tight clusters of ten vectors each
+	centers := make([][]float64, 10)
+	for c := range centers {
+		centers[c] = make([]float64, inputDim)
+		for i := range centers[c] {
+			centers[c][i] = rng.NormFloat64() * 10
+		}
+		for j := 0; j < 10; j++ {
+			values := make([]float64, inputDim)
+			for i := range values {
+				values[i] = centers[c][i] + rng.NormFloat64()*0.1
+			}
+			id := fmt.Sprintf("c%d-%d", c, j)
+			hnswIndex.AddVector(id, Vector{ID: id, Values: values})
+		}
+	}
+
+	if stored, _ := hnswIndex.GetVector("c0-0"); len(stored.Values) != targetDim {
+		t.Fatalf("Expected stored vectors of dimension %d, but got %d", targetDim, len(stored.Values))
+	}
+
+	// A query at a cluster center finds that cluster's vectors first
+	for c := range centers {
+		results, _ := hnswIndex.SearchWithStats(Vector{Values: centers[c]}, 10)
+		for _, result := range results {
+			if len(result.Vector.Values) != targetDim {
+				t.Fatalf("Expected results of dimension %d, but got %d", targetDim, len(result.Vector.Values))
+			}
+			var cluster, member int
+			fmt.Sscanf(result.ID, "c%d-%d", &cluster, &member)
+			if cluster != c {
+				t.Errorf("Expected cluster %d vectors for its center, but got %s", c, result.ID)
+			}
+		}
+	}
+
+	// The same seed projects identically
+	other := NewHNSW(5, 3, WithRandomProjection(targetDim, 7))
+	original, _ := hnswIndex.GetVector("c0-0")
+	other.AddVector("first", Vector{ID: "first", Values: centers[0]})
+	projected := hnswIndex.transformQuery(Vector{Values: centers[0]})
+	if stored, _ := other.GetVector("first"); !VectorsAlmostEqual(stored, projected, 1e-12) {
+		t.Errorf("Expected the same projection from the same seed")
+	}
+	if VectorsAlmostEqual(original, projected, 0) {
+		t.Errorf("Expected a member to differ from its center after projection")
+	}
+}
+
+// Test for a projected index projecting queries after Save and Load
+func TestRandomProjectionPersistence(t *testing.T) {
+	const inputDim, targetDim = 64, 8
+	hnswIndex := NewHNSW(5, 3, WithRandomProjection(targetDim, 3))
+	for i := 0; i < 50; i++ {
+		hnswIndex.AddVector(fmt.Sprintf("vec-%d", i), generateRandomVector(inputDim))
+	}
+	query := generateRandomVector(inputDim)
+	expected, _ := hnswIndex.SearchWithStats(query, 5)
+
+	var gobBuf, compactBuf bytes.Buffer
+	if err := hnswIndex.Save(&gobBuf); err != nil {
+		t.Fatalf("Error saving index: %v", err)
+	}
+	if err := hnswIndex.SaveCompact(&compactBuf, true); err != nil {
+		t.Fatalf("Error saving compact index: %v", err)
+	}
+	fromGob, err := Load(&gobBuf)
+	if err != nil {
+		t.Fatalf("Error loading index: %v", err)
+	}
+	fromCompact, err := LoadCompact(&compactBuf)
+	if err != nil {
+		t.Fatalf("Error loading compact index: %v", err)
+	}
+
+	for name, loaded := range map[string]*HNSW{"gob": fromGob, "compact": fromCompact} {
+		results, _ := loaded.SearchWithStats(query, 5)
+		if len(results) != len(expected) {
+			t.Fatalf("Expected %d results from the %s index, but got %d", len(expected), name, len(results))
+		}
+		for i := range expected {
+			if results[i].ID != expected[i].ID {
+				t.Errorf("Expected %s at rank %d from the %s index, but got %s", expected[i].ID, i, name, results[i].ID)
+			}
+		}
+	}
+}
@@ -0,0 +1,108 @@
+package gector
+
+import "fmt"
+
+// quantizedScan ranks every node by the asymmetric distance from the query to
+// its product quantization code, then re-scores the best max(rerank, k)
+// candidates with their original vectors and keeps the k nearest. Without
+// re-ranking the quantized distances are returned as they are. Nodes without
+// a code are scored exactly. It returns the number of exact distance
+// computations. The caller must hold the lock.
+func (hnsw *HNSW) quantizedScan(query Vector, k int, buf []SearchResult) ([]SearchResult, int) {
+	pool := k
+	if hnsw.rerank > 0 {
+		pool = max(hnsw.rerank, k)
+	}
+
+	queryInvNorm := inverseNorm(vectorNorm(query))
+	table := hnsw.quantizer.Table(query)
+	candidates := make(resultHeap, 0, pool)
+	evaluations := 0
+	for _, node := range hnsw.slots {
+		if node == nil {
+			continue
+		}
+
+		var distance float64
+		if node.codes != nil {
+			distance = table.Distance(node.codes)
+		} else {
+			distance = hnsw.queryDistance(query, queryInvNorm, node)
+			evaluations++
+		}
+		candidates.offer(SearchResult{
+			ID:       node.ID,
+			Vector:   node.Vector,
+			Distance: distance,
+			Metadata: hnsw.resultMetadata(node),
+		}, pool)
+	}
+	if hnsw.rerank <= 0 {
+		return candidates.sorted(), evaluations
+	}
+
+	best := resultHeap(buf[:0])
+	if cap(best) < k {
+		best = make(resultHeap, 0, k)
+	}
+	for _, candidate := range candidates {
+		candidate.Distance = hnsw.queryDistance(query, queryInvNorm, hnsw.nodes[candidate.ID])
+		evaluations++
+		best.offer(candidate, k)
+	}
+	return best.sorted(), evaluations
+}
+
+// snapshotQuantizer is the serialized form of a quantizer and its rerank
+// setting. Codes are not stored, since encoding the vectors again with the
+// same codebooks gives the same codes.
+type snapshotQuantizer struct {
+	Centroids [][][]float64
+	Rerank    int
+}
+
+// quantizerSnapshot returns the serialized quantizer of the index, or nil
+// without one. The caller must hold the lock.
+func (hnsw *HNSW) quantizerSnapshot() *snapshotQuantizer {
+	if hnsw.quantizer == nil {
+		return nil
+	}
+	return &snapshotQuantizer{Centroids: hnsw.quantizer.centroids, Rerank: hnsw.rerank}
+}
+
+// restoreQuantizer sets the quantizer of an index being loaded, before its
+// nodes are restored so they are encoded. Codebooks of inconsistent shape,
+// or of another dimension than the index's dim when it is known, are
+// rejected.
+func (hnsw *HNSW) restoreQuantizer(s *snapshotQuantizer, dim int) error {
+	if s == nil {
+		return nil
+	}
+	if len(s.Centroids) == 0 || len(s.Centroids[0]) == 0 || len(s.Centroids[0][0]) == 0 {
+		return fmt.Errorf("invalid quantizer: empty codebook")
+	}
+	subDim := len(s.Centroids[0][0])
+	for _, codebook := range s.Centroids {
+		if len(codebook) == 0 || len(codebook) > 256 {
+			return fmt.Errorf("invalid quantizer: %d centroids in a subspace", len(codebook))
+		}
+		for _, centroid := range codebook {
+			if len(centroid) != subDim {
+				return fmt.Errorf("invalid quantizer: centroid of length %d, expected %d", len(centroid), subDim)
+			}
+		}
+	}
+
+	if dim != 0 && len(s.Centroids)*subDim != dim {
+		return fmt.Errorf("invalid quantizer: dimension %d, expected %d", len(s.Centroids)*subDim, dim)
+	}
+
+	hnsw.quantizer = &ProductQuantizer{
+		dim:       len(s.Centroids) * subDim,
+		subspaces: len(s.Centroids),
+		subDim:    subDim,
+		centroids: s.Centroids,
+	}
+	hnsw.rerank = s.Rerank
+	return nil
+}
@@ -0,0 +1,139 @@
+package gector
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"math/rand"
+	"testing"
+)
+
+// Test for re-ranking with original vectors beating the quantized ranking
+func TestWithQuantizerRerank(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	vectors := generatePQData(1000, 16, rng)
+	pq, err := TrainProductQuantizer(vectors, 4, 16, rng)
+	if err != nil {
+		t.Fatalf("Error training quantizer: %v", err)
+	}
+
+	quantized := NewHNSW(5, 4, WithQuantizer(pq, 0), WithMaxInsertCandidates(16))
+	reranked := NewHNSW(5, 4, WithQuantizer(pq, 100), WithMaxInsertCandidates(16))
+	for i, vector := range vectors {
+		id := fmt.Sprintf("vec-%d", i)
+		quantized.AddVector(id, vector)
+		reranked.AddVector(id, vector)
+	}
+
+	var quantizedRecall, rerankedRecall float64
+	queries := generatePQData(20, 16, rng)
+	for _, query := range queries {
+		exact := reranked.BruteForceSearch(query, 10)
+		approx, _ := quantized.SearchWithStats(query, 10)
+		quantizedRecall += recallOf(approx, exact)
+
+		results, _ := reranked.SearchWithStats(query, 10)
+		rerankedRecall += recallOf(results, exact)
+		for _, result := range results {
+			if expected := EuclideanDistance(query, result.Vector); result.Distance != expected {
+				t.Errorf("Expected the exact distance %f for %s, but got %f", expected, result.ID, result.Distance)
+			}
+		}
+	}
+	quantizedRecall /= float64(len(queries))
+	rerankedRecall /= float64(len(queries))
+
+	if rerankedRecall <= quantizedRecall {
+		t.Errorf("Expected re-ranked recall above quantized recall %f, but got %f", quantizedRecall, rerankedRecall)
+	}
+	if rerankedRecall < 0.9 {
+		t.Errorf("Expected re-ranked recall of at least 0.9, but got %f", rerankedRecall)
+	}
+}
+
+// Test for the quantizer surviving every save format
+func TestQuantizerPersistence(t *testing.T) {
+	rng := rand.New(rand.NewSource(2))
+	vectors := generatePQData(300, 16, rng)
+	pq, err := TrainProductQuantizer(vectors, 4, 16, rng)
+	if err != nil {
+		t.Fatalf("Error training quantizer: %v", err)
+	}
+	hnswIndex := NewHNSW(5, 4, WithQuantizer(pq, 0), WithMaxInsertCandidates(16))
+	for i, vector := range vectors {
+		hnswIndex.AddVector(fmt.Sprintf("vec-%d", i), vector)
+	}
+
+	formats := map[string]func() (*HNSW, error){
+		"gob": func() (*HNSW, error) {
+			var buf bytes.Buffer
+			if err := hnswIndex.Save(&buf); err != nil {
+				return nil, err
+			}
+			return Load(&buf)
+		},
+		"compact": func() (*HNSW, error) {
+			var buf bytes.Buffer
+			if err := hnswIndex.SaveCompact(&buf, false); err != nil {
+				return nil, err
+			}
+			return LoadCompact(&buf)
+		},
+		"stream": func() (*HNSW, error) {
+			var buf bytes.Buffer
+			if err := hnswIndex.SaveStream(&buf); err != nil {
+				return nil, err
+			}
+			loaded, _, err := LoadStream(&buf)
+			return loaded, err
+		},
+	}
+	query := generatePQData(1, 16, rng)[0]
+	expected, _ := hnswIndex.SearchWithStats(query, 5)
+	for name, load := range formats {
+		loaded, err := load()
+		if err != nil {
+			t.Fatalf("Error round-tripping the %s format: %v", name, err)
+		}
+		if loaded.quantizer == nil || loaded.nodes["vec-0"].codes == nil {
+			t.Fatalf("Expected the %s format to keep the quantizer and codes", name)
+		}
+		results, _ := loaded.SearchWithStats(query, 5)
+		for i := range expected {
+			if results[i].ID != expected[i].ID || results[i].Distance != expected[i].Distance {
+				t.Errorf("Expected %s at %f at rank %d after the %s format, but got %s at %f", expected[i].ID, expected[i].Distance, i, name, results[i].ID, results[i].Distance)
+			}
+		}
+	}
+}
+
+// Test for a quantizer of the wrong dimension being rejected on insert and load
+func TestQuantizerDimension(t *testing.T) {
+	rng := rand.New(rand.NewSource(3))
+	vectors := generatePQData(100, 16, rng)
+	pq, err := TrainProductQuantizer(vectors, 4, 16, rng)
+	if err != nil {
+		t.Fatalf("Error training quantizer: %v", err)
+	}
+
+	hnswIndex := NewHNSW(5, 4, WithQuantizer(pq, 0))
+	err = hnswIndex.AddVector("short", generateRandomVector(8))
+	var vectorErr *VectorError
+	if !errors.As(err, &vectorErr) || vectorErr.Want != 16 || vectorErr.Got != 8 {
+		t.Errorf("Expected a VectorError wanting dimension 16, but got %v", err)
+	}
+	if hnswIndex.Len() != 0 {
+		t.Errorf("Expected the mismatched vector to be rejected, but the index holds %d", hnswIndex.Len())
+	}
+	if err := hnswIndex.AddVector("vec-0", vectors[0]); err != nil {
+		t.Errorf("Error adding a vector of the quantizer dimension: %v", err)
+	}
+
+	// A snapshot whose quantizer does not match its vectors fails to load
+	s := hnswIndex.snapshot()
+	s.Dim = 8
+	s.Nodes = nil
+	if _, err := restoreSnapshot(s); err == nil {
+		t.Errorf("Expected an error loading a quantizer of the wrong dimension, but got nil")
+	}
+}
@@ -0,0 +1,136 @@
+package gector
+
+import (
+	"container/list"
+	"encoding/binary"
+	"hash/fnv"
+	"math"
+	"sync"
+)
+
+// queryCache is an LRU cache of search results keyed on the query and k.
+type queryCache struct {
+	// Guards the entries; searches share the index read lock, so the cache
+	// needs its own lock to update the recency order
+	mu      sync.Mutex
+	size    int
+	entries map[uint64]*list.Element
+	// Most recently used entries are at the front
+	order *list.List
+	// Number of lookups answered from the cache
+	hits int
+}
+
+// cacheEntry is a cached result set along with the query that produced it.
+type cacheEntry struct {
+	key     uint64
+	query   []float64
+	k       int
+	results []SearchResult
+}
+
+// newQueryCache creates a cache holding up to size result sets.
+func newQueryCache(size int) *queryCache {
+	return &queryCache{
+		size:    size,
+		entries: make(map[uint64]*list.Element),
+		order:   list.New(),
+	}
+}
+
+// get returns a copy of the cached results for the query, if any.
+func (c *queryCache) get(query Vector, k int) ([]SearchResult, bool) {
+	key := hashQuery(query, k)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	element, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+	entry := element.Value.(*cacheEntry)
+	// Guard against hash collisions
+	if entry.k != k || !sameQuery(entry.query, query.Values) {
+		return nil, false
+	}
+
+	c.order.MoveToFront(element)
+	c.hits++
+	return append([]SearchResult(nil), entry.results...), true
+}
+
+// put stores a copy of the results, evicting the least recently used entry when full.
+func (c *queryCache) put(query Vector, k int, results []SearchResult) {
+	if c.size <= 0 {
+		return
+	}
+	key := hashQuery(query, k)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if element, ok := c.entries[key]; ok {
+		c.order.Remove(element)
+		delete(c.entries, key)
+	}
+	if c.order.Len() >= c.size {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*cacheEntry).key)
+	}
+
+	c.entries[key] = c.order.PushFront(&cacheEntry{
+		key:     key,
+		query:   append([]float64(nil), query.Values...),
+		k:       k,
+		results: append([]SearchResult(nil), results...),
+	})
+}
+
+// clear drops every cached result set.
+func (c *queryCache) clear() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.entries = make(map[uint64]*list.Element)
+	c.order.Init()
+}
+
+// hashQuery hashes k and the bit patterns of the query values. Negative zero
+// and NaNs are canonicalized so equal-looking queries hash the same.
+func hashQuery(query Vector, k int) uint64 {
+	h := fnv.New64a()
+	var buf [8]byte
+	binary.LittleEndian.PutUint64(buf[:], uint64(k))
+	h.Write(buf[:])
+	for _, value := range query.Values {
+		binary.LittleEndian.PutUint64(buf[:], canonicalBits(value))
+		h.Write(buf[:])
+	}
+	return h.Sum64()
+}
+
+// canonicalBits returns the bit pattern of a float with -0 and NaN normalized.
+func canonicalBits(value float64) uint64 {
+	if value == 0 {
+		return 0
+	}
+	if math.IsNaN(value) {
+		return math.Float64bits(math.NaN())
+	}
+	return math.Float64bits(value)
+}
+
+// sameQuery compares two queries by their canonical bit patterns.
+func sameQuery(a, b []float64) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if canonicalBits(a[i]) != canonicalBits(b[i]) {
+			return false
+		}
+	}
+	return true
+}
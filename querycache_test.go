@@ -0,0 +1,46 @@
+package gector
+
+import (
+	"fmt"
+	"math"
+	"testing"
+)
+
+// Test for the query cache hitting on repeated queries and clearing on insert
+func TestQueryCache(t *testing.T) {
+	hnswIndex := NewHNSW(5, 4, WithQueryCache(2))
+	for i := 0; i < 10; i++ {
+		id := fmt.Sprintf("vec-%d", i)
+		hnswIndex.AddVector(id, Vector{ID: id, Values: []float64{float64(i), 0}})
+	}
+
+	query := Vector{Values: []float64{4.2, 0}}
+	first := hnswIndex.NearestNeighbors(query, 1)
+	second := hnswIndex.NearestNeighbors(query, 1)
+	if hnswIndex.cache.hits != 1 {
+		t.Errorf("Expected 1 cache hit after a repeated query, but got %d", hnswIndex.cache.hits)
+	}
+	if !equalVectors(first[0], second[0]) {
+		t.Errorf("Expected the cached result to match the original")
+	}
+
+	// Negative zero is the same query as zero
+	hnswIndex.NearestNeighbors(Vector{Values: []float64{0, 0}}, 1)
+	hnswIndex.NearestNeighbors(Vector{Values: []float64{math.Copysign(0, -1), 0}}, 1)
+	if hnswIndex.cache.hits != 2 {
+		t.Errorf("Expected negative zero to hit the cache, but got %d hits", hnswIndex.cache.hits)
+	}
+
+	// An insert invalidates the cached results
+	hnswIndex.AddVector("vec-new", Vector{ID: "vec-new", Values: []float64{4.2, 0}})
+	if hnswIndex.cache.order.Len() != 0 {
+		t.Errorf("Expected the cache to be cleared after an insert")
+	}
+	results, _ := hnswIndex.SearchWithStats(query, 1)
+	if results[0].ID != "vec-new" {
+		t.Errorf("Expected the new vector after invalidation, but got '%s'", results[0].ID)
+	}
+	if hnswIndex.cache.hits != 2 {
+		t.Errorf("Expected no cache hit after invalidation, but got %d hits", hnswIndex.cache.hits)
+	}
+}
@@ -0,0 +1,67 @@
+package gector
+
+import (
+	"fmt"
+	"sync"
+)
+
+var (
+	// Guards the custom metric registry
+	metricsMu sync.RWMutex
+	// Custom metrics by name: the divergences for probability vectors and
+	// those registered by callers
+	customMetrics = map[string]DistanceFunc{
+		"kl": KLDivergence,
+		"js": JensenShannonDivergence,
+	}
+)
+
+// builtinMetrics maps the names of the built-in metrics to their Metric.
+var builtinMetrics = map[string]Metric{
+	"l2":        Euclidean,
+	"euclidean": Euclidean,
+	"cosine":    Cosine,
+}
+
+// RegisterMetric makes a custom distance function selectable by name through
+// WithMetricName. Names must be unique and cannot shadow the built-in metrics.
+func RegisterMetric(name string, fn DistanceFunc) error {
+	if name == "" || fn == nil {
+		return fmt.Errorf("metric needs a name and a distance function")
+	}
+
+	metricsMu.Lock()
+	defer metricsMu.Unlock()
+
+	if _, exists := builtinMetrics[name]; exists {
+		return fmt.Errorf("metric %s is already registered", name)
+	}
+	if _, exists := customMetrics[name]; exists {
+		return fmt.Errorf("metric %s is already registered", name)
+	}
+	customMetrics[name] = fn
+	return nil
+}
+
+// WithMetricName selects the index metric by name, such as "l2" or "cosine"
+// read from a config file, "kl" or "js" for KLDivergence and
+// JensenShannonDivergence, or a name passed to RegisterMetric.
+func WithMetricName(name string) (Option, error) {
+	if metric, exists := builtinMetrics[name]; exists {
+		return func(hnsw *HNSW) {
+			hnsw.Metric = metric
+			hnsw.distance = nil
+		}, nil
+	}
+
+	metricsMu.RLock()
+	fn, exists := customMetrics[name]
+	metricsMu.RUnlock()
+	if !exists {
+		return nil, fmt.Errorf("unknown metric %s", name)
+	}
+
+	return func(hnsw *HNSW) {
+		hnsw.distance = fn
+	}, nil
+}
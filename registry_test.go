@@ -0,0 +1,51 @@
+package gector
+
+import (
+	"math"
+	"testing"
+)
+
+// Test for registering a custom metric and selecting it by name
+func TestMetricRegistry(t *testing.T) {
+	manhattan := func(a, b Vector) float64 {
+		var sum float64
+		for i := range a.Values {
+			sum += math.Abs(a.Values[i] - b.Values[i])
+		}
+		return sum
+	}
+	if err := RegisterMetric("test-manhattan", manhattan); err != nil {
+		t.Fatalf("Error registering metric: %v", err)
+	}
+	if err := RegisterMetric("test-manhattan", manhattan); err == nil {
+		t.Errorf("Expected an error registering a duplicate metric, but got nil")
+	}
+	if err := RegisterMetric("cosine", manhattan); err == nil {
+		t.Errorf("Expected an error shadowing a built-in metric, but got nil")
+	}
+
+	opt, err := WithMetricName("test-manhattan")
+	if err != nil {
+		t.Fatalf("Error selecting metric: %v", err)
+	}
+	hnswIndex := NewHNSW(5, 4, opt)
+	hnswIndex.AddVector("vec-1", Vector{ID: "vec-1", Values: []float64{3, 4}})
+
+	results, _ := hnswIndex.SearchWithStats(Vector{Values: []float64{0, 0}}, 1)
+	if len(results) != 1 || results[0].Distance != 7 {
+		t.Errorf("Expected the Manhattan distance 7, but got %v", results)
+	}
+
+	// Built-in names select the built-in metrics
+	opt, err = WithMetricName("cosine")
+	if err != nil {
+		t.Fatalf("Error selecting metric: %v", err)
+	}
+	if NewHNSW(5, 4, opt).Metric != Cosine {
+		t.Errorf("Expected 'cosine' to select the cosine metric")
+	}
+
+	if _, err := WithMetricName("unknown"); err == nil {
+		t.Errorf("Expected an error for an unknown metric, but got nil")
+	}
+}
@@ -0,0 +1,41 @@
+package gector
+
+import "sort"
+
+// ReindexWithMetric switches the index to the distance function dist and
+// rebuilds the graph under it from the stored vectors. Every vector keeps its
+// ID, metadata, weight and level; only the neighbor lists are recomputed. A
+// nil dist returns to the index's Metric. The switch is not recorded in the
+// delta log, so replicas must be reindexed separately.
+func (hnsw *HNSW) ReindexWithMetric(dist DistanceFunc) error {
+	hnsw.mu.Lock()
+	defer hnsw.mu.Unlock()
+
+	if hnsw.frozen.Load() {
+		return ErrFrozen
+	}
+
+	hnsw.rebuilding.Store(true)
+	defer hnsw.rebuilding.Store(false)
+
+	nodes := make([]*HNSWNode, 0, len(hnsw.nodes))
+	levels := make(map[string]int, len(hnsw.nodes))
+	for id, node := range hnsw.nodes {
+		nodes = append(nodes, node)
+		levels[id] = hnsw.topLevel(id)
+	}
+	// Re-insert in ID order so the rebuilt graph does not depend on map order
+	sort.Slice(nodes, func(i, j int) bool {
+		return nodes[i].ID < nodes[j].ID
+	})
+
+	for _, node := range nodes {
+		hnsw.deleteVector(node.ID)
+	}
+	hnsw.distance = dist
+	for _, node := range nodes {
+		hnsw.insertNode(node.ID, node.Vector, node.Metadata, levels[node.ID])
+		hnsw.nodes[node.ID].weight = node.weight
+	}
+	return nil
+}
@@ -0,0 +1,50 @@
+package gector
+
+import (
+	"fmt"
+	"testing"
+)
+
+// Test for ReindexWithMetric switching from L2 to cosine
+func TestReindexWithMetric(t *testing.T) {
+	hnswIndex := NewHNSW(3, 3)
+	// Same direction as the query but far away, and close but at an angle
+	hnswIndex.AddVectorWithMetadata("aligned", Vector{ID: "aligned", Values: []float64{10, 0}}, map[string]any{"kind": "aligned"})
+	hnswIndex.AddVector("angled", Vector{ID: "angled", Values: []float64{1, 1}})
+	for i := 0; i < 20; i++ {
+		id := fmt.Sprintf("vec-%d", i)
+		hnswIndex.AddVector(id, Vector{ID: id, Values: []float64{-float64(i) - 1, float64(i)}})
+	}
+	query := Vector{Values: []float64{1, 0}}
+
+	results, _ := hnswIndex.SearchWithStats(query, 1)
+	if results[0].ID != "angled" {
+		t.Fatalf("Expected angled nearest under L2, but got %s", results[0].ID)
+	}
+
+	levels := make(map[string]int)
+	for id := range hnswIndex.nodes {
+		levels[id] = hnswIndex.topLevel(id)
+	}
+
+	if err := hnswIndex.ReindexWithMetric(CosineDistance); err != nil {
+		t.Fatalf("Error reindexing: %v", err)
+	}
+	results, _ = hnswIndex.SearchWithStats(query, 1)
+	if results[0].ID != "aligned" || results[0].Distance != 0 {
+		t.Errorf("Expected aligned nearest under cosine, but got %v", results[0])
+	}
+
+	// IDs, metadata and levels are preserved
+	if len(hnswIndex.nodes) != 22 {
+		t.Errorf("Expected 22 vectors after reindexing, but got %d", len(hnswIndex.nodes))
+	}
+	if hnswIndex.nodes["aligned"].Metadata["kind"] != "aligned" {
+		t.Errorf("Expected metadata to survive reindexing")
+	}
+	for id, level := range levels {
+		if hnswIndex.topLevel(id) != level {
+			t.Errorf("Expected %s to stay on level %d, but got %d", id, level, hnswIndex.topLevel(id))
+		}
+	}
+}
@@ -0,0 +1,102 @@
+package gector
+
+// RelinkNode recomputes the neighbors of an existing node across the levels it
+// belongs to, and adds back-edges from its new neighbors to the node.
+func (hnsw *HNSW) RelinkNode(id string) error {
+	hnsw.mu.Lock()
+	defer hnsw.mu.Unlock()
+
+	if hnsw.frozen.Load() {
+		return ErrFrozen
+	}
+
+	node, exists := hnsw.nodes[id]
+	if !exists {
+		return notFoundError("relink", id)
+	}
+
+	hnsw.relinkNode(node)
+	return nil
+}
+
+// relinkNode recomputes a node's neighbors. The caller must hold the write lock.
+func (hnsw *HNSW) relinkNode(node *HNSWNode) {
+	id := node.ID
+
+	// Walk the levels in insertion order so the result matches AddVector
+	for level := hnsw.MaxLevels - 1; level >= 0; level-- {
+		if _, ok := hnsw.levels[level][id]; ok {
+			hnsw.setNeighbors(node, hnsw.findNeighbors(node, level))
+		}
+	}
+
+	// Make sure the new neighbors link back to the node
+	for _, neighborID := range node.Neighbors {
+		if neighbor, ok := hnsw.neighborNode(neighborID); ok {
+			hnsw.addBackEdge(neighbor, node)
+		}
+	}
+}
+
+// addBackEdge links from to node, replacing its farthest neighbor when full.
+func (hnsw *HNSW) addBackEdge(from, to *HNSWNode) {
+	for _, neighborID := range from.Neighbors {
+		if neighborID == to.ID {
+			return
+		}
+	}
+
+	if len(from.Neighbors) < hnsw.MaxNeighbors {
+		from.Neighbors = append(from.Neighbors, to.ID)
+		hnsw.linkIn(from, to.ID)
+		return
+	}
+
+	// Find the farthest existing neighbor (missing nodes count as infinitely far)
+	farthest := -1
+	farthestDist := hnsw.nodeDistance(from, to)
+	for i, neighborID := range from.Neighbors {
+		neighbor, ok := hnsw.neighborNode(neighborID)
+		if !ok {
+			farthest = i
+			break
+		}
+		if dist := hnsw.nodeDistance(from, neighbor); dist > farthestDist {
+			farthest = i
+			farthestDist = dist
+		}
+	}
+	if farthest >= 0 {
+		hnsw.unlinkIn(from, from.Neighbors[farthest])
+		hnsw.linkIn(from, to.ID)
+		from.Neighbors[farthest] = to.ID
+	}
+}
+
+// PruneNeighbors removes neighbor IDs that no longer exist in the index from
+// every neighbor list and returns how many were removed. Unlike RelinkNode it
+// does not look for replacement neighbors, so it is cheap but can leave nodes
+// with fewer neighbors.
+func (hnsw *HNSW) PruneNeighbors() (int, error) {
+	hnsw.mu.Lock()
+	defer hnsw.mu.Unlock()
+
+	if hnsw.frozen.Load() {
+		return 0, ErrFrozen
+	}
+
+	pruned := 0
+	for _, node := range hnsw.nodes {
+		kept := node.Neighbors[:0]
+		for _, neighborID := range node.Neighbors {
+			if _, exists := hnsw.nodes[neighborID]; exists {
+				kept = append(kept, neighborID)
+			} else {
+				hnsw.unlinkIn(node, neighborID)
+			}
+		}
+		pruned += len(node.Neighbors) - len(kept)
+		node.Neighbors = kept
+	}
+	return pruned, nil
+}
@@ -0,0 +1,91 @@
+package gector
+
+import (
+	"fmt"
+	"testing"
+)
+
+// Test for RelinkNode repairing a corrupted neighbor list
+func TestRelinkNode(t *testing.T) {
+	// A single level keeps the neighbor lists deterministic
+	hnswIndex := NewHNSW(2, 1)
+
+	// Points on a line: vec-i sits at x = i
+	for i := 0; i < 10; i++ {
+		id := fmt.Sprintf("vec-%d", i)
+		hnswIndex.AddVector(id, Vector{ID: id, Values: []float64{float64(i)}})
+	}
+
+	// Corrupt the links of vec-5 with far away and unknown nodes
+	node := hnswIndex.nodes["vec-5"]
+	node.Neighbors = []string{"vec-0", "missing"}
+
+	if err := hnswIndex.RelinkNode("vec-5"); err != nil {
+		t.Fatalf("Error relinking node: %v", err)
+	}
+
+	// The closest nodes to vec-5 are vec-4 and vec-6
+	if len(node.Neighbors) != 2 || node.Neighbors[0] != "vec-4" || node.Neighbors[1] != "vec-6" {
+		t.Fatalf("Expected neighbors [vec-4 vec-6] after relink, but got %v", node.Neighbors)
+	}
+
+	// Each new neighbor must link back to the relinked node
+	for _, neighborID := range node.Neighbors {
+		found := false
+		for _, id := range hnswIndex.nodes[neighborID].Neighbors {
+			if id == "vec-5" {
+				found = true
+			}
+		}
+		if !found {
+			t.Errorf("Expected '%s' to link back to 'vec-5', but got %v", neighborID, hnswIndex.nodes[neighborID].Neighbors)
+		}
+	}
+}
+
+// Test for RelinkNode with an unknown ID
+func TestRelinkNodeUnknown(t *testing.T) {
+	hnswIndex := NewHNSW(5, 4)
+
+	if err := hnswIndex.RelinkNode("missing"); err == nil {
+		t.Errorf("Expected an error relinking an unknown vector, but got nil")
+	}
+}
+
+// Test for PruneNeighbors removing references to deleted vectors
+func TestPruneNeighbors(t *testing.T) {
+	hnswIndex := NewHNSW(4, 3)
+	for i := 0; i < 50; i++ {
+		hnswIndex.AddVector(fmt.Sprintf("vec-%d", i), generateRandomVector(4))
+	}
+
+	dangling := func() int {
+		count := 0
+		for _, node := range hnswIndex.nodes {
+			for _, neighborID := range node.Neighbors {
+				if _, exists := hnswIndex.nodes[neighborID]; !exists {
+					count++
+				}
+			}
+		}
+		return count
+	}
+
+	for i := 0; i < 50; i += 3 {
+		hnswIndex.DeleteVector(fmt.Sprintf("vec-%d", i))
+	}
+	expected := dangling()
+	if expected == 0 {
+		t.Fatalf("Expected deletes to leave dangling neighbors")
+	}
+
+	if pruned, _ := hnswIndex.PruneNeighbors(); pruned != expected {
+		t.Errorf("Expected %d pruned neighbors, but got %d", expected, pruned)
+	}
+	if remaining := dangling(); remaining != 0 {
+		t.Errorf("Expected no dangling neighbors after pruning, but got %d", remaining)
+	}
+	if pruned, _ := hnswIndex.PruneNeighbors(); pruned != 0 {
+		t.Errorf("Expected nothing left to prune, but got %d", pruned)
+	}
+}
@@ -0,0 +1,74 @@
+package gector
+
+import "fmt"
+
+// RenameVector changes the ID of a stored vector while keeping its place in
+// the graph: its levels, neighbors, metadata and slot are unchanged, and
+// every neighbor list naming the old ID names the new one instead.
+func (hnsw *HNSW) RenameVector(oldID, newID string) error {
+	hnsw.mu.Lock()
+	defer hnsw.mu.Unlock()
+
+	if hnsw.frozen.Load() {
+		return ErrFrozen
+	}
+
+	if err := hnsw.renameVector(oldID, newID); err != nil {
+		return err
+	}
+	hnsw.recordRenameDelta(oldID, newID)
+	return nil
+}
+
+// renameVector moves a node to a new ID. The caller must hold the write lock.
+func (hnsw *HNSW) renameVector(oldID, newID string) error {
+	node, exists := hnsw.nodes[oldID]
+	if !exists {
+		return notFoundError("rename", oldID)
+	}
+	if _, exists := hnsw.nodes[newID]; exists {
+		return fmt.Errorf("rename: vector with id %s already exists", newID)
+	}
+	if newID == "" {
+		return fmt.Errorf("rename: vector without an id")
+	}
+
+	hnsw.invalidateCache()
+	node.ID = newID
+	if node.Vector.ID == oldID {
+		node.Vector.ID = newID
+	}
+	delete(hnsw.nodes, oldID)
+	hnsw.nodes[newID] = node
+	for level := 0; level < hnsw.MaxLevels; level++ {
+		if _, exists := hnsw.levels[level][oldID]; exists {
+			delete(hnsw.levels[level], oldID)
+			hnsw.levels[level][newID] = node
+		}
+	}
+
+	for _, other := range hnsw.nodes {
+		for i, neighborID := range other.Neighbors {
+			if neighborID == oldID {
+				other.Neighbors[i] = newID
+			}
+		}
+	}
+	if sources, ok := hnsw.inEdges[oldID]; ok {
+		delete(hnsw.inEdges, oldID)
+		for source, count := range sources {
+			for ; count > 0; count-- {
+				hnsw.linkIn(source, newID)
+			}
+		}
+	}
+	return nil
+}
+
+// recordRenameDelta records a RenameVector call. The caller must hold the write lock.
+func (hnsw *HNSW) recordRenameDelta(oldID, newID string) {
+	hnsw.recordDelta(DeltaRename, oldID, Vector{}, nil)
+	if hnsw.deltaLog {
+		hnsw.deltas[len(hnsw.deltas)-1].NewID = newID
+	}
+}
@@ -0,0 +1,95 @@
+package gector
+
+import (
+	"fmt"
+	"slices"
+	"testing"
+)
+
+// Test for RenameVector updating the maps and neighbor references
+func TestRenameVector(t *testing.T) {
+	hnswIndex := NewHNSW(5, 4)
+	for i := 0; i < 50; i++ {
+		hnswIndex.AddVector(fmt.Sprintf("vec-%d", i), generateRandomVector(5))
+	}
+
+	// Find a node that other nodes link to
+	var target string
+	for _, node := range hnswIndex.nodes {
+		if len(node.Neighbors) > 0 {
+			target = node.Neighbors[0]
+			break
+		}
+	}
+	old := hnswIndex.nodes[target]
+	neighbors := slices.Clone(old.Neighbors)
+	level := hnswIndex.topLevel(target)
+
+	if err := hnswIndex.RenameVector(target, "renamed"); err != nil {
+		t.Fatalf("Error renaming vector: %v", err)
+	}
+	if _, exists := hnswIndex.nodes[target]; exists {
+		t.Errorf("Expected %s to be gone after the rename", target)
+	}
+	node, exists := hnswIndex.nodes["renamed"]
+	if !exists || node != old {
+		t.Fatalf("Expected the node to be stored under the new ID")
+	}
+	if !slices.Equal(node.Neighbors, neighbors) {
+		t.Errorf("Expected neighbors %v to be kept, but got %v", neighbors, node.Neighbors)
+	}
+	if got := hnswIndex.topLevel("renamed"); got != level {
+		t.Errorf("Expected top level %d, but got %d", level, got)
+	}
+
+	referenced := false
+	for _, other := range hnswIndex.nodes {
+		if slices.Contains(other.Neighbors, target) {
+			t.Errorf("Expected no neighbor list to reference %s, but %s does", target, other.ID)
+		}
+		if slices.Contains(other.Neighbors, "renamed") {
+			referenced = true
+		}
+	}
+	if !referenced {
+		t.Errorf("Expected neighbor lists to reference the new ID")
+	}
+
+	results, _ := hnswIndex.SearchWithStats(node.Vector, 1)
+	if len(results) != 1 || results[0].ID != "renamed" {
+		t.Errorf("Expected a search to find the new ID, but got %v", results)
+	}
+
+	if err := hnswIndex.RenameVector(target, "other"); err == nil {
+		t.Errorf("Expected an error renaming a missing vector, but got nil")
+	}
+	existing := "vec-0"
+	if target == existing {
+		existing = "vec-1"
+	}
+	if err := hnswIndex.RenameVector("renamed", existing); err == nil {
+		t.Errorf("Expected an error renaming onto an existing ID, but got nil")
+	}
+}
+
+// Test for replaying a rename on a replica
+func TestRenameVectorDelta(t *testing.T) {
+	primary := NewHNSW(5, 4, WithSeed(1), WithDeltaLog())
+	replica := NewHNSW(5, 4, WithSeed(1))
+	for i := 0; i < 10; i++ {
+		primary.AddVector(fmt.Sprintf("vec-%d", i), generateRandomVector(5))
+	}
+	primary.RenameVector("vec-3", "renamed")
+
+	for _, delta := range primary.DeltaSince(0) {
+		if err := replica.Apply(delta); err != nil {
+			t.Fatalf("Error applying delta %d: %v", delta.Seq, err)
+		}
+	}
+	if _, exists := replica.GetVector("renamed"); !exists {
+		t.Errorf("Expected the replica to hold the renamed vector")
+	}
+	if !primary.StructurallyEqual(replica) {
+		t.Errorf("Expected the replica to match the primary")
+	}
+}
@@ -0,0 +1,149 @@
+package gector
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// centroidRecomputeInterval is the number of incremental centroid updates
+// after which the centroid is recomputed exactly, so rounding errors cannot
+// accumulate without bound.
+const centroidRecomputeInterval = 10000
+
+// centroidCache holds the centroid of the stored vectors. It is computed
+// exactly on first use, then updated in O(dim) on every insert and delete,
+// and recomputed exactly every centroidRecomputeInterval updates.
+type centroidCache struct {
+	// Guards the cache; searches share the index read lock, so the cache
+	// needs its own lock to fill it
+	mu       sync.Mutex
+	valid    bool
+	centroid Vector
+	// Number of vectors averaged into the centroid
+	count int
+	// Incremental updates since the last exact computation
+	updates int
+}
+
+// updateCentroid adds a vector to the cached centroid, or removes it, as a
+// running mean. Vectors whose length differs from the centroid are skipped,
+// as they are by the exact computation. The caller must hold the write lock.
+func (hnsw *HNSW) updateCentroid(v Vector, add bool) {
+	cache := &hnsw.centroid
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+
+	if !cache.valid {
+		return
+	}
+	// An empty centroid has no dimension to compare with, and emptying it
+	// is simplest done exactly
+	if cache.count == 0 || (!add && cache.count == 1) || cache.updates >= centroidRecomputeInterval {
+		cache.valid = false
+		return
+	}
+	if len(v.Values) != len(cache.centroid.Values) {
+		return
+	}
+
+	// mean' = mean + (v - mean) / (n + 1) on insert, and
+	// mean' = mean - (v - mean) / (n - 1) on delete
+	var scale float64
+	if add {
+		cache.count++
+		scale = 1 / float64(cache.count)
+	} else {
+		cache.count--
+		scale = -1 / float64(cache.count)
+	}
+	// Build a new slice rather than updating in place, since callers of
+	// cachedCentroid may still be reading the old one after unlocking
+	values := make([]float64, len(v.Values))
+	for i, value := range v.Values {
+		mean := cache.centroid.Values[i]
+		values[i] = mean + (value-mean)*scale
+	}
+	cache.centroid = Vector{Values: values}
+	cache.updates++
+}
+
+// cachedCentroid returns the centroid of the stored vectors, computing it
+// exactly when it is not cached, and false for an empty index. Vectors whose
+// length differs from the index dimension are skipped. The returned values
+// are never modified, so they may be read after the lock is released. The
+// caller must hold the lock.
+func (hnsw *HNSW) cachedCentroid() (Vector, bool) {
+	cache := &hnsw.centroid
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+
+	if !cache.valid {
+		centroid := Vector{Values: make([]float64, hnsw.dim)}
+		count := 0
+		for _, node := range hnsw.nodes {
+			if len(node.Vector.Values) != hnsw.dim {
+				continue
+			}
+			for i, value := range node.Vector.Values {
+				centroid.Values[i] += value
+			}
+			count++
+		}
+		if count == 0 {
+			centroid.Values = nil
+		}
+		for i := range centroid.Values {
+			centroid.Values[i] /= float64(count)
+		}
+		cache.centroid = centroid
+		cache.count = count
+		cache.updates = 0
+		cache.valid = true
+	}
+	return cache.centroid, cache.centroid.Values != nil
+}
+
+// NearestIndex returns the name of the index whose centroid is nearest to the
+// query under dist, for routing queries among many small indexes. A nil dist
+// uses EuclideanDistance. The query is whitened and projected like the
+// vectors of each index before it is compared with that index's centroid.
+// Centroids are cached by each index and kept up to date as it changes.
+// Empty indexes and indexes whose dimension the query does not match are
+// skipped, ties go to the first name in sorted order, and an error is
+// returned when no index is left.
+func NearestIndex(query Vector, indexes map[string]*HNSW, dist DistanceFunc) (string, error) {
+	if dist == nil {
+		dist = EuclideanDistance
+	}
+
+	names := make([]string, 0, len(indexes))
+	for name := range indexes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	best, bestDistance := "", 0.0
+	for _, name := range names {
+		index := indexes[name]
+		locked := index.readLock()
+		if index.checkDimension("route", query.ID, query) != nil {
+			index.readUnlock(locked)
+			continue
+		}
+		transformed := index.transformQuery(query)
+		centroid, ok := index.cachedCentroid()
+		index.readUnlock(locked)
+		if !ok {
+			continue
+		}
+
+		if distance := dist(transformed, centroid); best == "" || distance < bestDistance {
+			best, bestDistance = name, distance
+		}
+	}
+	if best == "" {
+		return "", fmt.Errorf("no non-empty index of the query dimension to route to")
+	}
+	return best, nil
+}
@@ -0,0 +1,164 @@
+package gector
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"sync"
+	"testing"
+)
+
+// Test for NearestIndex routing queries to the index of their cluster
+func TestNearestIndex(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	centers := map[string][]float64{
+		"north": {0, 100},
+		"east":  {100, 0},
+		"south": {0, -100},
+	}
+	indexes := make(map[string]*HNSW)
+	for name, center := range centers {
+		hnswIndex := NewHNSW(5, 3)
+		for i := 0; i < 50; i++ {
+			id := fmt.Sprintf("%s-%d", name, i)
+			hnswIndex.AddVector(id, Vector{ID: id, Values: []float64{center[0] + rng.NormFloat64()*5, center[1] + rng.NormFloat64()*5}})
+		}
+		indexes[name] = hnswIndex
+	}
+	indexes["empty"] = NewHNSW(5, 3)
+
+	for name, center := range centers {
+		query := Vector{Values: []float64{center[0] + 10, center[1] - 10}}
+		routed, err := NearestIndex(query, indexes, nil)
+		if err != nil {
+			t.Fatalf("Error routing query: %v", err)
+		}
+		if routed != name {
+			t.Errorf("Expected the query near %s to route to it, but got %s", name, routed)
+		}
+	}
+
+	// The cached centroid follows inserts and deletes
+	for i := 0; i < 200; i++ {
+		id := fmt.Sprintf("moved-%d", i)
+		indexes["east"].AddVector(id, Vector{ID: id, Values: []float64{-100, 0}})
+	}
+	if routed, _ := NearestIndex(Vector{Values: []float64{-60, 0}}, indexes, nil); routed != "east" {
+		t.Errorf("Expected routing to follow the moved centroid of east, but got %s", routed)
+	}
+	for i := 0; i < 200; i++ {
+		indexes["east"].DeleteVector(fmt.Sprintf("moved-%d", i))
+	}
+	if routed, _ := NearestIndex(Vector{Values: []float64{90, 0}}, indexes, nil); routed != "east" {
+		t.Errorf("Expected routing back to east after the deletes, but got %s", routed)
+	}
+
+	if _, err := NearestIndex(Vector{Values: []float64{0, 0}}, map[string]*HNSW{"empty": NewHNSW(5, 3)}, nil); err == nil {
+		t.Errorf("Expected an error when every index is empty, but got nil")
+	}
+}
+
+// Test for the incremental centroid staying close to the exact mean
+func TestIncrementalCentroid(t *testing.T) {
+	rng := rand.New(rand.NewSource(2))
+	hnswIndex := NewHNSW(5, 3)
+	add := func(i int) {
+		id := fmt.Sprintf("v%d", i)
+		hnswIndex.AddVector(id, Vector{ID: id, Values: []float64{rng.NormFloat64() * 10, rng.NormFloat64()*10 + 50, rng.Float64()}})
+	}
+	for i := 0; i < 20; i++ {
+		add(i)
+	}
+	hnswIndex.cachedCentroid()
+
+	for i := 20; i < 500; i++ {
+		add(i)
+		if i%3 == 0 {
+			hnswIndex.DeleteVector(fmt.Sprintf("v%d", i-10))
+		}
+	}
+	if !hnswIndex.centroid.valid {
+		t.Fatalf("Expected the centroid to stay cached across inserts and deletes")
+	}
+	if hnswIndex.centroid.updates == 0 {
+		t.Errorf("Expected incremental updates to be counted, but got 0")
+	}
+	incremental, _ := hnswIndex.cachedCentroid()
+	exact, _ := hnswIndex.Spread()
+	for i := range exact.Values {
+		if math.Abs(incremental.Values[i]-exact.Values[i]) > 1e-9 {
+			t.Errorf("Expected the incremental centroid %v to match the exact one %v", incremental.Values, exact.Values)
+			break
+		}
+	}
+
+	// Reaching the recompute interval drops the cache for an exact pass
+	hnswIndex.centroid.updates = centroidRecomputeInterval
+	add(1000)
+	if hnswIndex.centroid.valid {
+		t.Errorf("Expected the centroid to be recomputed after %d updates", centroidRecomputeInterval)
+	}
+	hnswIndex.cachedCentroid()
+	if hnswIndex.centroid.updates != 0 {
+		t.Errorf("Expected the exact recompute to reset the update count, but got %d", hnswIndex.centroid.updates)
+	}
+}
+
+// Test for NearestIndex racing with inserts, meant to run with -race
+func TestNearestIndexConcurrentInserts(t *testing.T) {
+	hnswIndex := NewHNSW(5, 3)
+	for i := 0; i < 20; i++ {
+		hnswIndex.AddVector(fmt.Sprintf("v%d", i), generateRandomVector(4))
+	}
+	indexes := map[string]*HNSW{"only": hnswIndex}
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 20; i < 300; i++ {
+			hnswIndex.AddVector(fmt.Sprintf("v%d", i), generateRandomVector(4))
+		}
+	}()
+	for i := 0; i < 300; i++ {
+		if routed, err := NearestIndex(generateRandomVector(4), indexes, nil); err != nil || routed != "only" {
+			t.Fatalf("Expected routing to the only index, but got %q, %v", routed, err)
+		}
+	}
+	wg.Wait()
+}
+
+// Test for NearestIndex projecting the query like each index
+func TestNearestIndexProjected(t *testing.T) {
+	rng := rand.New(rand.NewSource(3))
+	indexes := map[string]*HNSW{
+		"low":  NewHNSW(5, 3, WithRandomProjection(4, 1)),
+		"high": NewHNSW(5, 3, WithRandomProjection(4, 1)),
+	}
+	point := func(offset float64) Vector {
+		values := make([]float64, 16)
+		for i := range values {
+			values[i] = offset + rng.NormFloat64()
+		}
+		return Vector{Values: values}
+	}
+	for i := 0; i < 30; i++ {
+		indexes["low"].AddVector(fmt.Sprintf("low-%d", i), point(-50))
+		indexes["high"].AddVector(fmt.Sprintf("high-%d", i), point(50))
+	}
+
+	if routed, err := NearestIndex(point(45), indexes, nil); err != nil || routed != "high" {
+		t.Errorf("Expected a projected query to route to high, but got %q, %v", routed, err)
+	}
+
+	// An index of another dimension is skipped rather than compared
+	plain := NewHNSW(5, 3)
+	plain.AddVector("three", Vector{ID: "three", Values: []float64{1, 2, 3}})
+	indexes["plain"] = plain
+	if routed, err := NearestIndex(point(-45), indexes, nil); err != nil || routed != "low" {
+		t.Errorf("Expected the mismatched index to be skipped, but got %q, %v", routed, err)
+	}
+	if _, err := NearestIndex(Vector{Values: []float64{1, 2}}, indexes, nil); err == nil {
+		t.Errorf("Expected an error when no index matches the query dimension, but got nil")
+	}
+}
@@ -0,0 +1,43 @@
+package gector
+
+import "math/rand"
+
+// Sample returns a uniform random sample of up to n stored vectors using
+// reservoir sampling. Nodes are visited in slot order rather than map order,
+// so the same rng seed over the same index yields the same sample. A nil rng
+// uses the global source.
+func (hnsw *HNSW) Sample(n int, rng *rand.Rand) []Vector {
+	defer hnsw.readUnlock(hnsw.readLock())
+
+	if n <= 0 {
+		return nil
+	}
+	intn := rand.Intn
+	if rng != nil {
+		intn = rng.Intn
+	}
+
+	reservoir := make([]Vector, 0, n)
+	seen := 0
+	for _, node := range hnsw.slots {
+		if node == nil {
+			continue
+		}
+		if seen < n {
+			reservoir = append(reservoir, node.Vector)
+		} else if j := intn(seen + 1); j < n {
+			reservoir[j] = node.Vector
+		}
+		seen++
+	}
+	return reservoir
+}
+
+// sampleIntn returns a random number in [0, n) from the index's sampling
+// source. The caller must hold rngMu.
+func (hnsw *HNSW) sampleIntn(n int) int {
+	if hnsw.sampleRng != nil {
+		return hnsw.sampleRng.Intn(n)
+	}
+	return rand.Intn(n)
+}
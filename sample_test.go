@@ -0,0 +1,49 @@
+package gector
+
+import (
+	"fmt"
+	"math/rand"
+	"testing"
+)
+
+// Test for Sample returning reproducible, roughly uniform samples
+func TestSample(t *testing.T) {
+	hnswIndex := NewHNSW(5, 4)
+	for i := 0; i < 100; i++ {
+		id := fmt.Sprintf("vec-%d", i)
+		hnswIndex.AddVector(id, Vector{ID: id, Values: []float64{float64(i)}})
+	}
+
+	if sample := hnswIndex.Sample(10, rand.New(rand.NewSource(1))); len(sample) != 10 {
+		t.Fatalf("Expected a sample of 10, but got %d", len(sample))
+	}
+	if sample := hnswIndex.Sample(500, rand.New(rand.NewSource(1))); len(sample) != 100 {
+		t.Errorf("Expected the whole index when sampling more than it holds, but got %d", len(sample))
+	}
+
+	// The same seed gives the same sample
+	first := hnswIndex.Sample(10, rand.New(rand.NewSource(7)))
+	second := hnswIndex.Sample(10, rand.New(rand.NewSource(7)))
+	for i := range first {
+		if first[i].ID != second[i].ID {
+			t.Fatalf("Expected identical samples for the same seed")
+		}
+	}
+
+	// Every vector is picked about equally often
+	rng := rand.New(rand.NewSource(42))
+	counts := make(map[string]int)
+	const trials = 2000
+	for i := 0; i < trials; i++ {
+		for _, vector := range hnswIndex.Sample(10, rng) {
+			counts[vector.ID]++
+		}
+	}
+	for i := 0; i < 100; i++ {
+		id := fmt.Sprintf("vec-%d", i)
+		// Expected count is trials * 10 / 100 = 200
+		if counts[id] < 120 || counts[id] > 280 {
+			t.Errorf("Expected '%s' to be sampled about 200 times, but got %d", id, counts[id])
+		}
+	}
+}
@@ -0,0 +1,451 @@
+package gector
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"time"
+)
+
+// anytimeCheckInterval is how many nodes SearchAnytime and SearchContext
+// score between checks of their deadline.
+const anytimeCheckInterval = 64
+
+// combinedCandidateFactor is how many candidates per requested result
+// SearchCombined retrieves before re-ranking.
+const combinedCandidateFactor = 10
+
+// diverseCandidateFactor is how many candidates per requested result
+// SearchDiverse retrieves before diversifying.
+const diverseCandidateFactor = 10
+
+// SearchByID returns the k nearest neighbors of an already stored vector,
+// excluding the vector itself from the results.
+func (hnsw *HNSW) SearchByID(id string, k int) ([]SearchResult, error) {
+	defer hnsw.readUnlock(hnsw.readLock())
+
+	node, exists := hnsw.nodes[id]
+	if !exists {
+		return nil, notFoundError("search", id)
+	}
+
+	// Ask for one extra result to make up for dropping the query itself
+	results := hnsw.search(node.Vector, k+1)
+	filtered := results[:0]
+	for _, result := range results {
+		if result.ID != id {
+			filtered = append(filtered, result)
+		}
+	}
+
+	if len(filtered) > k {
+		filtered = filtered[:k]
+	}
+	return hnsw.processResults(filtered), nil
+}
+
+// SearchAnytime returns the best k results found within the time budget. The
+// clock is checked periodically while scanning, and once the budget is spent
+// the best results so far are returned. At least one node is always scored,
+// so the results are never empty for a non-empty index.
+func (hnsw *HNSW) SearchAnytime(query Vector, k int, budget time.Duration) []SearchResult {
+	defer hnsw.readUnlock(hnsw.readLock())
+
+	if hnsw.checkDimension("search", query.ID, query) != nil {
+		return nil
+	}
+
+	deadline := time.Now().Add(budget)
+	results := hnsw.scanUntil(hnsw.transformQuery(query), k, func() bool {
+		return time.Now().After(deadline)
+	})
+	hnsw.touch(results)
+	return hnsw.processResults(results)
+}
+
+// SearchContext returns the k nearest neighbors to the query, checking ctx
+// periodically while scanning. When ctx is done before the scan completes,
+// the best results found so far are returned together with ctx.Err(), so
+// callers can choose to use them; such partial results only cover part of
+// the index and may have lower recall. At least one node is always scored.
+func (hnsw *HNSW) SearchContext(ctx context.Context, query Vector, k int) ([]SearchResult, error) {
+	defer hnsw.readUnlock(hnsw.readLock())
+
+	if err := hnsw.checkDimension("search", query.ID, query); err != nil {
+		return nil, err
+	}
+
+	var err error
+	results := hnsw.scanUntil(hnsw.transformQuery(query), k, func() bool {
+		err = ctx.Err()
+		return err != nil
+	})
+	hnsw.touch(results)
+	return hnsw.processResults(results), err
+}
+
+// scanUntil scores nodes for the k nearest neighbors until every node has been
+// scored or stop returns true. stop is called every anytimeCheckInterval
+// nodes, after at least one node has been scored. The query must already be
+// transformed. The caller must hold the lock.
+func (hnsw *HNSW) scanUntil(query Vector, k int, stop func() bool) []SearchResult {
+	if k <= 0 {
+		return nil
+	}
+
+	queryInvNorm := inverseNorm(vectorNorm(query))
+	best := make(resultHeap, 0, k)
+	scanned := 0
+	for id, node := range hnsw.nodes {
+		if scanned > 0 && scanned%anytimeCheckInterval == 0 && stop() {
+			break
+		}
+		scanned++
+
+		best.offer(SearchResult{
+			ID:       id,
+			Vector:   node.Vector,
+			Distance: hnsw.queryDistance(query, queryInvNorm, node),
+			Metadata: hnsw.resultMetadata(node),
+		}, k)
+	}
+
+	return best.sorted()
+}
+
+// SearchNodes returns the nodes of the k nearest neighbors to the query without
+// copying their vectors. The returned nodes are shared with the index and must
+// be treated as read-only; mutating them is undefined behavior.
+func (hnsw *HNSW) SearchNodes(query Vector, k int) []*HNSWNode {
+	defer hnsw.readUnlock(hnsw.readLock())
+
+	if hnsw.checkDimension("search", query.ID, query) != nil {
+		return nil
+	}
+
+	results := hnsw.processResults(hnsw.search(hnsw.transformQuery(query), k))
+
+	nodes := make([]*HNSWNode, len(results))
+	for i, result := range results {
+		nodes[i] = hnsw.nodes[result.ID]
+	}
+	return nodes
+}
+
+// SearchMulti searches with the weighted average of several query vectors.
+// Weights must be non-negative with a positive sum and are normalized, so the
+// blend lies between the queries; results are then ranked against the blend.
+func (hnsw *HNSW) SearchMulti(queries []Vector, weights []float64, k int) ([]SearchResult, error) {
+	defer hnsw.readUnlock(hnsw.readLock())
+
+	if len(queries) == 0 {
+		return nil, fmt.Errorf("no query vectors given")
+	}
+	if len(weights) != len(queries) {
+		return nil, fmt.Errorf("got %d weights for %d query vectors", len(weights), len(queries))
+	}
+
+	dim := len(queries[0].Values)
+	var total float64
+	for i, query := range queries {
+		if len(query.Values) != dim {
+			return nil, fmt.Errorf("query vector %d has dimension %d, expected %d", i, len(query.Values), dim)
+		}
+		if err := hnsw.checkDimension("search", query.ID, query); err != nil {
+			return nil, err
+		}
+		if weights[i] < 0 {
+			return nil, fmt.Errorf("weight %d is negative: %f", i, weights[i])
+		}
+		total += weights[i]
+	}
+	if total == 0 {
+		return nil, fmt.Errorf("weights sum to zero")
+	}
+
+	blend := Vector{Values: make([]float64, dim)}
+	for i, query := range queries {
+		scale := weights[i] / total
+		for j, value := range query.Values {
+			blend.Values[j] += value * scale
+		}
+	}
+
+	return hnsw.processResults(hnsw.search(hnsw.transformQuery(blend), k)), nil
+}
+
+// SearchAdaptive retrieves up to kMax results and keeps only those at or below
+// the distance found at the given quantile of the retrieved distances, using
+// the nearest-rank method. The quantile is clamped to [0, 1].
+func (hnsw *HNSW) SearchAdaptive(query Vector, kMax int, quantile float64) []SearchResult {
+	defer hnsw.readUnlock(hnsw.readLock())
+
+	if hnsw.checkDimension("search", query.ID, query) != nil {
+		return nil
+	}
+
+	results := hnsw.search(hnsw.transformQuery(query), kMax)
+	if len(results) == 0 {
+		return results
+	}
+
+	quantile = math.Max(0, math.Min(1, quantile))
+	rank := int(math.Ceil(quantile*float64(len(results)))) - 1
+	if rank < 0 {
+		rank = 0
+	}
+	threshold := results[rank].Distance
+
+	// Results are sorted, so cut at the first one beyond the threshold
+	cut := len(results)
+	for i, result := range results {
+		if result.Distance > threshold {
+			cut = i
+			break
+		}
+	}
+	return hnsw.processResults(results[:cut])
+}
+
+// SearchInto writes the k nearest neighbors to the query into buf and returns
+// it, growing it only when its capacity is below k. The returned slice shares
+// buf's backing array whenever it fits, so the previous contents of buf are
+// overwritten and buf should be replaced by the returned slice, as with append.
+func (hnsw *HNSW) SearchInto(query Vector, k int, buf []SearchResult) []SearchResult {
+	defer hnsw.readUnlock(hnsw.readLock())
+
+	if hnsw.checkDimension("search", query.ID, query) != nil {
+		return buf[:0]
+	}
+
+	results, _ := hnsw.scanInto(hnsw.transformQuery(query), k, buf)
+	hnsw.touch(results)
+	return hnsw.processResults(results)
+}
+
+// SearchWithMetric retrieves the k nearest neighbors under the index metric
+// and re-ranks them by the given distance function. Only the final ranking
+// and the reported distances use dist; which candidates are retrieved is
+// still decided by the metric the index was built with.
+func (hnsw *HNSW) SearchWithMetric(query Vector, k int, dist DistanceFunc) []SearchResult {
+	defer hnsw.readUnlock(hnsw.readLock())
+
+	if hnsw.checkDimension("search", query.ID, query) != nil {
+		return nil
+	}
+
+	query = hnsw.transformQuery(query)
+	results := hnsw.search(query, k)
+	for i := range results {
+		results[i].Distance = dist(query, results[i].Vector)
+	}
+	sortResults(results)
+	return hnsw.processResults(results)
+}
+
+// SearchCombined retrieves k*combinedCandidateFactor candidates under the
+// index metric and ranks them by the weighted sum of their distances under
+// each of the metrics, which is also the reported distance. The metrics see
+// the query and the stored vectors after any transforms of the index.
+func (hnsw *HNSW) SearchCombined(query Vector, k int, metrics []DistanceFunc, weights []float64) ([]SearchResult, error) {
+	defer hnsw.readUnlock(hnsw.readLock())
+
+	if err := hnsw.checkDimension("search", query.ID, query); err != nil {
+		return nil, err
+	}
+
+	if len(metrics) == 0 {
+		return nil, fmt.Errorf("no metrics given")
+	}
+	if len(weights) != len(metrics) {
+		return nil, fmt.Errorf("got %d weights for %d metrics", len(weights), len(metrics))
+	}
+	if k <= 0 {
+		return nil, nil
+	}
+
+	query = hnsw.transformQuery(query)
+	results := hnsw.search(query, k*combinedCandidateFactor)
+	for i := range results {
+		var score float64
+		for j, metric := range metrics {
+			score += weights[j] * metric(query, results[i].Vector)
+		}
+		results[i].Distance = score
+	}
+	sortResults(results)
+
+	if len(results) > k {
+		results = results[:k]
+	}
+	return hnsw.processResults(results), nil
+}
+
+// SearchRerankFunc retrieves the kRetrieve nearest neighbors under the index
+// metric, scores each with score, and returns the kFinal with the lowest
+// score, which is also the reported distance; negate a relevance score where
+// higher is better. score gets the transformed query, the candidate with its
+// distance under the index metric, and the candidate's metadata, whether or
+// not the index includes metadata in results. It generalizes the exact
+// re-ranking of SearchWithMetric to scores beyond a distance.
+func (hnsw *HNSW) SearchRerankFunc(query Vector, kRetrieve, kFinal int, score func(query Vector, r SearchResult, meta map[string]any) float64) []SearchResult {
+	defer hnsw.readUnlock(hnsw.readLock())
+
+	if hnsw.checkDimension("search", query.ID, query) != nil || kFinal <= 0 {
+		return nil
+	}
+
+	query = hnsw.transformQuery(query)
+	results := hnsw.search(query, kRetrieve)
+	for i := range results {
+		results[i].Distance = score(query, results[i], hnsw.nodes[results[i].ID].Metadata)
+	}
+	sortResults(results)
+
+	if len(results) > kFinal {
+		results = results[:kFinal]
+	}
+	return hnsw.processResults(results)
+}
+
+// SearchExactTop1 returns k results of which the first is guaranteed to be
+// the exact nearest neighbor, while the rest come from the regular search
+// and are as approximate as it is (see WithMaxExpansionsPerLevel and
+// WithQuantizer). The guarantee costs one brute-force scan over every stored
+// vector on top of the search.
+func (hnsw *HNSW) SearchExactTop1(query Vector, k int) []SearchResult {
+	defer hnsw.readUnlock(hnsw.readLock())
+
+	if hnsw.checkDimension("search", query.ID, query) != nil || k <= 0 {
+		return nil
+	}
+
+	query = hnsw.transformQuery(query)
+	results := hnsw.search(query, k)
+	exact := hnsw.bruteForce(query, 1)
+	if len(exact) == 0 {
+		return hnsw.processResults(results)
+	}
+
+	top := make([]SearchResult, 0, k)
+	top = append(top, exact[0])
+	for _, result := range results {
+		if len(top) == k {
+			break
+		}
+		if result.ID != exact[0].ID {
+			top = append(top, result)
+		}
+	}
+	return hnsw.processResults(top)
+}
+
+// SearchDiverse returns up to k results in which every pair is at least
+// minDistance apart. It retrieves k*diverseCandidateFactor candidates and
+// greedily keeps each one, nearest first, that is far enough from all the
+// results kept so far, so fewer than k results are returned when the
+// candidates are too clustered.
+func (hnsw *HNSW) SearchDiverse(query Vector, k int, minDistance float64) []SearchResult {
+	defer hnsw.readUnlock(hnsw.readLock())
+
+	if hnsw.checkDimension("search", query.ID, query) != nil {
+		return nil
+	}
+
+	candidates := hnsw.search(hnsw.transformQuery(query), k*diverseCandidateFactor)
+	selected := make([]SearchResult, 0, k)
+	for _, candidate := range candidates {
+		if len(selected) == k {
+			break
+		}
+
+		diverse := true
+		for _, result := range selected {
+			if hnsw.nodeDistance(hnsw.nodes[candidate.ID], hnsw.nodes[result.ID]) < minDistance {
+				diverse = false
+				break
+			}
+		}
+		if diverse {
+			selected = append(selected, candidate)
+		}
+	}
+	return hnsw.processResults(selected)
+}
+
+// SearchLevel returns the k nearest neighbors to the query among the nodes on
+// the given level only. Level 0 is the sparsest level and MaxLevels-1 holds
+// every node.
+func (hnsw *HNSW) SearchLevel(query Vector, k, level int) ([]SearchResult, error) {
+	defer hnsw.readUnlock(hnsw.readLock())
+
+	if err := hnsw.checkDimension("search", query.ID, query); err != nil {
+		return nil, err
+	}
+
+	if level < 0 || level >= hnsw.MaxLevels {
+		return nil, fmt.Errorf("level %d out of range [0, %d)", level, hnsw.MaxLevels)
+	}
+	if k <= 0 {
+		return nil, nil
+	}
+
+	query = hnsw.transformQuery(query)
+	queryInvNorm := inverseNorm(vectorNorm(query))
+	best := make(resultHeap, 0, k)
+	for id, node := range hnsw.levels[level] {
+		best.offer(SearchResult{
+			ID:       id,
+			Vector:   node.Vector,
+			Distance: hnsw.queryDistance(query, queryInvNorm, node),
+			Metadata: hnsw.resultMetadata(node),
+		}, k)
+	}
+	return hnsw.processResults(best.sorted()), nil
+}
+
+// SearchRecall returns k results expected to contain about targetRecall of
+// the true k nearest neighbors, scoring fewer vectors for lower targets. The
+// vectors are visited in arbitrary order and the scan stops after
+// ceil(targetRecall * n) of the n vectors, so each true neighbor is found
+// with probability about targetRecall. This is best-effort: the target holds
+// on average over queries, not for every query. A target of 1 or more is an
+// exact search.
+func (hnsw *HNSW) SearchRecall(query Vector, k int, targetRecall float64) []SearchResult {
+	defer hnsw.readUnlock(hnsw.readLock())
+
+	if hnsw.checkDimension("search", query.ID, query) != nil {
+		return nil
+	}
+
+	query = hnsw.transformQuery(query)
+	if targetRecall >= 1 {
+		return hnsw.processResults(hnsw.search(query, k))
+	}
+	if k <= 0 {
+		return nil
+	}
+
+	// Always score at least k vectors so the results are never short
+	budget := max(int(math.Ceil(targetRecall*float64(len(hnsw.nodes)))), k)
+	queryInvNorm := inverseNorm(vectorNorm(query))
+	best := make(resultHeap, 0, k)
+	scanned := 0
+	for id, node := range hnsw.nodes {
+		if scanned == budget {
+			break
+		}
+		scanned++
+
+		best.offer(SearchResult{
+			ID:       id,
+			Vector:   node.Vector,
+			Distance: hnsw.queryDistance(query, queryInvNorm, node),
+			Metadata: hnsw.resultMetadata(node),
+		}, k)
+	}
+
+	results := best.sorted()
+	hnsw.touch(results)
+	return hnsw.processResults(results)
+}
@@ -0,0 +1,502 @@
+package gector
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"testing"
+	"time"
+)
+
+// Test for SearchByID excluding the query vector itself
+func TestSearchByID(t *testing.T) {
+	hnswIndex := NewHNSW(5, 4)
+
+	for i := 0; i < 10; i++ {
+		id := fmt.Sprintf("vec-%d", i)
+		hnswIndex.AddVector(id, Vector{ID: id, Values: []float64{float64(i), 0}})
+	}
+
+	results, err := hnswIndex.SearchByID("vec-5", 3)
+	if err != nil {
+		t.Fatalf("Error searching by ID: %v", err)
+	}
+	if len(results) != 3 {
+		t.Fatalf("Expected 3 results, but got %d", len(results))
+	}
+	for _, result := range results {
+		if result.ID == "vec-5" {
+			t.Errorf("Expected the query vector 'vec-5' to be excluded from the results")
+		}
+	}
+	if results[0].ID != "vec-4" || results[1].ID != "vec-6" {
+		t.Errorf("Expected the closest results to be 'vec-4' and 'vec-6', but got '%s' and '%s'", results[0].ID, results[1].ID)
+	}
+}
+
+// Test for SearchByID with an unknown ID
+func TestSearchByIDUnknown(t *testing.T) {
+	hnswIndex := NewHNSW(5, 4)
+
+	if _, err := hnswIndex.SearchByID("missing", 3); err == nil {
+		t.Errorf("Expected an error searching by an unknown ID, but got nil")
+	}
+}
+
+// Test for SearchAnytime returning promptly with a tiny budget
+func TestSearchAnytime(t *testing.T) {
+	hnswIndex := NewHNSW(5, 4)
+	for i := 0; i < 1000; i++ {
+		hnswIndex.AddVector(fmt.Sprintf("vec-%d", i), generateRandomVector(16))
+	}
+	query := generateRandomVector(16)
+
+	// Count the nodes scored by each search
+	scored := 0
+	hnswIndex.distance = func(a, b Vector) float64 {
+		scored++
+		return EuclideanDistance(a, b)
+	}
+
+	// A tiny budget stops at the first deadline check and still returns
+	// valid best-so-far results
+	results := hnswIndex.SearchAnytime(query, 5, time.Nanosecond)
+	if len(results) == 0 || len(results) > 5 {
+		t.Fatalf("Expected between 1 and 5 results with a tiny budget, but got %d", len(results))
+	}
+	if scored != anytimeCheckInterval {
+		t.Errorf("Expected the tiny budget to stop after %d of %d nodes, but got %d", anytimeCheckInterval, hnswIndex.Len(), scored)
+	}
+	for _, result := range results {
+		if stored, exists := hnswIndex.GetVector(result.ID); !exists || result.Distance != EuclideanDistance(query, stored) {
+			t.Errorf("Expected %s to be a stored vector at its true distance", result.ID)
+		}
+	}
+	for i := 1; i < len(results); i++ {
+		if results[i-1].Distance > results[i].Distance {
+			t.Errorf("Expected results to be sorted by distance")
+		}
+	}
+
+	// A generous budget scores every node and matches the full search
+	exact := hnswIndex.search(query, 5)
+	scored = 0
+	results = hnswIndex.SearchAnytime(query, 5, time.Minute)
+	if scored != hnswIndex.Len() {
+		t.Errorf("Expected a generous budget to score all %d nodes, but got %d", hnswIndex.Len(), scored)
+	}
+	for i := range exact {
+		if results[i].ID != exact[i].ID {
+			t.Errorf("Expected result %d to be '%s', but got '%s'", i, exact[i].ID, results[i].ID)
+		}
+	}
+
+	// Results count as search hits
+	for _, result := range results {
+		if hits := hnswIndex.nodes[result.ID].accessCount.Load(); hits == 0 {
+			t.Errorf("Expected %s to be counted as a search hit", result.ID)
+		}
+	}
+}
+
+// Test for SearchNodes returning the nodes of the nearest vectors
+func TestSearchNodes(t *testing.T) {
+	hnswIndex := NewHNSW(5, 4)
+	for i := 0; i < 50; i++ {
+		hnswIndex.AddVector(fmt.Sprintf("vec-%d", i), generateRandomVector(5))
+	}
+
+	query := generateRandomVector(5)
+	nodes := hnswIndex.SearchNodes(query, 5)
+	results := hnswIndex.search(query, 5)
+
+	if len(nodes) != len(results) {
+		t.Fatalf("Expected %d nodes, but got %d", len(results), len(nodes))
+	}
+	for i, node := range nodes {
+		if node.ID != results[i].ID {
+			t.Errorf("Expected node %d to be '%s', but got '%s'", i, results[i].ID, node.ID)
+		}
+		if node != hnswIndex.nodes[node.ID] {
+			t.Errorf("Expected node '%s' to be the stored node, not a copy", node.ID)
+		}
+	}
+}
+
+// Test for SearchMulti with two equal-weight queries
+func TestSearchMulti(t *testing.T) {
+	hnswIndex := NewHNSW(5, 4)
+	for i := 0; i <= 10; i++ {
+		id := fmt.Sprintf("vec-%d", i)
+		hnswIndex.AddVector(id, Vector{ID: id, Values: []float64{float64(i), 0}})
+	}
+
+	queries := []Vector{
+		{Values: []float64{0, 0}},
+		{Values: []float64{10, 0}},
+	}
+
+	// The blend of both queries sits halfway at x = 5
+	results, err := hnswIndex.SearchMulti(queries, []float64{1, 1}, 1)
+	if err != nil {
+		t.Fatalf("Error searching with multiple queries: %v", err)
+	}
+	if len(results) != 1 || results[0].ID != "vec-5" {
+		t.Errorf("Expected the blended query to match 'vec-5', but got %v", results)
+	}
+
+	// Mismatched weights and dimensions are rejected
+	if _, err := hnswIndex.SearchMulti(queries, []float64{1}, 1); err == nil {
+		t.Errorf("Expected an error for mismatched weights, but got nil")
+	}
+	if _, err := hnswIndex.SearchMulti([]Vector{{Values: []float64{0}}, {Values: []float64{1, 2}}}, []float64{1, 1}, 1); err == nil {
+		t.Errorf("Expected an error for mismatched dimensions, but got nil")
+	}
+	if _, err := hnswIndex.SearchMulti(queries, []float64{0, 0}, 1); err == nil {
+		t.Errorf("Expected an error for zero weights, but got nil")
+	}
+}
+
+// Test for SearchAdaptive on a bimodal distance distribution
+func TestSearchAdaptive(t *testing.T) {
+	hnswIndex := NewHNSW(5, 4)
+
+	// Five vectors close to the origin and fifteen far away
+	for i := 0; i < 5; i++ {
+		id := fmt.Sprintf("near-%d", i)
+		hnswIndex.AddVector(id, Vector{ID: id, Values: []float64{1 + float64(i)*0.01, 0}})
+	}
+	for i := 0; i < 15; i++ {
+		id := fmt.Sprintf("far-%d", i)
+		hnswIndex.AddVector(id, Vector{ID: id, Values: []float64{100 + float64(i), 0}})
+	}
+
+	query := Vector{Values: []float64{0, 0}}
+	results := hnswIndex.SearchAdaptive(query, 20, 0.25)
+	if len(results) != 5 {
+		t.Fatalf("Expected the 5 near vectors at the 25th percentile, but got %d results", len(results))
+	}
+	for _, result := range results {
+		if result.Distance > 2 {
+			t.Errorf("Expected only near vectors, but got '%s' at distance %f", result.ID, result.Distance)
+		}
+	}
+
+	// The full quantile keeps everything retrieved
+	if results := hnswIndex.SearchAdaptive(query, 20, 1); len(results) != 20 {
+		t.Errorf("Expected 20 results at the 100th percentile, but got %d", len(results))
+	}
+}
+
+// Test for SearchInto reusing the caller's buffer
+func TestSearchInto(t *testing.T) {
+	hnswIndex := NewHNSW(5, 4)
+	for i := 0; i < 50; i++ {
+		hnswIndex.AddVector(fmt.Sprintf("vec-%d", i), generateRandomVector(5))
+	}
+	query := generateRandomVector(5)
+
+	buf := make([]SearchResult, 0, 10)
+	results := hnswIndex.SearchInto(query, 5, buf)
+	if len(results) != 5 {
+		t.Fatalf("Expected 5 results, but got %d", len(results))
+	}
+	if &results[0] != &buf[:1][0] {
+		t.Errorf("Expected the results to share the caller's buffer")
+	}
+
+	exact := hnswIndex.search(query, 5)
+	for i := range exact {
+		if results[i].ID != exact[i].ID {
+			t.Errorf("Expected result %d to be '%s', but got '%s'", i, exact[i].ID, results[i].ID)
+		}
+	}
+}
+
+// Benchmark SearchInto with a reused buffer
+func BenchmarkSearchInto(b *testing.B) {
+	hnswIndex := NewHNSW(5, 4)
+	for i := 0; i < 1000; i++ {
+		hnswIndex.AddVector(fmt.Sprintf("vec-%d", i), generateRandomVector(16))
+	}
+	query := generateRandomVector(16)
+	buf := make([]SearchResult, 0, 10)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		buf = hnswIndex.SearchInto(query, 10, buf)
+	}
+}
+
+// Test for SearchWithMetric re-ranking candidates under a different metric
+func TestSearchWithMetric(t *testing.T) {
+	hnswIndex := NewHNSW(5, 4)
+	hnswIndex.AddVector("same-direction", Vector{ID: "same-direction", Values: []float64{2, 0}})
+	hnswIndex.AddVector("closer", Vector{ID: "closer", Values: []float64{1, 0.9}})
+
+	query := Vector{Values: []float64{1, 0}}
+
+	// Under L2 the closer point ranks first
+	results, _ := hnswIndex.SearchWithStats(query, 2)
+	if results[0].ID != "closer" {
+		t.Fatalf("Expected 'closer' to rank first under L2, but got '%s'", results[0].ID)
+	}
+
+	// Under cosine the point in the same direction ranks first
+	results = hnswIndex.SearchWithMetric(query, 2, CosineDistance)
+	if len(results) != 2 || results[0].ID != "same-direction" {
+		t.Fatalf("Expected 'same-direction' to rank first under cosine, but got %v", results)
+	}
+	if results[0].Distance > 1e-9 {
+		t.Errorf("Expected a cosine distance of 0 for 'same-direction', but got %f", results[0].Distance)
+	}
+}
+
+// Test for SearchDiverse skipping near-duplicates
+func TestSearchDiverse(t *testing.T) {
+	hnswIndex := NewHNSW(5, 4)
+
+	// A tight cluster of duplicates right next to the query
+	for i := 0; i < 10; i++ {
+		id := fmt.Sprintf("dup-%d", i)
+		hnswIndex.AddVector(id, Vector{ID: id, Values: []float64{1 + float64(i)*0.001, 0}})
+	}
+	// Spread-out items further away
+	for i := 0; i < 5; i++ {
+		id := fmt.Sprintf("spread-%d", i)
+		hnswIndex.AddVector(id, Vector{ID: id, Values: []float64{0, 5 + float64(i)*5}})
+	}
+
+	query := Vector{Values: []float64{0, 0}}
+	results := hnswIndex.SearchDiverse(query, 4, 1)
+	if len(results) != 4 {
+		t.Fatalf("Expected 4 diverse results, but got %d", len(results))
+	}
+
+	duplicates := 0
+	for i, result := range results {
+		if result.ID[:3] == "dup" {
+			duplicates++
+		}
+		for _, other := range results[i+1:] {
+			if d := euclideanDistance(result.Vector, other.Vector); d < 1 {
+				t.Errorf("Expected results at least 1 apart, but '%s' and '%s' are %f apart", result.ID, other.ID, d)
+			}
+		}
+	}
+	if duplicates != 1 {
+		t.Errorf("Expected exactly one item from the duplicate cluster, but got %d", duplicates)
+	}
+	if results[0].ID != "dup-0" {
+		t.Errorf("Expected the nearest item 'dup-0' first, but got '%s'", results[0].ID)
+	}
+}
+
+// Test for SearchLevel only returning the nodes on the searched level
+func TestSearchLevel(t *testing.T) {
+	hnswIndex := NewHNSW(3, 3)
+	for level := 0; level < 3; level++ {
+		for i := 0; i < 4; i++ {
+			id := fmt.Sprintf("level-%d-%d", level, i)
+			hnswIndex.AddVectorAtLevel(id, Vector{ID: id, Values: []float64{float64(10*level + i), 0}}, level)
+		}
+	}
+
+	query := Vector{Values: []float64{25, 0}}
+	for level := 0; level < 3; level++ {
+		results, err := hnswIndex.SearchLevel(query, 100, level)
+		if err != nil {
+			t.Fatalf("Error searching level %d: %v", level, err)
+		}
+		// Pinned nodes are on their level and every level below it
+		if expected := 4 * (level + 1); len(results) != expected {
+			t.Errorf("Expected %d results on level %d, but got %d", expected, level, len(results))
+		}
+		for _, result := range results {
+			if _, ok := hnswIndex.levels[level][result.ID]; !ok {
+				t.Errorf("Expected only level %d nodes, but got %s", level, result.ID)
+			}
+		}
+	}
+
+	// The nearest node on the top level is the nearest of the level 0 nodes
+	results, _ := hnswIndex.SearchLevel(query, 1, 0)
+	if results[0].ID != "level-0-3" {
+		t.Errorf("Expected level-0-3 nearest on the top level, but got %s", results[0].ID)
+	}
+
+	for _, level := range []int{-1, 3} {
+		if _, err := hnswIndex.SearchLevel(query, 1, level); err == nil {
+			t.Errorf("Expected an error for level %d, but got nil", level)
+		}
+	}
+}
+
+// Test for SearchContext returning partial results once the deadline passes
+func TestSearchContext(t *testing.T) {
+	hnswIndex := NewHNSW(5, 4, WithMaxInsertCandidates(16))
+	for i := 0; i < 1000; i++ {
+		hnswIndex.AddVector(fmt.Sprintf("vec-%d", i), generateRandomVector(16))
+	}
+	query := generateRandomVector(16)
+
+	// An expired deadline stops the scan after the first batch
+	ctx, cancel := context.WithTimeout(context.Background(), time.Nanosecond)
+	defer cancel()
+	time.Sleep(time.Millisecond)
+	results, err := hnswIndex.SearchContext(ctx, query, 10)
+	if err != context.DeadlineExceeded {
+		t.Errorf("Expected context.DeadlineExceeded, but got %v", err)
+	}
+	if len(results) != 10 {
+		t.Errorf("Expected 10 partial results, but got %d", len(results))
+	}
+
+	// Without a deadline the results are complete
+	results, err = hnswIndex.SearchContext(context.Background(), query, 10)
+	if err != nil {
+		t.Fatalf("Error searching: %v", err)
+	}
+	expected, _ := hnswIndex.SearchWithStats(query, 10)
+	for i := range expected {
+		if results[i].ID != expected[i].ID {
+			t.Errorf("Expected %s at rank %d, but got %s", expected[i].ID, i, results[i].ID)
+		}
+	}
+}
+
+// Test for SearchRecall meeting its recall target on average
+func TestSearchRecall(t *testing.T) {
+	hnswIndex := NewHNSW(5, 4, WithMaxInsertCandidates(16))
+	for i := 0; i < 2000; i++ {
+		hnswIndex.AddVector(fmt.Sprintf("vec-%d", i), generateRandomVector(8))
+	}
+
+	queries := make([]Vector, 100)
+	for i := range queries {
+		queries[i] = generateRandomVector(8)
+	}
+	meanRecall := func(target float64) float64 {
+		var sum float64
+		for _, query := range queries {
+			exact := hnswIndex.bruteForce(query, 10)
+			sum += recallOf(hnswIndex.SearchRecall(query, 10, target), exact)
+		}
+		return sum / float64(len(queries))
+	}
+
+	for _, target := range []float64{0.5, 0.8, 0.95} {
+		if recall := meanRecall(target); recall < target-0.1 {
+			t.Errorf("Expected mean recall near %.2f, but got %.2f", target, recall)
+		}
+	}
+	if recall := meanRecall(1); recall != 1 {
+		t.Errorf("Expected exact results for a target of 1, but got recall %.2f", recall)
+	}
+}
+
+// Test for SearchCombined ranking by a weighted sum of two metrics
+func TestSearchCombined(t *testing.T) {
+	hnswIndex := NewHNSW(5, 4)
+	hnswIndex.AddVector("first-block", Vector{Values: []float64{0, 0, 3, 3}})
+	hnswIndex.AddVector("second-block", Vector{Values: []float64{2, 2, 0, 0}})
+	hnswIndex.AddVector("far", Vector{Values: []float64{5, 5, 5, 5}})
+
+	// Each metric compares one block of the features
+	firstBlock := func(a, b Vector) float64 { return EuclideanRaw(a.Values[:2], b.Values[:2]) }
+	secondBlock := func(a, b Vector) float64 { return EuclideanRaw(a.Values[2:], b.Values[2:]) }
+	metrics := []DistanceFunc{firstBlock, secondBlock}
+	query := Vector{Values: []float64{0, 0, 0, 0}}
+
+	results, err := hnswIndex.SearchCombined(query, 3, metrics, []float64{1, 0.1})
+	if err != nil {
+		t.Fatalf("Error searching: %v", err)
+	}
+	if results[0].ID != "first-block" || results[2].ID != "far" {
+		t.Errorf("Expected first-block first and far last, but got %v", results)
+	}
+	if expected := 0.1 * math.Sqrt(18); math.Abs(results[0].Distance-expected) > 1e-9 {
+		t.Errorf("Expected combined distance %f, but got %f", expected, results[0].Distance)
+	}
+
+	// Shifting the weight to the second block flips the ranking
+	results, _ = hnswIndex.SearchCombined(query, 3, metrics, []float64{0.1, 1})
+	if results[0].ID != "second-block" || results[2].ID != "far" {
+		t.Errorf("Expected second-block first and far last, but got %v", results)
+	}
+
+	if _, err := hnswIndex.SearchCombined(query, 3, metrics, []float64{1}); err == nil {
+		t.Errorf("Expected an error for mismatched weights, but got nil")
+	}
+}
+
+// Test for SearchExactTop1 fixing the top result of a capped search
+func TestSearchExactTop1(t *testing.T) {
+	hnswIndex := NewHNSW(5, 4, WithMaxExpansionsPerLevel(10), WithMaxInsertCandidates(16))
+	for i := 0; i < 500; i++ {
+		hnswIndex.AddVector(fmt.Sprintf("vec-%d", i), generateRandomVector(4))
+	}
+
+	missed := 0
+	for q := 0; q < 20; q++ {
+		query := generateRandomVector(4)
+		exact := hnswIndex.BruteForceSearch(query, 1)[0]
+		if approx, _ := hnswIndex.SearchWithStats(query, 5); approx[0].ID != exact.ID {
+			missed++
+		}
+
+		results := hnswIndex.SearchExactTop1(query, 5)
+		if len(results) != 5 {
+			t.Fatalf("Expected 5 results, but got %d", len(results))
+		}
+		if results[0].ID != exact.ID || results[0].Distance != exact.Distance {
+			t.Errorf("Expected top result %s, but got %s", exact.ID, results[0].ID)
+		}
+		for _, result := range results[1:] {
+			if result.ID == exact.ID {
+				t.Errorf("Expected %s to appear only once", exact.ID)
+			}
+		}
+	}
+	if missed == 0 {
+		t.Errorf("Expected the capped search to miss some true nearest neighbors")
+	}
+}
+
+// Test for SearchRerankFunc with scores inverting the distance ordering
+func TestSearchRerankFunc(t *testing.T) {
+	hnswIndex := NewHNSW(5, 3)
+	for i := 0; i < 10; i++ {
+		id := fmt.Sprintf("vec-%d", i)
+		hnswIndex.AddVectorWithMetadata(id, Vector{ID: id, Values: []float64{float64(i), 0}}, map[string]any{"n": i})
+	}
+	query := Vector{Values: []float64{0, 0}}
+
+	scores := map[string]func(query Vector, r SearchResult, meta map[string]any) float64{
+		"distance": func(query Vector, r SearchResult, meta map[string]any) float64 {
+			return -r.Distance
+		},
+		"metadata": func(query Vector, r SearchResult, meta map[string]any) float64 {
+			return -float64(meta["n"].(int))
+		},
+	}
+	for name, score := range scores {
+		results := hnswIndex.SearchRerankFunc(query, 5, 3, score)
+		if len(results) != 3 {
+			t.Fatalf("Expected 3 results scored by %s, but got %d", name, len(results))
+		}
+		for i, result := range results {
+			if expected := fmt.Sprintf("vec-%d", 4-i); result.ID != expected {
+				t.Errorf("Expected %s at rank %d scored by %s, but got %s", expected, i, name, result.ID)
+			}
+			if result.Distance != float64(i-4) {
+				t.Errorf("Expected the score %v as the distance of %s, but got %v", float64(i-4), result.ID, result.Distance)
+			}
+		}
+	}
+
+	if results := hnswIndex.SearchRerankFunc(query, 5, 0, scores["distance"]); results != nil {
+		t.Errorf("Expected no results for kFinal 0, but got %v", results)
+	}
+}
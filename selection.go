@@ -0,0 +1,76 @@
+package gector
+
+import "math/rand"
+
+// NeighborSelection chooses which candidates a node keeps as neighbors when
+// there are more than MaxNeighbors of them.
+type NeighborSelection int
+
+const (
+	// KeepClosest keeps the MaxNeighbors closest candidates.
+	KeepClosest NeighborSelection = iota
+	// KeepDiverse keeps candidates nearest first, skipping any candidate that
+	// is closer to an already kept neighbor than to the node itself. It is the
+	// HNSW neighbor heuristic, and may keep fewer than MaxNeighbors.
+	KeepDiverse
+	// KeepRandom keeps MaxNeighbors candidates chosen uniformly at random.
+	KeepRandom
+)
+
+// selectNeighbors picks the neighbors of a node from candidates sorted by
+// their distance to it, according to the index's neighbor selection. The
+// result is in order of distance and has capacity MaxNeighbors, so later
+// back-edges can be appended without reallocating.
+func (hnsw *HNSW) selectNeighbors(node *HNSWNode, candidates []string, distances map[string]float64, level int) []string {
+	neighbors := make([]string, 0, hnsw.MaxNeighbors)
+	if len(candidates) <= hnsw.MaxNeighbors {
+		return append(neighbors, candidates...)
+	}
+
+	switch hnsw.neighborSelection {
+	case KeepDiverse:
+		for _, id := range candidates {
+			if len(neighbors) == hnsw.MaxNeighbors {
+				break
+			}
+			candidate := hnsw.levels[level][id]
+			diverse := true
+			for _, keptID := range neighbors {
+				if hnsw.nodeDistance(candidate, hnsw.levels[level][keptID]) < distances[id] {
+					diverse = false
+					break
+				}
+			}
+			if diverse {
+				neighbors = append(neighbors, id)
+			}
+		}
+	case KeepRandom:
+		// Pick positions without replacement, then restore distance order
+		picked := make([]bool, len(candidates))
+		for i := len(candidates) - hnsw.MaxNeighbors; i < len(candidates); i++ {
+			j := hnsw.selectIntn(i + 1)
+			if picked[j] {
+				j = i
+			}
+			picked[j] = true
+		}
+		for i, id := range candidates {
+			if picked[i] {
+				neighbors = append(neighbors, id)
+			}
+		}
+	default:
+		neighbors = append(neighbors, candidates[:hnsw.MaxNeighbors]...)
+	}
+	return neighbors
+}
+
+// selectIntn returns a random number in [0, n) from the index's selection
+// source. The caller must hold the write lock.
+func (hnsw *HNSW) selectIntn(n int) int {
+	if hnsw.selectRng != nil {
+		return hnsw.selectRng.Intn(n)
+	}
+	return rand.Intn(n)
+}
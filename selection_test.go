@@ -0,0 +1,64 @@
+package gector
+
+import (
+	"fmt"
+	"slices"
+	"testing"
+)
+
+// buildSelectionIndex adds three candidates around the origin and then a node
+// at the origin, returning the neighbors chosen for it.
+func buildSelectionIndex(strategy NeighborSelection, seed int64) []string {
+	hnswIndex := NewHNSW(2, 1, WithNeighborSelection(strategy), WithSeed(seed))
+	hnswIndex.AddVector("a", Vector{ID: "a", Values: []float64{1, 0}})
+	hnswIndex.AddVector("b", Vector{ID: "b", Values: []float64{1.1, 0}})
+	hnswIndex.AddVector("c", Vector{ID: "c", Values: []float64{-2, 0}})
+	hnswIndex.AddVector("origin", Vector{ID: "origin", Values: []float64{0, 0}})
+	return hnswIndex.nodes["origin"].Neighbors
+}
+
+// Test for each neighbor selection strategy on a crafted candidate set
+func TestNeighborSelection(t *testing.T) {
+	// The two closest candidates
+	if neighbors := buildSelectionIndex(KeepClosest, 1); !slices.Equal(neighbors, []string{"a", "b"}) {
+		t.Errorf("Expected KeepClosest to keep [a b], but got %v", neighbors)
+	}
+
+	// b is closer to a than to the origin, so the heuristic skips it for c
+	if neighbors := buildSelectionIndex(KeepDiverse, 1); !slices.Equal(neighbors, []string{"a", "c"}) {
+		t.Errorf("Expected KeepDiverse to keep [a c], but got %v", neighbors)
+	}
+
+	// Any two distinct candidates in distance order, and every pair over many seeds
+	seen := make(map[string]bool)
+	for seed := int64(0); seed < 50; seed++ {
+		neighbors := buildSelectionIndex(KeepRandom, seed)
+		if len(neighbors) != 2 || neighbors[0] == neighbors[1] {
+			t.Fatalf("Expected two distinct neighbors from KeepRandom, but got %v", neighbors)
+		}
+		if !slices.IsSortedFunc(neighbors, func(x, y string) int {
+			return slices.Index([]string{"a", "b", "c"}, x) - slices.Index([]string{"a", "b", "c"}, y)
+		}) {
+			t.Errorf("Expected KeepRandom neighbors in distance order, but got %v", neighbors)
+		}
+		seen[neighbors[0]+neighbors[1]] = true
+	}
+	if len(seen) != 3 {
+		t.Errorf("Expected KeepRandom to choose all 3 pairs over 50 seeds, but got %v", seen)
+	}
+}
+
+// Test for KeepRandom leaving the levels of the same seed unchanged
+func TestKeepRandomLevels(t *testing.T) {
+	random := NewHNSW(2, 4, WithNeighborSelection(KeepRandom), WithSeed(7))
+	closest := NewHNSW(2, 4, WithSeed(7))
+	for i := 0; i < 200; i++ {
+		id := fmt.Sprintf("vec-%d", i)
+		vector := generateRandomVector(3)
+		random.AddVector(id, vector)
+		closest.AddVector(id, vector)
+		if got, want := random.topLevel(id), closest.topLevel(id); got != want {
+			t.Fatalf("Expected %s on level %d with KeepRandom, but got %d", id, want, got)
+		}
+	}
+}
@@ -0,0 +1,146 @@
+package gector
+
+import (
+	"fmt"
+	"math"
+	"sync"
+)
+
+// SparseVector is a high-dimensional vector that stores only its nonzero
+// entries. Indices must be strictly increasing and match Values in length.
+type SparseVector struct {
+	Indices []int32
+	Values  []float64
+}
+
+// Validate checks that the indices are strictly increasing and line up with the values.
+func (v SparseVector) Validate() error {
+	if len(v.Indices) != len(v.Values) {
+		return fmt.Errorf("sparse vector has %d indices but %d values", len(v.Indices), len(v.Values))
+	}
+	for i := 1; i < len(v.Indices); i++ {
+		if v.Indices[i] <= v.Indices[i-1] {
+			return fmt.Errorf("sparse vector indices are not strictly increasing at position %d", i)
+		}
+	}
+	return nil
+}
+
+// SparseFromDense converts a dense vector into a sparse one, dropping zeros.
+func SparseFromDense(v Vector) SparseVector {
+	var sparse SparseVector
+	for i, value := range v.Values {
+		if value != 0 {
+			sparse.Indices = append(sparse.Indices, int32(i))
+			sparse.Values = append(sparse.Values, value)
+		}
+	}
+	return sparse
+}
+
+// SparseDot calculates the dot product of two sparse vectors by merging their
+// sorted indices, so only nonzero positions are visited.
+func SparseDot(a, b SparseVector) float64 {
+	var sum float64
+	i, j := 0, 0
+	for i < len(a.Indices) && j < len(b.Indices) {
+		switch {
+		case a.Indices[i] < b.Indices[j]:
+			i++
+		case a.Indices[i] > b.Indices[j]:
+			j++
+		default:
+			sum += a.Values[i] * b.Values[j]
+			i++
+			j++
+		}
+	}
+	return sum
+}
+
+// sparseNorm calculates the L2 norm of a sparse vector.
+func sparseNorm(v SparseVector) float64 {
+	var sum float64
+	for _, value := range v.Values {
+		sum += value * value
+	}
+	return math.Sqrt(sum)
+}
+
+// SparseCosineDistance calculates one minus the cosine similarity of two sparse vectors.
+func SparseCosineDistance(a, b SparseVector) float64 {
+	norms := sparseNorm(a) * sparseNorm(b)
+	if norms == 0 {
+		return 1
+	}
+	return 1 - SparseDot(a, b)/norms
+}
+
+// sparseEntry is a stored sparse vector with its cached inverse norm.
+type sparseEntry struct {
+	vector  SparseVector
+	invNorm float64
+}
+
+// SparseIndex stores sparse vectors and searches them by cosine distance.
+type SparseIndex struct {
+	mu      sync.RWMutex
+	vectors map[string]sparseEntry
+}
+
+// NewSparseIndex creates an empty sparse index.
+func NewSparseIndex() *SparseIndex {
+	return &SparseIndex{
+		vectors: make(map[string]sparseEntry),
+	}
+}
+
+// AddVector adds or replaces a sparse vector in the index. The indices and
+// values are copied, so the caller may reuse them.
+func (idx *SparseIndex) AddVector(id string, vector SparseVector) error {
+	if err := vector.Validate(); err != nil {
+		return err
+	}
+	vector = SparseVector{
+		Indices: append([]int32(nil), vector.Indices...),
+		Values:  append([]float64(nil), vector.Values...),
+	}
+
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	idx.vectors[id] = sparseEntry{
+		vector:  vector,
+		invNorm: inverseNorm(sparseNorm(vector)),
+	}
+	return nil
+}
+
+// DeleteVector removes a sparse vector from the index.
+func (idx *SparseIndex) DeleteVector(id string) {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	delete(idx.vectors, id)
+}
+
+// Search returns the k stored vectors closest to the query by cosine distance.
+// Results carry only the ID and distance, as the vectors are not dense. An
+// invalid query, see SparseVector.Validate, returns nil.
+func (idx *SparseIndex) Search(query SparseVector, k int) []SearchResult {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+
+	if k <= 0 || query.Validate() != nil {
+		return nil
+	}
+	queryInvNorm := inverseNorm(sparseNorm(query))
+	best := make(resultHeap, 0, k)
+	for id, entry := range idx.vectors {
+		best.offer(SearchResult{
+			ID:       id,
+			Distance: 1 - SparseDot(query, entry.vector)*queryInvNorm*entry.invNorm,
+		}, k)
+	}
+	return best.sorted()
+}
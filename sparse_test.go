@@ -0,0 +1,85 @@
+package gector
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"testing"
+)
+
+// Helper function to generate a mostly-zero dense vector
+func generateMostlyZeroVector(dim int) Vector {
+	values := make([]float64, dim)
+	for i := 0; i < dim/10; i++ {
+		values[rand.Intn(dim)] = rand.Float64()
+	}
+	return Vector{Values: values}
+}
+
+// Test that sparse cosine distance matches dense cosine distance on equivalent data
+func TestSparseCosineMatchesDense(t *testing.T) {
+	for i := 0; i < 50; i++ {
+		a := generateMostlyZeroVector(200)
+		b := generateMostlyZeroVector(200)
+
+		dense := cosineDistance(a, b)
+		sparse := SparseCosineDistance(SparseFromDense(a), SparseFromDense(b))
+		if math.Abs(dense-sparse) > 1e-9 {
+			t.Fatalf("Expected sparse cosine %f to match dense cosine %f", sparse, dense)
+		}
+	}
+}
+
+// Test for searching a sparse index against dense cosine search
+func TestSparseIndexSearch(t *testing.T) {
+	sparseIndex := NewSparseIndex()
+	denseIndex := NewHNSW(5, 4)
+	denseIndex.Metric = Cosine
+
+	for i := 0; i < 100; i++ {
+		id := fmt.Sprintf("vec-%d", i)
+		vector := generateMostlyZeroVector(200)
+		if err := sparseIndex.AddVector(id, SparseFromDense(vector)); err != nil {
+			t.Fatalf("Error adding sparse vector: %v", err)
+		}
+		denseIndex.AddVector(id, vector)
+	}
+
+	query := generateMostlyZeroVector(200)
+	sparseResults := sparseIndex.Search(SparseFromDense(query), 5)
+	denseResults, _ := denseIndex.SearchWithStats(query, 5)
+
+	if len(sparseResults) != len(denseResults) {
+		t.Fatalf("Expected %d sparse results, but got %d", len(denseResults), len(sparseResults))
+	}
+	for i := range denseResults {
+		if math.Abs(sparseResults[i].Distance-denseResults[i].Distance) > 1e-9 {
+			t.Errorf("Expected sparse distance %f at %d to match dense distance %f", sparseResults[i].Distance, i, denseResults[i].Distance)
+		}
+	}
+
+	// Malformed sparse vectors are rejected
+	if err := sparseIndex.AddVector("bad", SparseVector{Indices: []int32{3, 1}, Values: []float64{1, 1}}); err == nil {
+		t.Errorf("Expected an error for unsorted indices, but got nil")
+	}
+	if results := sparseIndex.Search(SparseVector{Indices: []int32{1, 1}, Values: []float64{1, 1}}, 5); results != nil {
+		t.Errorf("Expected no results for a query with repeated indices, but got %v", results)
+	}
+}
+
+// Test for SparseIndex keeping its own copy of inserted vectors
+func TestSparseIndexCopiesVectors(t *testing.T) {
+	sparseIndex := NewSparseIndex()
+	vector := SparseVector{Indices: []int32{0, 2}, Values: []float64{1, 1}}
+	if err := sparseIndex.AddVector("a", vector); err != nil {
+		t.Fatalf("Error adding sparse vector: %v", err)
+	}
+
+	// Reusing the caller's buffers does not change the stored vector
+	vector.Indices[1] = 1
+	vector.Values[0] = -1
+	results := sparseIndex.Search(SparseVector{Indices: []int32{0, 2}, Values: []float64{1, 1}}, 1)
+	if len(results) != 1 || math.Abs(results[0].Distance) > 1e-9 {
+		t.Errorf("Expected the stored vector to match its original, but got %v", results)
+	}
+}
@@ -0,0 +1,76 @@
+package gector
+
+import "fmt"
+
+// Spread returns the centroid of all stored vectors and the mean distance
+// from it under the index metric. Both are computed exactly in a single pass
+// over the index. Vectors whose length differs from the index dimension are
+// skipped, and an empty index returns a zero centroid and distance.
+func (hnsw *HNSW) Spread() (centroid Vector, meanDistance float64) {
+	defer hnsw.readUnlock(hnsw.readLock())
+
+	centroid = Vector{Values: make([]float64, hnsw.dim)}
+	count := 0
+	for _, node := range hnsw.nodes {
+		if len(node.Vector.Values) != hnsw.dim {
+			continue
+		}
+		for i, value := range node.Vector.Values {
+			centroid.Values[i] += value
+		}
+		count++
+	}
+	if count == 0 {
+		return centroid, 0
+	}
+	for i := range centroid.Values {
+		centroid.Values[i] /= float64(count)
+	}
+
+	centroidInvNorm := inverseNorm(vectorNorm(centroid))
+	var total float64
+	for _, node := range hnsw.nodes {
+		if len(node.Vector.Values) != hnsw.dim {
+			continue
+		}
+		total += hnsw.queryDistance(centroid, centroidInvNorm, node)
+	}
+	return centroid, total / float64(count)
+}
+
+// Medoid returns the ID among ids of the stored vector with the smallest sum
+// of distances to the others under the index metric. Unlike a centroid, the
+// medoid is always a stored vector. Ties go to the ID given first, and an
+// error is returned for an empty list or an unknown ID.
+func (hnsw *HNSW) Medoid(ids []string) (string, error) {
+	defer hnsw.readUnlock(hnsw.readLock())
+
+	if len(ids) == 0 {
+		return "", fmt.Errorf("no vectors given")
+	}
+	nodes := make([]*HNSWNode, len(ids))
+	for i, id := range ids {
+		node, exists := hnsw.nodes[id]
+		if !exists {
+			return "", notFoundError("medoid", id)
+		}
+		nodes[i] = node
+	}
+
+	sums := make([]float64, len(nodes))
+	for i := range nodes {
+		for j := i + 1; j < len(nodes); j++ {
+			d := hnsw.nodeDistance(nodes[i], nodes[j])
+			sums[i] += d
+			sums[j] += d
+		}
+	}
+
+	best := 0
+	for i := range sums {
+		if sums[i] < sums[best] {
+			best = i
+		}
+	}
+	return ids[best], nil
+}
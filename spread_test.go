@@ -0,0 +1,61 @@
+package gector
+
+import (
+	"fmt"
+	"math"
+	"testing"
+)
+
+// Test for Spread on points evenly placed around a known center
+func TestSpread(t *testing.T) {
+	hnswIndex := NewHNSW(5, 4)
+
+	// Points on a circle of radius 2 around (10, 10)
+	for i := 0; i < 8; i++ {
+		angle := float64(i) * math.Pi / 4
+		id := fmt.Sprintf("vec-%d", i)
+		hnswIndex.AddVector(id, Vector{ID: id, Values: []float64{10 + 2*math.Cos(angle), 10 + 2*math.Sin(angle)}})
+	}
+
+	centroid, meanDistance := hnswIndex.Spread()
+	if math.Abs(centroid.Values[0]-10) > 1e-9 || math.Abs(centroid.Values[1]-10) > 1e-9 {
+		t.Errorf("Expected the centroid at (10, 10), but got %v", centroid.Values)
+	}
+	if math.Abs(meanDistance-2) > 1e-9 {
+		t.Errorf("Expected a mean distance of 2, but got %f", meanDistance)
+	}
+
+	// An empty index has no spread
+	if _, meanDistance := NewHNSW(5, 4).Spread(); meanDistance != 0 {
+		t.Errorf("Expected a mean distance of 0 for an empty index, but got %f", meanDistance)
+	}
+}
+
+// Test for Medoid on a small set with a known medoid
+func TestMedoid(t *testing.T) {
+	hnswIndex := NewHNSW(5, 4)
+	hnswIndex.AddVector("left", Vector{Values: []float64{0, 0}})
+	hnswIndex.AddVector("middle", Vector{Values: []float64{1, 0}})
+	hnswIndex.AddVector("right", Vector{Values: []float64{2, 0}})
+	hnswIndex.AddVector("far", Vector{Values: []float64{10, 0}})
+	hnswIndex.AddVector("outside", Vector{Values: []float64{1.5, 0}})
+
+	medoid, err := hnswIndex.Medoid([]string{"left", "middle", "right", "far"})
+	if err != nil {
+		t.Fatalf("Error computing medoid: %v", err)
+	}
+	// middle sums 1+1+9 = 11 and right sums 2+1+8 = 11; the tie goes to middle
+	if medoid != "middle" {
+		t.Errorf("Expected medoid middle, but got %s", medoid)
+	}
+
+	if medoid, _ := hnswIndex.Medoid([]string{"far"}); medoid != "far" {
+		t.Errorf("Expected the only vector to be the medoid, but got %s", medoid)
+	}
+	if _, err := hnswIndex.Medoid(nil); err == nil {
+		t.Errorf("Expected an error for an empty set, but got nil")
+	}
+	if _, err := hnswIndex.Medoid([]string{"left", "missing"}); err == nil {
+		t.Errorf("Expected an error for an unknown ID, but got nil")
+	}
+}
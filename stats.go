@@ -0,0 +1,100 @@
+package gector
+
+import "math"
+
+// DistanceStats summarizes the distances of a result set.
+type DistanceStats struct {
+	Min    float64
+	Max    float64
+	Mean   float64
+	StdDev float64
+}
+
+// SearchWithStats returns the k nearest neighbors to the query together with
+// statistics over their distances. The stats are zero when there are no results.
+func (hnsw *HNSW) SearchWithStats(query Vector, k int) ([]SearchResult, DistanceStats) {
+	defer hnsw.readUnlock(hnsw.readLock())
+
+	if hnsw.checkDimension("search", query.ID, query) != nil {
+		return nil, DistanceStats{}
+	}
+
+	results := hnsw.processResults(hnsw.search(hnsw.transformQuery(query), k))
+	return results, computeDistanceStats(results)
+}
+
+// computeDistanceStats calculates min, max, mean and population standard deviation.
+func computeDistanceStats(results []SearchResult) DistanceStats {
+	var stats DistanceStats
+	if len(results) == 0 {
+		return stats
+	}
+
+	stats.Min = results[0].Distance
+	stats.Max = results[0].Distance
+	var sum float64
+	for _, result := range results {
+		stats.Min = math.Min(stats.Min, result.Distance)
+		stats.Max = math.Max(stats.Max, result.Distance)
+		sum += result.Distance
+	}
+	stats.Mean = sum / float64(len(results))
+
+	var variance float64
+	for _, result := range results {
+		diff := result.Distance - stats.Mean
+		variance += diff * diff
+	}
+	stats.StdDev = math.Sqrt(variance / float64(len(results)))
+
+	return stats
+}
+
+// EstimateSearchCost returns the approximate number of distance computations
+// of a graph search for k neighbors with the given ef, for admission control
+// before running a query. The model is a greedy descent costing MaxNeighbors
+// computations per hop over about log2(n) hops, followed by a bottom level
+// beam of max(ef, k) candidates each expanding MaxNeighbors neighbors. The
+// estimate is capped at the index size, which is the cost of the exact scan.
+func (hnsw *HNSW) EstimateSearchCost(k, ef int) int {
+	defer hnsw.readUnlock(hnsw.readLock())
+
+	n := len(hnsw.nodes)
+	if n == 0 || k <= 0 {
+		return 0
+	}
+
+	hops := int(math.Ceil(math.Log2(float64(n))))
+	cost := hnsw.MaxNeighbors * (hops + max(ef, k))
+	return min(cost, n)
+}
+
+// IndexStats holds counters describing the state of an index.
+type IndexStats struct {
+	// Number of stored vectors
+	Size int
+	// Number of times a graph traversal, such as Reachability or RelinkNode,
+	// met a neighbor ID with no stored vector. Such IDs are left behind by
+	// deletes; a growing count calls for PruneNeighbors. Searches scan the
+	// stored vectors rather than following links, so they never add to it.
+	MissingNeighbors int64
+}
+
+// Stats returns the counters of the index. It never waits for the index lock.
+func (hnsw *HNSW) Stats() IndexStats {
+	return IndexStats{
+		Size:             int(hnsw.size.Load()),
+		MissingNeighbors: hnsw.missingNeighbors.Load(),
+	}
+}
+
+// neighborNode looks up a neighbor ID met while following links, counting
+// IDs with no stored vector in the MissingNeighbors stat. The caller must
+// hold the lock.
+func (hnsw *HNSW) neighborNode(id string) (*HNSWNode, bool) {
+	node, exists := hnsw.nodes[id]
+	if !exists {
+		hnsw.missingNeighbors.Add(1)
+	}
+	return node, exists
+}
@@ -0,0 +1,108 @@
+package gector
+
+import (
+	"fmt"
+	"math"
+	"testing"
+)
+
+// Test for SearchWithStats with known distances
+func TestSearchWithStats(t *testing.T) {
+	hnswIndex := NewHNSW(5, 4)
+
+	// Distances to the origin are 1, 2, 3 and 4
+	hnswIndex.AddVector("vec-1", Vector{ID: "vec-1", Values: []float64{1, 0}})
+	hnswIndex.AddVector("vec-2", Vector{ID: "vec-2", Values: []float64{0, 2}})
+	hnswIndex.AddVector("vec-3", Vector{ID: "vec-3", Values: []float64{3, 0}})
+	hnswIndex.AddVector("vec-4", Vector{ID: "vec-4", Values: []float64{0, 4}})
+
+	query := Vector{Values: []float64{0, 0}}
+	results, stats := hnswIndex.SearchWithStats(query, 4)
+
+	if len(results) != 4 {
+		t.Fatalf("Expected 4 results, but got %d", len(results))
+	}
+	for i, want := range []string{"vec-1", "vec-2", "vec-3", "vec-4"} {
+		if results[i].ID != want {
+			t.Errorf("Expected result %d to be '%s', but got '%s'", i, want, results[i].ID)
+		}
+	}
+
+	const eps = 1e-9
+	if stats.Min != 1 || stats.Max != 4 {
+		t.Errorf("Expected min 1 and max 4, but got %f and %f", stats.Min, stats.Max)
+	}
+	if math.Abs(stats.Mean-2.5) > eps {
+		t.Errorf("Expected mean 2.5, but got %f", stats.Mean)
+	}
+	if math.Abs(stats.StdDev-math.Sqrt(1.25)) > eps {
+		t.Errorf("Expected stddev %f, but got %f", math.Sqrt(1.25), stats.StdDev)
+	}
+}
+
+// Test for SearchWithStats on an empty index
+func TestSearchWithStatsEmpty(t *testing.T) {
+	hnswIndex := NewHNSW(5, 4)
+
+	results, stats := hnswIndex.SearchWithStats(generateRandomVector(5), 3)
+	if len(results) != 0 {
+		t.Errorf("Expected 0 results for an empty index, but got %d", len(results))
+	}
+	if stats != (DistanceStats{}) {
+		t.Errorf("Expected zero stats for an empty index, but got %+v", stats)
+	}
+}
+
+// Test for EstimateSearchCost rising with ef and k and capped at the index size
+func TestEstimateSearchCost(t *testing.T) {
+	hnswIndex := NewHNSW(5, 4)
+	if cost := hnswIndex.EstimateSearchCost(10, 10); cost != 0 {
+		t.Errorf("Expected cost 0 for an empty index, but got %d", cost)
+	}
+	for i := 0; i < 2000; i++ {
+		hnswIndex.AddVector(fmt.Sprintf("vec-%d", i), generateRandomVector(2))
+	}
+
+	base := hnswIndex.EstimateSearchCost(10, 10)
+	if higherEf := hnswIndex.EstimateSearchCost(10, 50); higherEf <= base {
+		t.Errorf("Expected the estimate to rise with ef, but got %d then %d", base, higherEf)
+	}
+	if higherK := hnswIndex.EstimateSearchCost(100, 10); higherK <= base {
+		t.Errorf("Expected the estimate to rise with k, but got %d then %d", base, higherK)
+	}
+
+	// Never more than the exact scan, which computes every distance
+	_, evaluations := hnswIndex.scan(generateRandomVector(2), 10)
+	if capped := hnswIndex.EstimateSearchCost(10, 100000); capped != evaluations {
+		t.Errorf("Expected the estimate capped at %d, but got %d", evaluations, capped)
+	}
+}
+
+// Test for the MissingNeighbors stat counting stale neighbor IDs
+func TestStatsMissingNeighbors(t *testing.T) {
+	hnswIndex := NewHNSW(5, 4)
+	for i := 0; i < 50; i++ {
+		hnswIndex.AddVector(fmt.Sprintf("vec-%d", i), generateRandomVector(5))
+	}
+	for i := 0; i < 50; i++ {
+		hnswIndex.RelinkNode(fmt.Sprintf("vec-%d", i))
+	}
+	if stats := hnswIndex.Stats(); stats.MissingNeighbors != 0 || stats.Size != 50 {
+		t.Fatalf("Expected 50 vectors and no missing neighbors, but got %+v", stats)
+	}
+
+	// Corrupt the neighbor list of the entry point so a traversal meets it
+	entry := hnswIndex.entryPoint()
+	entry.Neighbors = append(entry.Neighbors, "missing-1", "missing-2")
+	hnswIndex.Reachability()
+	if got := hnswIndex.Stats().MissingNeighbors; got != 2 {
+		t.Errorf("Expected 2 missing neighbors, but got %d", got)
+	}
+
+	// Once pruned, traversals no longer meet them
+	hnswIndex.PruneNeighbors()
+	hnswIndex.Reachability()
+	if got := hnswIndex.Stats().MissingNeighbors; got != 2 {
+		t.Errorf("Expected the count to stay at 2 after pruning, but got %d", got)
+	}
+}
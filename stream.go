@@ -0,0 +1,195 @@
+package gector
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// streamMagic identifies files written by SaveStream.
+const streamMagic = "GCTS"
+
+// streamVersion is the version of the stream format. Version 1 streams,
+// written before weights were stored, are still read.
+const streamVersion = 2
+
+// streamMaxRecordSize bounds the size of a stream record, so a corrupt
+// length cannot allocate up to 4 GB. It leaves room for the header of an
+// index whitening a few thousand dimensions.
+const streamMaxRecordSize = 256 << 20
+
+// SaveStream writes the index as a sequence of records: a header holding the
+// settings of the index, then one record per node in ID order, each framed
+// by its length. Unlike Save, a stream can be loaded record by record and a
+// failed load resumed with LoadResume.
+func (hnsw *HNSW) SaveStream(w io.Writer) error {
+	locked := hnsw.readLock()
+	s := hnsw.snapshot()
+	hnsw.readUnlock(locked)
+
+	if _, err := io.WriteString(w, streamMagic); err != nil {
+		return err
+	}
+	if _, err := w.Write([]byte{streamVersion}); err != nil {
+		return err
+	}
+
+	nodes := s.Nodes
+	s.Nodes = nil
+	if err := writeStreamRecord(w, s); err != nil {
+		return err
+	}
+	for _, n := range nodes {
+		if err := writeStreamRecord(w, n); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// LoadStream reads an index written by SaveStream. When a node record cannot
+// be read, the index holding the nodes loaded so far is returned along with
+// their number and the error, so the load can be continued with LoadResume.
+func LoadStream(r io.Reader) (*HNSW, int, error) {
+	s, err := readStreamHeader(r)
+	if err != nil {
+		return nil, 0, err
+	}
+	hnsw, err := restoreSnapshot(s)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	hnsw.mu.Lock()
+	defer hnsw.mu.Unlock()
+
+	loaded, err := hnsw.loadStreamRecords(r)
+	return hnsw, loaded, err
+}
+
+// LoadResume continues a load that stopped after fromRecord node records,
+// reading the stream again from its start and skipping the records already
+// loaded. It returns the total number of node records loaded, which is the
+// point to resume from should this load fail too.
+func (hnsw *HNSW) LoadResume(r io.ReadSeeker, fromRecord int) (int, error) {
+	hnsw.mu.Lock()
+	defer hnsw.mu.Unlock()
+
+	if hnsw.frozen.Load() {
+		return fromRecord, ErrFrozen
+	}
+
+	if _, err := r.Seek(0, io.SeekStart); err != nil {
+		return fromRecord, err
+	}
+	s, err := readStreamHeader(r)
+	if err != nil {
+		return fromRecord, err
+	}
+	if s.MaxLevels != hnsw.MaxLevels || s.MaxNeighbors != hnsw.MaxNeighbors {
+		return fromRecord, fmt.Errorf("stream was saved from a different index")
+	}
+
+	// Skip the records already loaded without decoding them
+	var size [4]byte
+	for i := 0; i < fromRecord; i++ {
+		if _, err := io.ReadFull(r, size[:]); err != nil {
+			return fromRecord, err
+		}
+		if _, err := r.Seek(int64(binary.LittleEndian.Uint32(size[:])), io.SeekCurrent); err != nil {
+			return fromRecord, err
+		}
+	}
+
+	loaded, err := hnsw.loadStreamRecords(r)
+	return fromRecord + loaded, err
+}
+
+// loadStreamRecords restores node records until the end of the stream and
+// returns how many were restored. The caller must hold the write lock.
+func (hnsw *HNSW) loadStreamRecords(r io.Reader) (int, error) {
+	loaded := 0
+	for {
+		var n snapshotNode
+		if err := readStreamRecord(r, &n); err != nil {
+			if errors.Is(err, io.EOF) {
+				return loaded, nil
+			}
+			return loaded, err
+		}
+		if err := hnsw.restoreNode(n); err != nil {
+			return loaded, err
+		}
+		loaded++
+	}
+}
+
+// readStreamHeader checks the magic and version of a stream and reads its
+// header record.
+func readStreamHeader(r io.Reader) (snapshot, error) {
+	var s snapshot
+	header := make([]byte, len(streamMagic)+1)
+	if _, err := io.ReadFull(r, header); err != nil {
+		return s, err
+	}
+	if string(header[:len(streamMagic)]) != streamMagic {
+		return s, fmt.Errorf("not an index stream")
+	}
+	if version := header[len(streamMagic)]; version < 1 || version > streamVersion {
+		return s, fmt.Errorf("unsupported stream format version %d", header[len(streamMagic)])
+	}
+
+	if err := readStreamRecord(r, &s); err != nil {
+		return s, err
+	}
+	if s.MaxLevels <= 0 {
+		return s, fmt.Errorf("invalid number of levels %d", s.MaxLevels)
+	}
+	return s, nil
+}
+
+// writeStreamRecord gob-encodes v on its own and writes it prefixed with its
+// length.
+func writeStreamRecord(w io.Writer, v any) error {
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(v); err != nil {
+		return err
+	}
+
+	if buf.Len() > streamMaxRecordSize {
+		return fmt.Errorf("stream record of %d bytes exceeds the limit of %d", buf.Len(), streamMaxRecordSize)
+	}
+	var size [4]byte
+	binary.LittleEndian.PutUint32(size[:], uint32(buf.Len()))
+	if _, err := w.Write(size[:]); err != nil {
+		return err
+	}
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+// readStreamRecord reads a record written by writeStreamRecord into v. It
+// returns io.EOF only at a record boundary; a record cut short is
+// io.ErrUnexpectedEOF.
+func readStreamRecord(r io.Reader, v any) error {
+	var size [4]byte
+	if _, err := io.ReadFull(r, size[:]); err != nil {
+		return err
+	}
+
+	length := binary.LittleEndian.Uint32(size[:])
+	if length > streamMaxRecordSize {
+		return fmt.Errorf("stream record of %d bytes exceeds the limit of %d", length, streamMaxRecordSize)
+	}
+	record := make([]byte, length)
+	if _, err := io.ReadFull(r, record); err != nil {
+		if err == io.EOF {
+			return io.ErrUnexpectedEOF
+		}
+		return err
+	}
+	return gob.NewDecoder(bytes.NewReader(record)).Decode(v)
+}
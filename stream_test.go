@@ -0,0 +1,81 @@
+package gector
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// Test for a SaveStream and LoadStream round trip
+func TestSaveStream(t *testing.T) {
+	hnswIndex := NewHNSW(5, 4)
+	for i := 0; i < 50; i++ {
+		id := fmt.Sprintf("vec-%d", i)
+		hnswIndex.AddVectorWithMetadata(id, generateRandomVector(5), map[string]any{"n": i})
+	}
+
+	var buf bytes.Buffer
+	if err := hnswIndex.SaveStream(&buf); err != nil {
+		t.Fatalf("Error saving stream: %v", err)
+	}
+	loaded, records, err := LoadStream(&buf)
+	if err != nil {
+		t.Fatalf("Error loading stream: %v", err)
+	}
+	if records != 50 {
+		t.Errorf("Expected 50 records, but got %d", records)
+	}
+	if !hnswIndex.StructurallyEqual(loaded) {
+		t.Errorf("Expected the loaded index to equal the saved one")
+	}
+	if got := loaded.nodes["vec-7"].Metadata["n"]; got != 7 {
+		t.Errorf("Expected metadata n=7, but got %v", got)
+	}
+}
+
+// Test for resuming a load that failed on a truncated stream
+func TestLoadResume(t *testing.T) {
+	hnswIndex := NewHNSW(5, 4)
+	for i := 0; i < 100; i++ {
+		hnswIndex.AddVector(fmt.Sprintf("vec-%d", i), generateRandomVector(5))
+	}
+
+	var buf bytes.Buffer
+	if err := hnswIndex.SaveStream(&buf); err != nil {
+		t.Fatalf("Error saving stream: %v", err)
+	}
+	full := buf.Bytes()
+
+	// Cut the stream in the middle of a record
+	partial, records, err := LoadStream(bytes.NewReader(full[:len(full)*2/3]))
+	if err == nil {
+		t.Fatalf("Expected an error loading a truncated stream, but got nil")
+	}
+	if records == 0 || records >= 100 || partial.Len() != records {
+		t.Fatalf("Expected a partial load, but got %d records and %d vectors", records, partial.Len())
+	}
+
+	records, err = partial.LoadResume(bytes.NewReader(full), records)
+	if err != nil {
+		t.Fatalf("Error resuming load: %v", err)
+	}
+	if records != 100 {
+		t.Errorf("Expected 100 records after resuming, but got %d", records)
+	}
+	if !hnswIndex.StructurallyEqual(partial) {
+		t.Errorf("Expected the resumed index to equal the saved one")
+	}
+
+	if _, err := NewHNSW(8, 4).LoadResume(bytes.NewReader(full), 0); err == nil {
+		t.Errorf("Expected an error resuming into a different index, but got nil")
+	}
+}
+
+// Test for LoadStream rejecting a record length beyond the limit
+func TestLoadStreamOversizedRecord(t *testing.T) {
+	data := append([]byte(streamMagic), streamVersion, 0xff, 0xff, 0xff, 0xff)
+	if _, _, err := LoadStream(bytes.NewReader(data)); err == nil || !strings.Contains(err.Error(), "exceeds the limit") {
+		t.Errorf("Expected an error for an oversized record, but got %v", err)
+	}
+}
@@ -0,0 +1,188 @@
+package gector
+
+import "sort"
+
+// StructurallyEqual reports whether two indexes hold the same vectors on the
+// same levels with the same neighbor lists. Neighbor order is ignored, and
+// vectors are compared within the receiver's epsilon (see WithEpsilon). The
+// other index is copied under its own lock before the receiver is locked, so
+// two calls comparing the same indexes in opposite directions cannot
+// deadlock.
+func (hnsw *HNSW) StructurallyEqual(other *HNSW) bool {
+	theirs := other.structure()
+
+	defer hnsw.readUnlock(hnsw.readLock())
+
+	if len(hnsw.nodes) != len(theirs.nodes) || len(hnsw.levels) != len(theirs.levels) {
+		return false
+	}
+
+	for id, node := range hnsw.nodes {
+		otherNode, exists := theirs.nodes[id]
+		if !exists || !VectorsAlmostEqual(node.Vector, otherNode.Vector, hnsw.epsilon) {
+			return false
+		}
+		if !sameNeighbors(node.Neighbors, otherNode.Neighbors) {
+			return false
+		}
+	}
+
+	for level := range hnsw.levels {
+		if len(hnsw.levels[level]) != len(theirs.levels[level]) {
+			return false
+		}
+		for id := range hnsw.levels[level] {
+			if !theirs.levels[level][id] {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// indexStructure is a copy of the vectors, neighbor lists and level
+// membership of an index, compared by StructurallyEqual.
+type indexStructure struct {
+	nodes  map[string]*HNSWNode
+	levels []map[string]bool
+}
+
+// structure copies the structure of the index under its read lock.
+func (hnsw *HNSW) structure() indexStructure {
+	defer hnsw.readUnlock(hnsw.readLock())
+
+	s := indexStructure{
+		nodes:  make(map[string]*HNSWNode, len(hnsw.nodes)),
+		levels: make([]map[string]bool, len(hnsw.levels)),
+	}
+	for id, node := range hnsw.nodes {
+		s.nodes[id] = &HNSWNode{
+			ID:        id,
+			Vector:    Vector{ID: node.Vector.ID, Values: append([]float64(nil), node.Vector.Values...)},
+			Neighbors: append([]string(nil), node.Neighbors...),
+		}
+	}
+	for level, nodes := range hnsw.levels {
+		s.levels[level] = make(map[string]bool, len(nodes))
+		for id := range nodes {
+			s.levels[level][id] = true
+		}
+	}
+	return s
+}
+
+// sameNeighbors compares two neighbor lists regardless of order.
+func sameNeighbors(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	sortedA := append([]string(nil), a...)
+	sortedB := append([]string(nil), b...)
+	sort.Strings(sortedA)
+	sort.Strings(sortedB)
+	for i := range sortedA {
+		if sortedA[i] != sortedB[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// Reachability returns the fraction of stored vectors reachable from the
+// entry point by following neighbor links, a breadth-first search over the
+// directed graph. Values well below 1 mean parts of the graph can only be
+// found by an exhaustive scan. An empty index is fully reachable.
+func (hnsw *HNSW) Reachability() float64 {
+	defer hnsw.readUnlock(hnsw.readLock())
+
+	entry := hnsw.entryPoint()
+	if entry == nil {
+		return 1
+	}
+
+	visited := map[string]bool{entry.ID: true}
+	queue := []*HNSWNode{entry}
+	for len(queue) > 0 {
+		node := queue[0]
+		queue = queue[1:]
+		for _, neighborID := range node.Neighbors {
+			if visited[neighborID] {
+				continue
+			}
+			neighbor, exists := hnsw.neighborNode(neighborID)
+			if !exists {
+				continue
+			}
+			visited[neighborID] = true
+			queue = append(queue, neighbor)
+		}
+	}
+	return float64(len(visited)) / float64(len(hnsw.nodes))
+}
+
+// entryPoint returns the node with the smallest ID on the sparsest non-empty
+// level, or nil for an empty index. The caller must hold the lock.
+func (hnsw *HNSW) entryPoint() *HNSWNode {
+	for level := 0; level < hnsw.MaxLevels; level++ {
+		var entry *HNSWNode
+		for id, node := range hnsw.levels[level] {
+			if entry == nil || id < entry.ID {
+				entry = node
+			}
+		}
+		if entry != nil {
+			return entry
+		}
+	}
+	return nil
+}
+
+// EntryCandidates returns the IDs of the nodes above the bottom level, the
+// ones searches and inserts start from, ordered from the sparsest level down
+// and by ID within a level. A warm-up routine can touch them after loading a
+// replica to avoid slow first queries. When every node sits on the bottom
+// level only the entry point is returned, and an empty index returns nil.
+func (hnsw *HNSW) EntryCandidates() []string {
+	defer hnsw.readUnlock(hnsw.readLock())
+
+	var ids []string
+	seen := make(map[string]bool)
+	for level := 0; level < hnsw.MaxLevels-1; level++ {
+		start := len(ids)
+		for id := range hnsw.levels[level] {
+			if !seen[id] {
+				seen[id] = true
+				ids = append(ids, id)
+			}
+		}
+		sort.Strings(ids[start:])
+	}
+	if len(ids) == 0 {
+		if entry := hnsw.entryPoint(); entry != nil {
+			ids = []string{entry.ID}
+		}
+	}
+	return ids
+}
+
+// DegreeHistogram returns, for every populated level, how many nodes on it
+// have each out-degree: level -> degree -> count. A node keeps one neighbor
+// list, chosen on its top level, so it counts with the same degree on every
+// level it is present on. Many nodes at degree 0 or at MaxNeighbors hint
+// that MaxNeighbors is too high or too low for the data.
+func (hnsw *HNSW) DegreeHistogram() map[int]map[int]int {
+	defer hnsw.readUnlock(hnsw.readLock())
+
+	histogram := make(map[int]map[int]int)
+	for level := 0; level < hnsw.MaxLevels; level++ {
+		if len(hnsw.levels[level]) == 0 {
+			continue
+		}
+		degrees := make(map[int]int)
+		for _, node := range hnsw.levels[level] {
+			degrees[len(node.Neighbors)]++
+		}
+		histogram[level] = degrees
+	}
+	return histogram
+}
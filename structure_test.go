@@ -0,0 +1,216 @@
+package gector
+
+import (
+	"fmt"
+	"slices"
+	"testing"
+	"time"
+)
+
+// Test for StructurallyEqual on indexes built with the same seed
+func TestStructurallyEqual(t *testing.T) {
+	vectors := make([]Vector, 100)
+	for i := range vectors {
+		vectors[i] = generateRandomVector(5)
+	}
+
+	build := func(seed int64) *HNSW {
+		hnswIndex := NewHNSW(5, 6, WithSeed(seed))
+		for i, vector := range vectors {
+			hnswIndex.AddVector(fmt.Sprintf("vec-%d", i), vector)
+		}
+		return hnswIndex
+	}
+
+	first := build(42)
+	second := build(42)
+	if !first.StructurallyEqual(second) {
+		t.Errorf("Expected indexes built with the same seed to be structurally equal")
+	}
+
+	// Changing a neighbor list breaks equality
+	second.nodes["vec-10"].Neighbors = []string{"vec-0"}
+	if first.StructurallyEqual(second) {
+		t.Errorf("Expected indexes with different neighbor lists to differ")
+	}
+
+	// A different seed promotes nodes differently
+	if first.StructurallyEqual(build(7)) {
+		t.Errorf("Expected indexes built with different seeds to differ")
+	}
+}
+
+// Test for StructurallyEqual not holding the receiver's lock while waiting
+// for the other index, which deadlocks calls comparing the same indexes in
+// opposite directions once writers queue on both
+func TestStructurallyEqualLockOrder(t *testing.T) {
+	first := NewHNSW(5, 4, WithSeed(1))
+	second := NewHNSW(5, 4, WithSeed(1))
+	for i := 0; i < 10; i++ {
+		id := fmt.Sprintf("vec-%d", i)
+		vector := generateRandomVector(3)
+		first.AddVector(id, vector)
+		second.AddVector(id, vector)
+	}
+
+	// A writer holds the other index while the comparison starts
+	second.mu.Lock()
+	compared := make(chan bool)
+	go func() {
+		compared <- first.StructurallyEqual(second)
+	}()
+	time.Sleep(50 * time.Millisecond)
+
+	locked := make(chan struct{})
+	go func() {
+		first.mu.Lock()
+		close(locked)
+	}()
+	select {
+	case <-locked:
+	case <-time.After(5 * time.Second):
+		second.mu.Unlock()
+		t.Fatalf("Expected the receiver to stay unlocked while waiting for the other index")
+	}
+	first.mu.Unlock()
+	second.mu.Unlock()
+
+	if !<-compared {
+		t.Errorf("Expected the indexes to be structurally equal")
+	}
+}
+
+// Test for WithEpsilon treating near-identical vectors as equal
+func TestWithEpsilon(t *testing.T) {
+	build := func(offset float64, opts ...Option) *HNSW {
+		hnswIndex := NewHNSW(3, 3, append(opts, WithSeed(1))...)
+		for i := 0; i < 10; i++ {
+			id := fmt.Sprintf("vec-%d", i)
+			hnswIndex.AddVector(id, Vector{ID: id, Values: []float64{float64(i) + offset, 1}})
+		}
+		return hnswIndex
+	}
+
+	exact := build(0)
+	if exact.StructurallyEqual(build(1e-12)) {
+		t.Errorf("Expected vectors differing in their last bits to differ without an epsilon")
+	}
+	tolerant := build(0, WithEpsilon(1e-9))
+	if !tolerant.StructurallyEqual(build(1e-12)) {
+		t.Errorf("Expected near-identical vectors to be equal under the epsilon")
+	}
+
+	// A near-identical update is detected as unchanged and keeps the stored values
+	tolerant.UpdateVector("vec-3", Vector{ID: "vec-3", Values: []float64{3 + 1e-12, 1}})
+	if stored, _ := tolerant.GetVector("vec-3"); stored.Values[0] != 3 {
+		t.Errorf("Expected the near-identical update to be skipped, but got %v", stored.Values)
+	}
+	tolerant.UpdateVector("vec-3", Vector{ID: "vec-3", Values: []float64{3.5, 1}})
+	if stored, _ := tolerant.GetVector("vec-3"); stored.Values[0] != 3.5 {
+		t.Errorf("Expected the changed update to be applied, but got %v", stored.Values)
+	}
+}
+
+// Test for Reachability on a graph with back-edges and on a disconnected one
+func TestReachability(t *testing.T) {
+	hnswIndex := NewHNSW(5, 4, WithSeed(1))
+	if got := hnswIndex.Reachability(); got != 1 {
+		t.Errorf("Expected an empty index to be fully reachable, but got %f", got)
+	}
+	for i := 0; i < 200; i++ {
+		hnswIndex.AddVector(fmt.Sprintf("vec-%d", i), generateRandomVector(5))
+	}
+
+	// Relinking adds the back-edges that make the graph navigable
+	for i := 0; i < 200; i++ {
+		hnswIndex.RelinkNode(fmt.Sprintf("vec-%d", i))
+	}
+	if got := hnswIndex.Reachability(); got < 0.9 {
+		t.Errorf("Expected reachability near 1 after relinking, but got %f", got)
+	}
+
+	// Without links only the entry point is reachable
+	for _, node := range hnswIndex.nodes {
+		node.Neighbors = nil
+	}
+	if got := hnswIndex.Reachability(); got != 1.0/200 {
+		t.Errorf("Expected reachability %f for a disconnected graph, but got %f", 1.0/200, got)
+	}
+}
+
+// Test for EntryCandidates returning the nodes on the upper levels
+func TestEntryCandidates(t *testing.T) {
+	hnswIndex := NewHNSW(5, 4)
+	if ids := hnswIndex.EntryCandidates(); ids != nil {
+		t.Errorf("Expected no entry candidates for an empty index, but got %v", ids)
+	}
+	for i := 0; i < 300; i++ {
+		id := fmt.Sprintf("v%d", i)
+		hnswIndex.AddVector(id, generateRandomVector(5))
+	}
+
+	ids := hnswIndex.EntryCandidates()
+	if len(ids) == 0 || len(ids) >= 300 {
+		t.Fatalf("Expected a proper subset of the vectors as entry candidates, but got %d", len(ids))
+	}
+	if entry := hnswIndex.entryPoint(); ids[0] != entry.ID {
+		t.Errorf("Expected the entry point %s first, but got %s", entry.ID, ids[0])
+	}
+	previous := -1
+	for _, id := range ids {
+		level := hnswIndex.topLevel(id)
+		if level >= hnswIndex.MaxLevels-1 {
+			t.Errorf("Expected %s to be above the bottom level, but got level %d", id, level)
+		}
+		if level < previous {
+			t.Errorf("Expected candidates ordered from the sparsest level, but %s at level %d follows level %d", id, level, previous)
+		}
+		previous = level
+	}
+	for id := range hnswIndex.nodes {
+		if hnswIndex.topLevel(id) < hnswIndex.MaxLevels-1 && !slices.Contains(ids, id) {
+			t.Errorf("Expected upper-level node %s among the entry candidates", id)
+		}
+	}
+}
+
+// Test for DegreeHistogram counting every node on every level once
+func TestDegreeHistogram(t *testing.T) {
+	hnswIndex := NewHNSW(4, 3)
+	if histogram := hnswIndex.DegreeHistogram(); len(histogram) != 0 {
+		t.Errorf("Expected an empty histogram for an empty index, but got %v", histogram)
+	}
+	for i := 0; i < 100; i++ {
+		hnswIndex.AddVector(fmt.Sprintf("v%d", i), generateRandomVector(3))
+	}
+
+	histogram := hnswIndex.DegreeHistogram()
+	bottom := hnswIndex.MaxLevels - 1
+	if _, ok := histogram[bottom]; !ok {
+		t.Fatalf("Expected the bottom level in the histogram, but got %v", histogram)
+	}
+	for level, degrees := range histogram {
+		total := 0
+		for degree, count := range degrees {
+			if degree < 0 || degree > hnswIndex.MaxNeighbors {
+				t.Errorf("Expected degrees within [0, %d], but got %d on level %d", hnswIndex.MaxNeighbors, degree, level)
+			}
+			total += count
+		}
+		if total != len(hnswIndex.levels[level]) {
+			t.Errorf("Expected level %d to count %d nodes, but got %d", level, len(hnswIndex.levels[level]), total)
+		}
+	}
+	if total := sumCounts(histogram[bottom]); total != 100 {
+		t.Errorf("Expected the bottom level to count all 100 nodes, but got %d", total)
+	}
+}
+
+// Helper function to sum the counts of a degree histogram
+func sumCounts(degrees map[int]int) int {
+	total := 0
+	for _, count := range degrees {
+		total += count
+	}
+	return total
+}
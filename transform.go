@@ -0,0 +1,21 @@
+package gector
+
+// transformInsert applies the index's whitening and random projection to a
+// vector being inserted, generating the projection matrix on the first
+// insert. The caller must hold the write lock.
+func (hnsw *HNSW) transformInsert(v Vector) Vector {
+	v = hnsw.whitener.transform(v)
+	if hnsw.projection == nil {
+		return v
+	}
+	hnsw.projection.init(len(v.Values))
+	return hnsw.projection.project(v)
+}
+
+// transformQuery applies the index's whitening and random projection to a
+// query, so it is compared in the same space as the stored vectors. Public
+// search methods transform their query once on entry; the internal search
+// helpers expect transformed queries.
+func (hnsw *HNSW) transformQuery(v Vector) Vector {
+	return hnsw.projection.project(hnsw.whitener.transform(v))
+}
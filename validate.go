@@ -0,0 +1,19 @@
+package gector
+
+import "sort"
+
+// CheckDimensions returns the sorted IDs of all stored vectors whose length
+// differs from the index dimension, which is captured from the first insert.
+// A non-empty result means the index holds corrupt or mismatched vectors.
+func (hnsw *HNSW) CheckDimensions() []string {
+	defer hnsw.readUnlock(hnsw.readLock())
+
+	var mismatched []string
+	for id, node := range hnsw.nodes {
+		if len(node.Vector.Values) != hnsw.dim {
+			mismatched = append(mismatched, id)
+		}
+	}
+	sort.Strings(mismatched)
+	return mismatched
+}
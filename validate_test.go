@@ -0,0 +1,27 @@
+package gector
+
+import (
+	"fmt"
+	"testing"
+)
+
+// Test for CheckDimensions flagging a wrong-dimension node
+func TestCheckDimensions(t *testing.T) {
+	hnswIndex := NewHNSW(5, 4)
+	for i := 0; i < 10; i++ {
+		hnswIndex.AddVector(fmt.Sprintf("vec-%d", i), generateRandomVector(5))
+	}
+
+	if mismatched := hnswIndex.CheckDimensions(); len(mismatched) != 0 {
+		t.Fatalf("Expected no mismatched vectors, but got %v", mismatched)
+	}
+
+	// Inject a node with the wrong dimension, as an earlier buggy insert would
+	hnswIndex.nodes["bad"] = &HNSWNode{ID: "bad", Vector: generateRandomVector(3)}
+	hnswIndex.nodes["also-bad"] = &HNSWNode{ID: "also-bad", Vector: generateRandomVector(7)}
+
+	mismatched := hnswIndex.CheckDimensions()
+	if len(mismatched) != 2 || mismatched[0] != "also-bad" || mismatched[1] != "bad" {
+		t.Errorf("Expected [also-bad bad] to be flagged, but got %v", mismatched)
+	}
+}
@@ -1,7 +1,23 @@
 package gector
 
+import "math"
+
 // Vector represents a high-dimensional vector.
 type Vector struct {
 	ID     string
 	Values []float64
 }
+
+// VectorsAlmostEqual reports whether two vectors have the same length and every
+// pair of components differs by at most eps. IDs are not compared.
+func VectorsAlmostEqual(a, b Vector, eps float64) bool {
+	if len(a.Values) != len(b.Values) {
+		return false
+	}
+	for i := 0; i < len(a.Values); i++ {
+		if math.Abs(a.Values[i]-b.Values[i]) > eps {
+			return false
+		}
+	}
+	return true
+}
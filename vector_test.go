@@ -0,0 +1,28 @@
+package gector
+
+import "testing"
+
+// Test for VectorsAlmostEqual with exact, close and distant vectors
+func TestVectorsAlmostEqual(t *testing.T) {
+	a := Vector{ID: "a", Values: []float64{1, 2, 3}}
+
+	// Exact match
+	if !VectorsAlmostEqual(a, Vector{ID: "b", Values: []float64{1, 2, 3}}, 0) {
+		t.Errorf("Expected identical vectors to be equal")
+	}
+
+	// Within epsilon
+	if !VectorsAlmostEqual(a, Vector{Values: []float64{1 + 1e-10, 2, 3 - 1e-10}}, 1e-9) {
+		t.Errorf("Expected vectors within epsilon to be equal")
+	}
+
+	// Outside epsilon
+	if VectorsAlmostEqual(a, Vector{Values: []float64{1, 2, 3.1}}, 1e-9) {
+		t.Errorf("Expected vectors outside epsilon to differ")
+	}
+
+	// Different lengths
+	if VectorsAlmostEqual(a, Vector{Values: []float64{1, 2}}, 1) {
+		t.Errorf("Expected vectors of different lengths to differ")
+	}
+}
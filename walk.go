@@ -0,0 +1,46 @@
+package gector
+
+import "math/rand"
+
+// RandomWalk walks steps neighbor links from the start vector, moving to a
+// uniformly chosen neighbor at each step, and returns how many times each
+// vector was visited. A node without stored neighbors is a dead end: the
+// walk restarts from the start vector, which counts as a visit. The start
+// itself is counted once before the first step. A nil rng uses the index's
+// sampling source (see WithSeed). It returns nil when the start is unknown.
+func (hnsw *HNSW) RandomWalk(startID string, steps int, rng *rand.Rand) map[string]int {
+	defer hnsw.readUnlock(hnsw.readLock())
+
+	start, exists := hnsw.nodes[startID]
+	if !exists {
+		return nil
+	}
+
+	intn := func(n int) int { return rng.Intn(n) }
+	if rng == nil {
+		// Concurrent readers share the random source
+		hnsw.rngMu.Lock()
+		defer hnsw.rngMu.Unlock()
+		intn = hnsw.sampleIntn
+	}
+
+	visits := map[string]int{startID: 1}
+	current := start
+	neighbors := make([]*HNSWNode, 0, hnsw.MaxNeighbors)
+	for step := 0; step < steps; step++ {
+		neighbors = neighbors[:0]
+		for _, neighborID := range current.Neighbors {
+			if neighbor, ok := hnsw.neighborNode(neighborID); ok {
+				neighbors = append(neighbors, neighbor)
+			}
+		}
+
+		if len(neighbors) == 0 {
+			current = start
+		} else {
+			current = neighbors[intn(len(neighbors))]
+		}
+		visits[current.ID]++
+	}
+	return visits
+}
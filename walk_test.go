@@ -0,0 +1,49 @@
+package gector
+
+import (
+	"math/rand"
+	"testing"
+)
+
+// Test for RandomWalk accumulating visits on the reachable nodes of a small graph
+func TestRandomWalk(t *testing.T) {
+	hnswIndex := NewHNSW(5, 1)
+	for _, id := range []string{"a", "b", "c", "d", "island"} {
+		hnswIndex.AddVector(id, generateRandomVector(3))
+	}
+	// a -> b -> c -> a is a cycle, c also leads to the dead end d, and
+	// island is not linked from anywhere
+	hnswIndex.nodes["a"].Neighbors = []string{"b"}
+	hnswIndex.nodes["b"].Neighbors = []string{"c"}
+	hnswIndex.nodes["c"].Neighbors = []string{"a", "d"}
+	hnswIndex.nodes["d"].Neighbors = nil
+	hnswIndex.nodes["island"].Neighbors = []string{"a"}
+
+	visits := hnswIndex.RandomWalk("a", 1000, rand.New(rand.NewSource(1)))
+	total := 0
+	for _, count := range visits {
+		total += count
+	}
+	if total != 1001 {
+		t.Errorf("Expected 1001 visits including the start, but got %d", total)
+	}
+	for _, id := range []string{"a", "b", "c", "d"} {
+		if visits[id] == 0 {
+			t.Errorf("Expected reachable node %s to be visited", id)
+		}
+	}
+	if visits["island"] != 0 {
+		t.Errorf("Expected unreachable node island not to be visited, but got %d", visits["island"])
+	}
+	// Every visit to d is a dead end followed by a restart at a
+	if visits["a"] < visits["d"] {
+		t.Errorf("Expected a to be visited at least as often as d, but got %d and %d", visits["a"], visits["d"])
+	}
+
+	if hnswIndex.RandomWalk("missing", 10, nil) != nil {
+		t.Errorf("Expected nil for an unknown start")
+	}
+	if visits := hnswIndex.RandomWalk("d", 3, nil); visits["d"] != 4 {
+		t.Errorf("Expected a walk from a dead end to stay at its start, but got %v", visits)
+	}
+}
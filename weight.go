@@ -0,0 +1,90 @@
+package gector
+
+import "fmt"
+
+// ScoreFunc combines the distance of a vector to the query with the vector's
+// weight into the score SearchWeighted ranks by, lower being better.
+type ScoreFunc func(distance, weight float64) float64
+
+// DistanceOverWeight scores a vector by its distance divided by its weight, so
+// a weight of 2 ranks a vector as if it were half as far away.
+func DistanceOverWeight(distance, weight float64) float64 {
+	return distance / weight
+}
+
+// SetWeight sets the ranking weight of a stored vector used by SearchWeighted.
+// Vectors default to a weight of 1. The weight is kept by UpdateVector and
+// reset by re-adding the vector. Weights are saved with the index and
+// recorded in the delta log.
+func (hnsw *HNSW) SetWeight(id string, w float64) error {
+	hnsw.mu.Lock()
+	defer hnsw.mu.Unlock()
+
+	if hnsw.frozen.Load() {
+		return ErrFrozen
+	}
+
+	if err := hnsw.setWeight(id, w); err != nil {
+		return err
+	}
+	hnsw.recordWeightDelta(id, w)
+	return nil
+}
+
+// setWeight sets the weight of a stored vector. The caller must hold the
+// write lock.
+func (hnsw *HNSW) setWeight(id string, w float64) error {
+	if !(w > 0) {
+		return fmt.Errorf("weight %f must be positive", w)
+	}
+
+	node, exists := hnsw.nodes[id]
+	if !exists {
+		return notFoundError("weight", id)
+	}
+	node.weight = w
+	hnsw.invalidateCache()
+	return nil
+}
+
+// SearchWeighted returns the k vectors with the lowest score, combining each
+// vector's distance to the query and its weight with score. A nil score uses
+// DistanceOverWeight. The Distance of each result holds its score.
+func (hnsw *HNSW) SearchWeighted(query Vector, k int, score ScoreFunc) []SearchResult {
+	defer hnsw.readUnlock(hnsw.readLock())
+
+	if hnsw.checkDimension("search", query.ID, query) != nil {
+		return nil
+	}
+
+	if k <= 0 {
+		return nil
+	}
+	if score == nil {
+		score = DistanceOverWeight
+	}
+
+	query = hnsw.transformQuery(query)
+	queryInvNorm := inverseNorm(vectorNorm(query))
+	best := make(resultHeap, 0, k)
+	for id, node := range hnsw.nodes {
+		best.offer(SearchResult{
+			ID:       id,
+			Vector:   node.Vector,
+			Distance: score(hnsw.queryDistance(query, queryInvNorm, node), node.rankWeight()),
+			Metadata: hnsw.resultMetadata(node),
+		}, k)
+	}
+
+	results := best.sorted()
+	hnsw.touch(results)
+	return hnsw.processResults(results)
+}
+
+// rankWeight returns the node's weight, defaulting to 1 when unset.
+func (node *HNSWNode) rankWeight() float64 {
+	if node.weight == 0 {
+		return 1
+	}
+	return node.weight
+}
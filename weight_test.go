@@ -0,0 +1,102 @@
+package gector
+
+import (
+	"bytes"
+	"math"
+	"testing"
+)
+
+// Test for SetWeight boosting a vector above closer ones in SearchWeighted
+func TestSearchWeighted(t *testing.T) {
+	hnswIndex := NewHNSW(3, 2)
+	hnswIndex.AddVector("near", Vector{ID: "near", Values: []float64{1, 0}})
+	hnswIndex.AddVector("middle", Vector{ID: "middle", Values: []float64{2, 0}})
+	hnswIndex.AddVector("popular", Vector{ID: "popular", Values: []float64{3, 0}})
+	query := Vector{Values: []float64{0, 0}}
+
+	// On distance alone the popular item ranks last
+	results := hnswIndex.SearchWeighted(query, 3, nil)
+	if results[2].ID != "popular" {
+		t.Fatalf("Expected popular last without a weight, but got %v", results)
+	}
+
+	if err := hnswIndex.SetWeight("popular", 4); err != nil {
+		t.Fatalf("Error setting weight: %v", err)
+	}
+	results = hnswIndex.SearchWeighted(query, 3, nil)
+	if results[0].ID != "popular" || results[0].Distance != 0.75 {
+		t.Errorf("Expected boosted popular first with score 0.75, but got %v", results[0])
+	}
+
+	// A custom combination, here subtracting a log boost
+	logBoost := func(distance, weight float64) float64 {
+		return distance - math.Log2(weight)
+	}
+	results = hnswIndex.SearchWeighted(query, 1, logBoost)
+	if results[0].ID != "near" {
+		t.Errorf("Expected near first under the log boost, but got %s", results[0].ID)
+	}
+
+	// The weight survives an update
+	hnswIndex.UpdateVector("popular", Vector{ID: "popular", Values: []float64{2.5, 0}})
+	if results = hnswIndex.SearchWeighted(query, 1, nil); results[0].ID != "popular" {
+		t.Errorf("Expected the weight to survive UpdateVector, but got %s", results[0].ID)
+	}
+
+	if err := hnswIndex.SetWeight("missing", 2); err == nil {
+		t.Errorf("Expected an error for an unknown ID, but got nil")
+	}
+	if err := hnswIndex.SetWeight("near", 0); err == nil {
+		t.Errorf("Expected an error for a non-positive weight, but got nil")
+	}
+}
+
+// Test for weights surviving every save format and the delta log
+func TestWeightPersistence(t *testing.T) {
+	hnswIndex := NewHNSW(3, 2, WithDeltaLog())
+	replica := NewHNSW(3, 2)
+	hnswIndex.AddVector("a", Vector{ID: "a", Values: []float64{1, 0}})
+	hnswIndex.AddVector("b", Vector{ID: "b", Values: []float64{2, 0}})
+	if err := hnswIndex.SetWeight("b", 4); err != nil {
+		t.Fatalf("Error setting weight: %v", err)
+	}
+	hnswIndex.UpdateVector("b", Vector{ID: "b", Values: []float64{3, 0}})
+
+	for _, delta := range hnswIndex.DeltaSince(0) {
+		if err := replica.Apply(delta); err != nil {
+			t.Fatalf("Error applying delta %d: %v", delta.Seq, err)
+		}
+	}
+
+	var saved, compact, stream bytes.Buffer
+	if err := hnswIndex.Save(&saved); err != nil {
+		t.Fatalf("Error saving: %v", err)
+	}
+	if err := hnswIndex.SaveCompact(&compact, true); err != nil {
+		t.Fatalf("Error saving compact: %v", err)
+	}
+	if err := hnswIndex.SaveStream(&stream); err != nil {
+		t.Fatalf("Error saving stream: %v", err)
+	}
+	loaded, err := Load(&saved)
+	if err != nil {
+		t.Fatalf("Error loading: %v", err)
+	}
+	loadedCompact, err := LoadCompact(&compact)
+	if err != nil {
+		t.Fatalf("Error loading compact: %v", err)
+	}
+	loadedStream, _, err := LoadStream(&stream)
+	if err != nil {
+		t.Fatalf("Error loading stream: %v", err)
+	}
+
+	for name, index := range map[string]*HNSW{"replica": replica, "Load": loaded, "LoadCompact": loadedCompact, "LoadStream": loadedStream} {
+		if w := index.nodes["b"].rankWeight(); w != 4 {
+			t.Errorf("Expected weight 4 on b after %s, but got %f", name, w)
+		}
+		if w := index.nodes["a"].rankWeight(); w != 1 {
+			t.Errorf("Expected the default weight on a after %s, but got %f", name, w)
+		}
+	}
+}
@@ -0,0 +1,178 @@
+package gector
+
+import (
+	"fmt"
+	"math"
+	"sort"
+)
+
+// whitenRegularization is added to every variance before scaling by its
+// inverse square root, so directions without variance are not blown up.
+const whitenRegularization = 1e-9
+
+// jacobiSweeps bounds the sweeps of the Jacobi eigenvalue iteration.
+const jacobiSweeps = 100
+
+// Whitener decorrelates vectors and scales them to unit variance with PCA
+// whitening: a vector v is mapped to Matrix (v - Mean). Its fields are
+// exported so a fitted Whitener can be saved and restored, and it is saved
+// along with any index it is attached to.
+type Whitener struct {
+	// Mean of the fitted sample
+	Mean []float64
+	// Rows are the principal components scaled by the inverse square root
+	// of their variance, ordered by decreasing variance
+	Matrix [][]float64
+}
+
+// Fit learns the mean and whitening matrix from a sample of vectors, which
+// must all have the same dimension.
+func (w *Whitener) Fit(vectors []Vector) error {
+	if len(vectors) < 2 {
+		return fmt.Errorf("whitening needs at least 2 vectors, got %d", len(vectors))
+	}
+	dim := len(vectors[0].Values)
+	for _, v := range vectors {
+		if len(v.Values) != dim {
+			return &VectorError{ID: v.ID, Op: "fit", Want: dim, Got: len(v.Values)}
+		}
+	}
+
+	mean := make([]float64, dim)
+	for _, v := range vectors {
+		for i, value := range v.Values {
+			mean[i] += value
+		}
+	}
+	for i := range mean {
+		mean[i] /= float64(len(vectors))
+	}
+
+	covariance := make([][]float64, dim)
+	for i := range covariance {
+		covariance[i] = make([]float64, dim)
+	}
+	for _, v := range vectors {
+		for i := 0; i < dim; i++ {
+			di := v.Values[i] - mean[i]
+			for j := i; j < dim; j++ {
+				covariance[i][j] += di * (v.Values[j] - mean[j])
+			}
+		}
+	}
+	for i := 0; i < dim; i++ {
+		for j := i; j < dim; j++ {
+			covariance[i][j] /= float64(len(vectors) - 1)
+			covariance[j][i] = covariance[i][j]
+		}
+	}
+
+	values, vectorsByColumn := symmetricEigen(covariance)
+	order := make([]int, dim)
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool {
+		return values[order[a]] > values[order[b]]
+	})
+
+	matrix := make([][]float64, dim)
+	for row, component := range order {
+		scale := 1 / math.Sqrt(math.Max(values[component], 0)+whitenRegularization)
+		matrix[row] = make([]float64, dim)
+		for i := 0; i < dim; i++ {
+			matrix[row][i] = vectorsByColumn[i][component] * scale
+		}
+	}
+
+	w.Mean = mean
+	w.Matrix = matrix
+	return nil
+}
+
+// Transform returns the whitened vector. Vectors whose dimension differs from
+// the fitted one, or any vector when the Whitener is not fitted, are returned
+// unchanged.
+func (w *Whitener) Transform(v Vector) Vector {
+	if len(w.Mean) == 0 || len(v.Values) != len(w.Mean) {
+		return v
+	}
+
+	centered := make([]float64, len(v.Values))
+	for i, value := range v.Values {
+		centered[i] = value - w.Mean[i]
+	}
+	values := make([]float64, len(w.Matrix))
+	for row := range values {
+		values[row] = DotRaw(w.Matrix[row], centered)
+	}
+	return Vector{ID: v.ID, Values: values}
+}
+
+// transform is Transform allowing a nil Whitener, which leaves v unchanged.
+func (w *Whitener) transform(v Vector) Vector {
+	if w == nil {
+		return v
+	}
+	return w.Transform(v)
+}
+
+// symmetricEigen returns the eigenvalues of the symmetric matrix a and its
+// eigenvectors as the columns of a matrix, using the cyclic Jacobi method.
+// a is left unchanged.
+func symmetricEigen(a [][]float64) ([]float64, [][]float64) {
+	n := len(a)
+	m := make([][]float64, n)
+	v := make([][]float64, n)
+	for i := range m {
+		m[i] = append([]float64(nil), a[i]...)
+		v[i] = make([]float64, n)
+		v[i][i] = 1
+	}
+
+	for sweep := 0; sweep < jacobiSweeps; sweep++ {
+		var offDiagonal float64
+		for i := 0; i < n; i++ {
+			for j := i + 1; j < n; j++ {
+				offDiagonal += m[i][j] * m[i][j]
+			}
+		}
+		if offDiagonal < 1e-22 {
+			break
+		}
+
+		for p := 0; p < n; p++ {
+			for q := p + 1; q < n; q++ {
+				if m[p][q] == 0 {
+					continue
+				}
+				// Rotate rows and columns p and q to zero m[p][q]
+				theta := (m[q][q] - m[p][p]) / (2 * m[p][q])
+				t := math.Copysign(1, theta) / (math.Abs(theta) + math.Sqrt(theta*theta+1))
+				c := 1 / math.Sqrt(t*t+1)
+				s := t * c
+				for k := 0; k < n; k++ {
+					mkp, mkq := m[k][p], m[k][q]
+					m[k][p] = c*mkp - s*mkq
+					m[k][q] = s*mkp + c*mkq
+				}
+				for k := 0; k < n; k++ {
+					mpk, mqk := m[p][k], m[q][k]
+					m[p][k] = c*mpk - s*mqk
+					m[q][k] = s*mpk + c*mqk
+				}
+				for k := 0; k < n; k++ {
+					vkp, vkq := v[k][p], v[k][q]
+					v[k][p] = c*vkp - s*vkq
+					v[k][q] = s*vkp + c*vkq
+				}
+			}
+		}
+	}
+
+	values := make([]float64, n)
+	for i := range values {
+		values[i] = m[i][i]
+	}
+	return values, v
+}
@@ -0,0 +1,92 @@
+package gector
+
+import (
+	"bytes"
+	"math"
+	"math/rand"
+	"testing"
+)
+
+// Test for Whitener decorrelating a sample and changing neighbor order sensibly
+func TestWhitener(t *testing.T) {
+	// x varies with standard deviation 10, y with 1, and z follows x
+	rng := rand.New(rand.NewSource(1))
+	sample := make([]Vector, 2000)
+	for i := range sample {
+		x := rng.NormFloat64() * 10
+		sample[i] = Vector{Values: []float64{x, rng.NormFloat64(), x + rng.NormFloat64()}}
+	}
+
+	var w Whitener
+	if err := w.Fit(sample); err != nil {
+		t.Fatalf("Error fitting whitener: %v", err)
+	}
+
+	// The whitened sample has an identity covariance
+	whitened := make([]Vector, len(sample))
+	for i, v := range sample {
+		whitened[i] = w.Transform(v)
+	}
+	for i := 0; i < 3; i++ {
+		for j := 0; j < 3; j++ {
+			var sum float64
+			for _, v := range whitened {
+				sum += v.Values[i] * v.Values[j]
+			}
+			expected := 0.0
+			if i == j {
+				expected = 1
+			}
+			if covariance := sum / float64(len(whitened)-1); math.Abs(covariance-expected) > 1e-6 {
+				t.Errorf("Expected whitened covariance %d,%d to be %f, but got %f", i, j, expected, covariance)
+			}
+		}
+	}
+
+	// Raw distances favor the point that is close along the low variance
+	// axis; whitened distances measure in standard deviations and favor the
+	// point that is close along the high variance axis
+	index := func(opts ...Option) *HNSW {
+		hnswIndex := NewHNSW(3, 2, opts...)
+		hnswIndex.AddVector("along-x", Vector{ID: "along-x", Values: []float64{4, 0, 4}})
+		hnswIndex.AddVector("along-y", Vector{ID: "along-y", Values: []float64{0, 3, 0}})
+		return hnswIndex
+	}
+	query := Vector{Values: []float64{0, 0, 0}}
+
+	results, _ := index().SearchWithStats(query, 1)
+	if results[0].ID != "along-y" {
+		t.Errorf("Expected along-y nearest without whitening, but got %s", results[0].ID)
+	}
+	whitenedIndex := index(WithWhitener(&w))
+	results, _ = whitenedIndex.SearchWithStats(query, 1)
+	if results[0].ID != "along-x" {
+		t.Errorf("Expected along-x nearest with whitening, but got %s", results[0].ID)
+	}
+
+	// The whitener is saved with the index
+	var buf bytes.Buffer
+	if err := whitenedIndex.Save(&buf); err != nil {
+		t.Fatalf("Error saving index: %v", err)
+	}
+	loaded, err := Load(&buf)
+	if err != nil {
+		t.Fatalf("Error loading index: %v", err)
+	}
+	if results, _ = loaded.SearchWithStats(query, 1); results[0].ID != "along-x" {
+		t.Errorf("Expected the loaded index to whiten queries, but got %s", results[0].ID)
+	}
+
+	// And with the compact format
+	buf.Reset()
+	if err := whitenedIndex.SaveCompact(&buf, true); err != nil {
+		t.Fatalf("Error saving compact index: %v", err)
+	}
+	loaded, err = LoadCompact(&buf)
+	if err != nil {
+		t.Fatalf("Error loading compact index: %v", err)
+	}
+	if results, _ = loaded.SearchWithStats(query, 1); results[0].ID != "along-x" {
+		t.Errorf("Expected the compact loaded index to whiten queries, but got %s", results[0].ID)
+	}
+}